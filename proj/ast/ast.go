@@ -3,15 +3,19 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"proj/ir"
 	st "proj/symboltable"
 	"proj/token"
 	"proj/types"
+	"strconv"
+	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	GetSpan() Span
 	TypeCheck(errors []string, symTable *st.SymbolTable) []string
 }
 
@@ -30,8 +34,72 @@ type Stat interface {
 	TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable)
 }
 
+type Span struct {
+	Start token.Position // Position of the first character of the node
+	End   token.Position // Position right after the last character of the node
+}
+
+func (s Span) GetSpan() Span {
+	return s
+}
+
+func semanticError(tok *token.Token, format string, args ...interface{}) string {
+	//Format a semantic error message with the location of the offending token
+	msg := fmt.Sprintf(format, args...)
+	if tok == nil {
+		return fmt.Sprintf("semantic error: %s", msg)
+	}
+	return fmt.Sprintf("%s: semantic error: %s", tok.Pos, msg)
+}
+
+func fieldType(structTy types.Type, field string, symTable *st.SymbolTable) (types.Type, bool) {
+	//Look up the type of a field in the definition of the given struct type
+	if !types.IsStruct(structTy) {
+		return types.UnknownTySig, false
+	}
+	structEntry, exist := symTable.ContainStructure(structTy.GetName())
+	if !exist {
+		return types.UnknownTySig, false
+	}
+	fieldEntry, exist := structEntry.GetValue().LocalSymbolTable.ContainLocally(field)
+	if !exist {
+		return types.UnknownTySig, false
+	}
+	return fieldEntry.GetValue().EntryType, true
+}
+
+// Results are returned in registers, at most one for each argument register
+const maxResults = 8
+
+func isKnown(t types.Type) bool {
+	//Unknown types have already been reported, so they should not produce follow-up errors
+	return t != nil && t.GetType() != types.UnknownTySig
+}
+
+// constValue is the value of an int or bool expression computed at compile time
+type constValue struct {
+	intValue  int
+	boolValue bool
+}
+
+func (c constValue) bits() int {
+	//The value as a register holds it, true is 1
+	if c.boolValue {
+		return 1
+	}
+	return c.intValue
+}
+
+func foldConstant(frag *ir.FuncFrag, value constValue) int {
+	//Register holding the value of a constant expression
+	reg := ir.NewRegister()
+	frag.Body = append(frag.Body, ir.NewMov(reg, value.bits(), ir.AL, ir.IMMEDIATE))
+	return reg
+}
+
 type Program struct {
-	Token             *token.Token
+	Token *token.Token
+	Span
 	Package           *Package
 	Import            *Import
 	Types             *Types
@@ -41,7 +109,7 @@ type Program struct {
 }
 
 func NewProgram(pac *Package, imp *Import, typ *Types, decs *Declarations, funcs *Functions) *Program {
-	return &Program{nil, pac, imp, typ, decs, funcs, nil}
+	return &Program{nil, Span{}, pac, imp, typ, decs, funcs, nil}
 }
 
 func (p *Program) TokenLiteral() string {
@@ -53,8 +121,12 @@ func (p *Program) TokenLiteral() string {
 
 func (p *Program) String() string {
 	out := bytes.Buffer{}
-	out.WriteString(p.Package.String())
-	out.WriteString(p.Import.String())
+	if p.Package != nil {
+		out.WriteString(p.Package.String())
+	}
+	if p.Import != nil {
+		out.WriteString(p.Import.String())
+	}
 	out.WriteString(p.Types.String())
 	out.WriteString(p.Declarations.String())
 	out.WriteString(p.Functions.String())
@@ -67,6 +139,11 @@ func (p *Program) TypeCheck(errors []string, symTable *st.SymbolTable) []string
 	errors = p.Types.TypeCheck(errors, symTable)
 	errors = p.Declarations.TypeCheck(errors, symTable)
 	errors = p.Functions.TypeCheck(errors, symTable)
+	if mainEntry, exist := symTable.ContainFunction("main"); !exist {
+		errors = append(errors, semanticError(nil, "Function main is not defined"))
+	} else if len(mainEntry.GetValue().Parameters) != 0 || mainEntry.GetValue().ReturnType != types.NilTySig {
+		errors = append(errors, semanticError(nil, "Function main must take no parameters and return no value"))
+	}
 	return errors
 }
 
@@ -81,16 +158,37 @@ func (p *Program) PerformSABuild(errors []string, symTable *st.SymbolTable) []st
 }
 
 func (p *Program) TranslateToILoc(symTable *st.SymbolTable) {
+	//Global variables are zero initialized in their own fragment placed before the functions
+	globalFrag := &ir.FuncFrag{Label: ir.GlobalFragLabel, Body: []ir.Instruction{}}
+	ir.ControlFlowFrags = append(ir.ControlFlowFrags, globalFrag)
+	// global arrays are allocated when main starts, the assembly only reserves their words
+	allocations := []ir.Instruction{}
+	for _, decl := range p.Declarations.Declarations {
+		for _, id := range decl.Ids.Idents {
+			reg := ir.NewRegister()
+			globalFrag.Body = append(globalFrag.Body, ir.NewMov(reg, 0, ir.AL, ir.IMMEDIATE), ir.NewStr(reg, -1, -1, id.Id, ir.GLOBALVAR))
+			if arrayTy, isArray := decl.Type.GetType(symTable).(*types.ArrayTy); isArray {
+				reg = ir.NewRegister()
+				allocations = append(allocations, ir.GetNewArrayInst(reg, arrayTy.Len(), ir.IMMEDIATE), ir.NewStr(reg, -1, -1, id.Id, ir.GLOBALVAR))
+			}
+		}
+	}
 	p.Functions.TranslateToILoc(symTable)
+	for _, frag := range ir.ControlFlowFrags {
+		if frag.Label == "main" {
+			frag.Body = append(allocations, frag.Body...)
+		}
+	}
 }
 
 type Package struct {
 	Token *token.Token
+	Span
 	Ident IdentLiteral
 }
 
 func NewPackage(ident IdentLiteral) *Package {
-	return &Package{nil, ident}
+	return &Package{nil, Span{}, ident}
 }
 
 func (p *Package) TokenLiteral() string {
@@ -120,11 +218,12 @@ func (p *Package) PerformSABuild(errors []string, table *st.SymbolTable) []strin
 
 type Import struct {
 	Token *token.Token
+	Span
 	Ident IdentLiteral
 }
 
 func NewImport(ident IdentLiteral) *Import {
-	return &Import{nil, ident}
+	return &Import{nil, Span{}, ident}
 }
 
 func (i *Import) TokenLiteral() string {
@@ -155,16 +254,20 @@ func (i *Import) PerformSABuild(errors []string, symTable *st.SymbolTable) []str
 }
 
 type Types struct {
-	token     *token.Token
+	Token *token.Token
+	Span
 	typedecls []TypeDeclaration
 }
 
 func NewTypes(typedecls []TypeDeclaration) *Types {
-	return &Types{nil, typedecls}
+	return &Types{nil, Span{}, typedecls}
 }
 
 func (t *Types) TokenLiterals() string {
-	return t.token.Literal
+	if t.Token != nil {
+		return t.Token.Literal
+	}
+	panic("Could not determine token literals for types")
 }
 
 func (t *Types) String() string {
@@ -193,14 +296,15 @@ func (t *Types) PerformSABuild(errors []string, symTable *st.SymbolTable) []stri
 }
 
 type TypeDeclaration struct {
-	Token   *token.Token
+	Token *token.Token
+	Span
 	Ident   IdentLiteral
 	Fields  *Fields
 	LocalST *st.SymbolTable
 }
 
 func NewTypeDeclaration(ident IdentLiteral, fields *Fields) *TypeDeclaration {
-	return &TypeDeclaration{nil, ident, fields, nil}
+	return &TypeDeclaration{nil, Span{}, ident, fields, nil}
 }
 
 func (t *TypeDeclaration) TokenLiterals() string {
@@ -233,7 +337,7 @@ func (t *TypeDeclaration) PerformSABuild(errors []string, symTable *st.SymbolTab
 		Create local st from global st and add the fields to local st
 	*/
 	if _, ext := symTable.Contain(t.Ident.Id); ext {
-		errors = append(errors, fmt.Sprintf("Struct name: %s has already been used", t.Ident.Id))
+		errors = append(errors, semanticError(t.Ident.Token, "Struct name: %s has already been used", t.Ident.Id))
 	} else {
 		t.LocalST = st.NewWithFather(symTable, "Struct:"+t.Ident.String())
 		symTable.InsertStructDefinition(t.Ident.Id, types.NewStructTy(t.Ident.Id), *t.LocalST)
@@ -241,7 +345,7 @@ func (t *TypeDeclaration) PerformSABuild(errors []string, symTable *st.SymbolTab
 	typeEntry, _ := symTable.Contain(t.Ident.Id)
 	paraStringList := []string{}
 	for _, decl := range t.Fields.Decls {
-		paraStringList = append(paraStringList, decl.Type.TypeString)
+		paraStringList = append(paraStringList, decl.Ident.Id)
 	}
 	typeEntry.GetValue().ParaNames = paraStringList
 	errors = t.Fields.PerformSABuild(errors, t.LocalST)
@@ -250,11 +354,12 @@ func (t *TypeDeclaration) PerformSABuild(errors []string, symTable *st.SymbolTab
 
 type Fields struct {
 	Token *token.Token
+	Span
 	Decls []Decl
 }
 
 func NewFields(decls []Decl) *Fields {
-	return &Fields{nil, decls}
+	return &Fields{nil, Span{}, decls}
 }
 
 func (f *Fields) TokenLiterals() string {
@@ -293,12 +398,13 @@ func (f *Fields) PerformSABuild(errors []string, symTable *st.SymbolTable) []str
 
 type Decl struct {
 	Token *token.Token
+	Span
 	Ident IdentLiteral
 	Type  *Type
 }
 
 func NewDecl(ident IdentLiteral, Type *Type) *Decl {
-	return &Decl{nil, ident, Type}
+	return &Decl{nil, Span{}, ident, Type}
 }
 
 func (d *Decl) TokenLiterals() string {
@@ -336,30 +442,28 @@ func (d *Decl) PerformSABuild(errors []string, symTable *st.SymbolTable) []strin
 		if valid, insert it into the local symbol table
 	*/
 	if _, ext := symTable.ContainLocally(d.Ident.Id); ext {
-		errors = append(errors, fmt.Sprintf("field name:%s  has already been used", d.Ident.Id))
+		errors = append(errors, semanticError(d.Ident.Token, "field name:%s  has already been used", d.Ident.Id))
 	} else {
 		typeSig := d.Type.GetType(symTable)
-		if typeSig == types.BoolTySig || typeSig == types.IntTySig {
-			symTable.Insert(d.Ident.Id, typeSig)
-		} else if typeSig.GetType() == types.StructTySig { //type struct
-			symTable.InsertStructDefinition(typeSig.GetName(), typeSig, *symTable)
-		} else { //type unknown
-			symTable.Insert(d.Ident.Id, typeSig)
-			errors = append(errors, fmt.Sprintf("Sturct:%s  not declared", d.Type.TypeString))
+		symTable.Insert(d.Ident.Id, typeSig)
+		if !isKnown(typeSig) {
+			errors = append(errors, semanticError(d.Type.Token, "Struct:%s not declared", d.Type.TypeString))
+		} else if types.IsArray(typeSig) {
+			// arrays are allocated with the variable, a field or a parameter would share the cell
+			errors = append(errors, semanticError(d.Type.Token, "%s cannot have the array type %s, use a slice", d.Ident.Id, typeSig.GetName()))
 		}
-		fmt.Sprintf("%s defined with type : %s hahahahaha", d.Ident.Id, typeSig.GetName())
 	}
-	fmt.Printf("Ident:%s defined in symboltable: %s \n", d.Ident.Id, symTable)
 	return errors
 }
 
 type Type struct {
-	Token      *token.Token
+	Token *token.Token
+	Span
 	TypeString string //int,bool or id
 }
 
 func NewType(TypeString string) *Type {
-	return &Type{nil, TypeString}
+	return &Type{nil, Span{}, TypeString}
 }
 
 func (t *Type) TokenLiterals() string {
@@ -379,8 +483,12 @@ func (t *Type) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
 	if t.TypeString == "int" || t.TypeString == "bool" {
 		return errors
 	}
-	if _, exist := symTable.Contain(t.TypeString[1:]); !exist {
-		errors = append(errors, fmt.Sprintf("Structured named %s not defined on line num: %d", t.TypeString, t.Token.Rows))
+	typeSig := t.GetType(symTable)
+	if !isKnown(typeSig) {
+		errors = append(errors, semanticError(t.Token, "Structured named %s not defined", t.TypeString))
+	} else if elemType := elementType(typeSig); elemType != nil && types.ContainsArray(elemType) {
+		// the elements are zero words, an array needs its own heap cell
+		errors = append(errors, semanticError(t.Token, "Type %s not supported, the elements of an array or a slice cannot be arrays", t.TypeString))
 	}
 	return errors
 }
@@ -389,28 +497,62 @@ func (t *Type) GetType(symTable *st.SymbolTable) types.Type {
 	/*
 		Check whether the type has been defined, if not unknown is returned
 	*/
+	return typeOf(t.TypeString, symTable)
+}
+
+func typeOf(typeString string, symTable *st.SymbolTable) types.Type {
+	//The type written as typeString, array and slice types are resolved from their element type
 	var typeSig types.Type
-	if t.TypeString == "" {
+	if typeString == "" {
 		typeSig = types.NilTySig
-	} else if t.TypeString == "int" {
+	} else if typeString == "int" {
 		typeSig = types.IntTySig
-	} else if t.TypeString == "bool" {
+	} else if typeString == "bool" {
 		typeSig = types.BoolTySig
-	} else if _, exist := symTable.ContainStructure(t.TypeString[1:]); exist {
-		typeSig = types.NewStructTy(t.TypeString[1:])
+	} else if typeString == "string" {
+		typeSig = types.StringTySig
+	} else if strings.HasPrefix(typeString, "[") {
+		end := strings.Index(typeString, "]")
+		elemType := typeOf(typeString[end+1:], symTable)
+		if !isKnown(elemType) {
+			return elemType
+		}
+		if end == 1 {
+			return types.NewSliceTy(elemType)
+		}
+		length, err := strconv.Atoi(typeString[1:end])
+		if err != nil {
+			return types.NewUnknownTy(typeString)
+		}
+		typeSig = types.NewArrayTy(elemType, length)
+	} else if _, exist := symTable.ContainStructure(typeString[1:]); exist {
+		typeSig = types.NewStructTy(typeString[1:])
 	} else {
-		typeSig = types.NewUnknownTy(t.TypeString[1:])
+		typeSig = types.NewUnknownTy(typeString[1:])
 	}
 	return typeSig
 }
 
+func elementType(t types.Type) types.Type {
+	//Type of the elements of an array or a slice, nil for any other type
+	switch ty := t.(type) {
+	case *types.ArrayTy:
+		return ty.Elem()
+	case *types.SliceTy:
+		return ty.Elem()
+	}
+	return nil
+}
+
 type Declarations struct {
-	Token        *token.Token
+	Token *token.Token
+	Span
 	Declarations []Declaration
+	Consts       []ConstDecl
 }
 
-func NewDeclarations(decls []Declaration) *Declarations {
-	return &Declarations{nil, decls}
+func NewDeclarations(decls []Declaration, consts []ConstDecl) *Declarations {
+	return &Declarations{nil, Span{}, decls, consts}
 }
 
 func (d *Declarations) TokenLiterals() string {
@@ -422,6 +564,10 @@ func (d *Declarations) TokenLiterals() string {
 
 func (d *Declarations) String() string {
 	out := bytes.Buffer{}
+	for _, dec := range d.Consts {
+		out.WriteString(dec.String())
+		out.WriteString("\n")
+	}
 	for _, dec := range d.Declarations {
 		out.WriteString(dec.String())
 		out.WriteString("\n")
@@ -430,10 +576,13 @@ func (d *Declarations) String() string {
 }
 
 func (d *Declarations) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	//Check whether the declarations already been declared in the given symbol table
+	//Check whether the declarations already been declared in the given symbol table, constants come last so that a variable in a value is reported as not constant
 	for _, decl := range d.Declarations {
 		errors = decl.PerformSABuild(errors, symTable)
 	}
+	for idx := range d.Consts {
+		errors = d.Consts[idx].PerformSABuild(errors, symTable)
+	}
 	return errors
 }
 
@@ -452,12 +601,13 @@ func (d *Declarations) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable)
 
 type Declaration struct {
 	Token *token.Token
-	Ids   *Ids
-	Type  *Type
+	Span
+	Ids  *Ids
+	Type *Type
 }
 
 func NewDeclaration(ids *Ids, Type *Type) *Declaration {
-	return &Declaration{nil, ids, Type}
+	return &Declaration{nil, Span{}, ids, Type}
 }
 
 func (d *Declaration) TokenLiterals() string {
@@ -485,11 +635,9 @@ func (d *Declaration) PerformSABuild(errors []string, symTable *st.SymbolTable)
 	*/
 	for _, id := range d.Ids.Idents {
 		if _, ext := symTable.Contain(id.Id); ext {
-			errors = append(errors, fmt.Sprintf("%s ident has already been used on line num: %d", id.Id, id.Token.Rows))
+			errors = append(errors, semanticError(id.Token, "%s ident has already been used", id.Id))
 		} else {
-			entry := symTable.InsertWithNewReg(id.Id, d.Type.GetType(symTable))
-			fmt.Printf("Ident:%s defined in symboltable: %s \n", id.Id, symTable)
-			fmt.Printf("Entry %s register loc: %d \n", id.Id, entry.GetValue().RegisterLoc)
+			symTable.InsertWithNewReg(id.Id, d.Type.GetType(symTable))
 		}
 	}
 	return errors
@@ -501,21 +649,26 @@ func (d *Declaration) TypeCheck(errors []string, symTable *st.SymbolTable) []str
 }
 
 func (d *Declaration) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	//Local arrays get their zeroed elements, every other variable starts as a zero register
 	for _, id := range d.Ids.Idents {
-		_, exist := table.Contain(id.Id)
+		entry, exist := table.Contain(id.Id)
 		if !exist {
 			panic("fail sa")
 		}
+		if arrayTy, isArray := entry.GetValue().EntryType.(*types.ArrayTy); isArray {
+			frag.Body = append(frag.Body, ir.GetNewArrayInst(entry.GetValue().RegisterLoc, arrayTy.Len(), ir.IMMEDIATE))
+		}
 	}
 }
 
 type Ids struct {
-	Token  *token.Token
+	Token *token.Token
+	Span
 	Idents []IdentLiteral //id literal list
 }
 
 func NewIds(idents []IdentLiteral) *Ids {
-	return &Ids{nil, idents}
+	return &Ids{nil, Span{}, idents}
 }
 
 func (id *Ids) TokenLiterals() string {
@@ -541,12 +694,13 @@ func (id *Ids) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
 }
 
 type Functions struct {
-	Token         *token.Token
+	Token *token.Token
+	Span
 	functionArray []Function
 }
 
 func NewFunctions(funcs []Function) *Functions {
-	return &Functions{nil, funcs}
+	return &Functions{nil, Span{}, funcs}
 }
 
 func (funcs *Functions) TokenLiterals() string {
@@ -566,8 +720,8 @@ func (funcs *Functions) String() string {
 }
 
 func (funcs *Functions) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	for _, function := range funcs.functionArray {
-		errors = function.PerformSABuild(errors, symTable)
+	for idx := range funcs.functionArray {
+		errors = funcs.functionArray[idx].PerformSABuild(errors, symTable)
 	}
 	return errors
 }
@@ -588,17 +742,28 @@ func (funcs *Functions) TranslateToILoc(table *st.SymbolTable) {
 }
 
 type Function struct {
-	Token        *token.Token
+	Token *token.Token
+	Span
+	Receiver     *Decl // Receiver of a method, nil for a plain function
 	Ident        IdentLiteral
 	Parameters   *Parameters
 	ReturnType   *ReturnType
 	Declarations *Declarations
 	Statements   *Statements
+	Closing      *token.Token // '}' ending the body
 	localST      *st.SymbolTable
 }
 
-func NewFunction(ident IdentLiteral, params *Parameters, returnType *ReturnType, declarations *Declarations, statements *Statements) *Function {
-	return &Function{nil, ident, params, returnType, declarations, statements, nil}
+func NewFunction(receiver *Decl, ident IdentLiteral, params *Parameters, returnType *ReturnType, declarations *Declarations, statements *Statements) *Function {
+	return &Function{nil, Span{}, receiver, ident, params, returnType, declarations, statements, nil, nil}
+}
+
+func (f *Function) label() string {
+	//Name of the function in the symbol table and in ILOC, a method is qualified by its struct
+	if f.Receiver == nil {
+		return f.Ident.Id
+	}
+	return st.MethodLabel(strings.TrimPrefix(f.Receiver.Type.TypeString, "*"), f.Ident.Id)
 }
 
 func (f *Function) TokenLiterals() string {
@@ -612,6 +777,11 @@ func (f *Function) String() string {
 	out := bytes.Buffer{}
 	out.WriteString("func")
 	out.WriteString(" ")
+	if f.Receiver != nil {
+		out.WriteString("(")
+		out.WriteString(f.Receiver.String())
+		out.WriteString(") ")
+	}
 	out.WriteString(f.Ident.String())
 	out.WriteString(" ")
 	out.WriteString(f.Parameters.String())
@@ -632,9 +802,12 @@ func (f *Function) PerformSABuild(errors []string, symTable *st.SymbolTable) []s
 		Stores the functionName, parameter types and return types in the global symbol table.
 	*/
 	//fmt.Println("Start function PerformSA")
+	if f.Receiver != nil {
+		return f.performMethodSABuild(errors, symTable)
+	}
 	_, exist := symTable.Contain(f.Ident.Id)
 	if exist {
-		errors = append(errors, fmt.Sprintf("Function name %s already defined", f.Ident.Id))
+		errors = append(errors, semanticError(f.Ident.Token, "Function name %s already defined", f.Ident.Id))
 	}
 	//fmt.Println("localST created")
 	f.localST = st.NewWithFather(symTable, f.Ident.String())
@@ -643,7 +816,36 @@ func (f *Function) PerformSABuild(errors []string, symTable *st.SymbolTable) []s
 	errors = f.ReturnType.PerformSABuild(errors, f.localST)
 	errors = f.Declarations.PerformSABuild(errors, f.localST)
 	errors = f.Statements.PerformSABuild(errors, f.localST)
-	symTable.InsertFunctionEntry(f.Ident.Id, types.NewFuncTy(f.Ident.Id), f.localST, f.Parameters.getParameterTypeArray(symTable), f.ReturnType.Type.GetType(symTable))
+	symTable.InsertFunctionEntry(f.Ident.Id, types.NewFuncTy(f.Ident.Id), f.localST, f.Parameters.getParameterTypeArray(symTable), f.ReturnType.GetType(symTable))
+	return errors
+}
+
+func (f *Function) performMethodSABuild(errors []string, symTable *st.SymbolTable) []string {
+	/*
+		Stores the method in the method table of its receiver's struct. The
+		receiver is a local variable like the parameters, it is not one of the
+		parameter types the arguments are checked against.
+	*/
+	f.localST = st.NewWithFather(symTable, f.label())
+	errors = f.Receiver.PerformSABuild(errors, f.localST)
+	errors = f.Parameters.PerformSABuild(errors, f.localST)
+	errors = f.ReturnType.PerformSABuild(errors, f.localST)
+	errors = f.Declarations.PerformSABuild(errors, f.localST)
+	errors = f.Statements.PerformSABuild(errors, f.localST)
+	receiverType := f.Receiver.GetType(symTable)
+	if !isKnown(receiverType) {
+		return errors
+	}
+	if !types.IsStruct(receiverType) {
+		return append(errors, semanticError(f.Receiver.Type.Token, "Receiver type %s is not a struct pointer", types.TypeString(receiverType)))
+	}
+	structName := receiverType.GetName()
+	if _, exist := symTable.ContainMethod(structName, f.Ident.Id); exist {
+		errors = append(errors, semanticError(f.Ident.Token, "Method %s already defined for %s", f.Ident.Id, structName))
+	} else if _, isField := fieldType(receiverType, f.Ident.Id, symTable); isField {
+		errors = append(errors, semanticError(f.Ident.Token, "%s has both a field and a method named %s", structName, f.Ident.Id))
+	}
+	symTable.InsertMethodEntry(structName, f.Ident.Id, types.NewFuncTy(f.label()), f.localST, f.Parameters.getParameterTypeArray(symTable), f.ReturnType.GetType(symTable))
 	return errors
 }
 
@@ -652,34 +854,42 @@ func (f *Function) TypeCheck(errors []string, symTable *st.SymbolTable) []string
 	errors = f.ReturnType.TypeCheck(errors, f.localST)
 	errors = f.Declarations.TypeCheck(errors, f.localST)
 	errors = f.Statements.TypeCheck(errors, f.localST)
-
+	// a function with results cannot fall off the end of its body
+	if len(f.ReturnType.Types) > 0 && !f.Statements.terminates() {
+		errors = append(errors, semanticError(f.Closing, "missing return"))
+	}
 	return errors
 }
 
 func (f *Function) TranslateToILoc(funcFrag *ir.FuncFrag, symTable *st.SymbolTable) {
 	//Create a funcFrag with the statements using local symbol table
-	funcFrag.Label = f.Ident.Id
+	funcFrag.Label = f.label()
 	var localST *st.SymbolTable
-	entry, exist := symTable.Contain(f.Ident.Id)
+	entry, exist := symTable.ContainFunction(f.label())
 	if exist {
 		localST = entry.GetValue().LocalSymbolTable
 	} else {
 		panic("Fail sa")
 	}
-	//Assign register to parameters
-	entry.GetValue().ParametersRegisterLocList = f.Parameters.GenerateRegisterList(localST)
+	//Assign register to parameters, the receiver of a method is passed before them
+	params := f.Parameters
+	if f.Receiver != nil {
+		params = NewParameters(append([]Decl{*f.Receiver}, f.Parameters.Decls...))
+	}
+	entry.GetValue().ParametersRegisterLocList = params.GenerateRegisterList(localST)
 	f.Declarations.TranslateToILoc(funcFrag, localST)
 	f.Statements.TranslateToILoc(funcFrag, localST)
 }
 
 type Parameters struct {
-	Token   *token.Token
+	Token *token.Token
+	Span
 	Decls   []Decl
 	regList []int
 }
 
 func NewParameters(decls []Decl) *Parameters {
-	return &Parameters{nil, decls, nil}
+	return &Parameters{nil, Span{}, decls, nil}
 }
 
 func (p *Parameters) TokenLiterals() string {
@@ -713,11 +923,7 @@ func (p *Parameters) PerformSABuild(errors []string, symTable *st.SymbolTable) [
 }
 
 func (p *Parameters) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	//for _, decl := range p.Decls {
-	//	if !CheckDeclared(decl.Type.TypeString, errors, symTable){
-	//		errors= append(errors, fmt.Sprintf("struct type %s not defined",decl.Type.TypeString ))
-	//	}
-	//}
+	// Unknown parameter types are reported by Decl.PerformSABuild
 	return errors
 }
 
@@ -770,17 +976,27 @@ func CheckDeclared(typeString string, errors []string, symTable *st.SymbolTable)
 
 type ReturnType struct {
 	Token *token.Token
-	Type  *Type
+	Span
+	Types []Type // Types of the results, empty when the function returns nothing
 }
 
-func NewReturnType(t *Type) *ReturnType {
-	return &ReturnType{nil, t}
+func NewReturnType(ts []Type) *ReturnType {
+	return &ReturnType{nil, Span{}, ts}
 }
 
 func (r *ReturnType) String() string {
 	out := bytes.Buffer{}
-	if r.Type != nil {
-		out.WriteString(r.Type.String())
+	if len(r.Types) == 1 {
+		out.WriteString(r.Types[0].String())
+	} else if len(r.Types) > 1 {
+		out.WriteString("(")
+		for idx, t := range r.Types {
+			if idx > 0 {
+				out.WriteString(", ")
+			}
+			out.WriteString(t.String())
+		}
+		out.WriteString(")")
 	}
 	return out.String()
 }
@@ -796,29 +1012,49 @@ func (r *ReturnType) PerformSABuild(errors []string, symTable *st.SymbolTable) [
 	/*
 		Check whether return type has been declared
 	*/
-	if r.Type == nil {
-		//Nil return
-		return errors
+	for _, t := range r.Types {
+		CheckDeclared(t.TypeString, errors, symTable)
 	}
-	CheckDeclared(r.Type.TypeString, errors, symTable)
 	return errors
 }
 
 func (r *ReturnType) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	// the results are returned in registers, there is one for each of the first 8
+	if len(r.Types) > maxResults {
+		errors = append(errors, semanticError(r.Token, "Functions return at most %d values, found %d", maxResults, len(r.Types)))
+	}
+	for idx := range r.Types {
+		if typeSig := r.Types[idx].GetType(symTable); types.IsArray(typeSig) {
+			errors = append(errors, semanticError(r.Types[idx].Token, "Functions cannot return the array type %s, use a slice", typeSig.GetName()))
+		}
+		errors = r.Types[idx].TypeCheck(errors, symTable)
+	}
 	return errors
 }
 
 func (r *ReturnType) GetType(symTable *st.SymbolTable) types.Type {
-	return r.Type.GetType(symTable)
+	//Nil without results, the type of the result or the tuple of the results
+	if len(r.Types) == 0 {
+		return types.NilTySig
+	}
+	if len(r.Types) == 1 {
+		return r.Types[0].GetType(symTable)
+	}
+	elems := []types.Type{}
+	for idx := range r.Types {
+		elems = append(elems, r.Types[idx].GetType(symTable))
+	}
+	return types.NewTupleTy(elems)
 }
 
 type Statements struct {
-	Token      *token.Token
+	Token *token.Token
+	Span
 	Statements []Statement
 }
 
 func NewStatements(stmts []Statement) *Statements {
-	return &Statements{nil, stmts}
+	return &Statements{nil, Span{}, stmts}
 }
 
 func (s *Statements) TokenLiterals() string {
@@ -840,13 +1076,16 @@ func (s *Statements) String() string {
 func (s *Statements) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
 	for _, statement := range s.Statements {
 		errors = statement.TypeCheck(errors, symTable)
+		symTable = scopeAfter(statement.statExpr, symTable)
 	}
 	return errors
 }
 
 func (s *Statements) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	//Each statement sees the variables declared by the statements before it
 	for _, statement := range s.Statements {
 		errors = statement.PerformSABuild(errors, symTable)
+		symTable = scopeAfter(statement.statExpr, symTable)
 	}
 	return errors
 }
@@ -855,18 +1094,93 @@ func (s *Statements) TranslateToILoc(funcFrag *ir.FuncFrag, symTable *st.SymbolT
 	if symTable == nil {
 		panic("Nill symboltalbe in Statements TranslateToILoc")
 	}
+	frag := funcFrag
+	for _, statement := range s.Statements {
+		statement.TranslateToILoc(frag, symTable)
+		symTable = scopeAfter(statement.statExpr, symTable)
+		//Control flow statements end in a new fragment, the next statement continues there
+		frag = lastFrag()
+	}
+}
+
+func (s *Statements) terminates() bool {
+	//Whether the last statement is a terminating one, the statements after it are never reached
+	if len(s.Statements) == 0 {
+		return false
+	}
+	return terminates(s.Statements[len(s.Statements)-1].statExpr)
+}
+
+func terminates(stat Stat) bool {
+	/*
+		Whether control never flows past the statement: a return, a block ending
+		with one, an if with an else whose branches both terminate, a loop without
+		a condition or a switch with a default whose clauses all terminate. The
+		loop and the switch must not be left by a break.
+	*/
+	switch stat := stat.(type) {
+	case *Return:
+		return true
+	case *Block:
+		return stat.stat.terminates()
+	case *Conditional:
+		return stat.ElseExists && stat.Block.stat.terminates() && stat.ElseBlock.stat.terminates()
+	case *Loop:
+		return stat.Expr == nil && !breaks(stat.Block.stat, stat.Label, true)
+	case *Switch:
+		hasDefault := false
+		for _, clause := range stat.Cases {
+			if clause.Exprs == nil {
+				hasDefault = true
+			}
+			if !clause.Body.stat.terminates() || breaks(clause.Body.stat, stat.Label, true) {
+				return false
+			}
+		}
+		return hasDefault
+	}
+	return false
+}
+
+func breaks(s *Statements, label string, innermost bool) bool {
+	//Whether a break in the statements leaves the loop or switch with the label, or the innermost one around them
 	for _, statement := range s.Statements {
-		statement.TranslateToILoc(funcFrag, symTable)
+		switch stat := statement.statExpr.(type) {
+		case *BranchStmt:
+			if stat.Keyword == "break" && (stat.Label == "" && innermost || stat.Label != "" && stat.Label == label) {
+				return true
+			}
+		case *Block:
+			if breaks(stat.stat, label, innermost) {
+				return true
+			}
+		case *Conditional:
+			if breaks(stat.Block.stat, label, innermost) || stat.ElseExists && breaks(stat.ElseBlock.stat, label, innermost) {
+				return true
+			}
+		case *Loop:
+			if breaks(stat.Block.stat, label, false) {
+				return true
+			}
+		case *Switch:
+			for _, clause := range stat.Cases {
+				if breaks(clause.Body.stat, label, false) {
+					return true
+				}
+			}
+		}
 	}
+	return false
 }
 
 type Statement struct {
-	Token    *token.Token
+	Token *token.Token
+	Span
 	statExpr Stat
 }
 
 func NewStatement(s Stat) *Statement {
-	return &Statement{nil, s}
+	return &Statement{nil, Span{}, s}
 }
 
 func (s *Statement) TokenLiterals() string {
@@ -895,7 +1209,9 @@ func (s *Statement) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 
 type Block struct {
 	Token *token.Token
-	stat  *Statements
+	Span
+	stat    *Statements
+	localST *st.SymbolTable // Scope of the variables declared in the block
 }
 
 func (b *Block) TokenLiteral() string {
@@ -903,7 +1219,7 @@ func (b *Block) TokenLiteral() string {
 }
 
 func NewBlock(stat *Statements) *Block {
-	return &Block{nil, stat}
+	return &Block{nil, Span{}, stat, nil}
 }
 
 func (b *Block) TokenLiterals() string {
@@ -923,27 +1239,30 @@ func (b *Block) String() string {
 }
 
 func (b *Block) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	errors = b.stat.TypeCheck(errors, symTable)
+	errors = b.stat.TypeCheck(errors, b.localST)
 	return errors
 }
 
 func (b *Block) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	errors = b.stat.PerformSABuild(errors, symTable)
+	// the scope is named after the function, which is how return finds its result types
+	b.localST = st.NewWithFather(symTable, symTable.String())
+	errors = b.stat.PerformSABuild(errors, b.localST)
 	return errors
 }
 
 func (b *Block) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	b.stat.TranslateToILoc(frag, table)
+	b.stat.TranslateToILoc(frag, b.localST)
 }
 
 type Assignment struct {
-	Token  *token.Token
+	Token *token.Token
+	Span
 	Lvalue *LValue
 	Expr   *Expression
 }
 
 func NewAssignment(lvalue *LValue, expr *Expression) *Assignment {
-	return &Assignment{nil, lvalue, expr}
+	return &Assignment{nil, Span{}, lvalue, expr}
 }
 
 func (a *Assignment) TokenLiteral() string {
@@ -965,10 +1284,19 @@ func (a *Assignment) String() string {
 
 func (a *Assignment) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
 	//Check whether type of LValue == type of Expression
+	errors = a.Lvalue.TypeCheck(errors, symTable)
+	if call := singleCall([]Expression{*a.Expr}); call != nil && len(types.Results(call.GetType(symTable))) > 1 {
+		// a call returning several values is only reported as the mismatch
+		errors, _ = checkValues(errors, a.Token, 1, []Expression{*a.Expr}, symTable)
+		return errors
+	}
+	errors = a.Expr.TypeCheck(errors, symTable)
 	lt := a.Lvalue.GetType(symTable)
 	rt := a.Expr.GetType(symTable)
-	if lt.GetName() != rt.GetName() {
-		errors = append(errors, fmt.Sprintf("Assignment type error: Expected: %s, Actual: %s on line num: %d", lt, rt, a.Token.Rows))
+	if types.IsArray(lt) {
+		errors = append(errors, semanticError(a.Token, "Cannot assign to the array %s, assign its elements", a.Lvalue.String()))
+	} else if isKnown(lt) && isKnown(rt) && !types.AssignableTo(rt, lt) {
+		errors = append(errors, semanticError(a.Token, "Assignment type error: Expected: %s, Actual: %s", types.TypeString(lt), types.TypeString(rt)))
 	}
 	return errors
 }
@@ -979,187 +1307,655 @@ func (a *Assignment) PerformSABuild(errors []string, symTable *st.SymbolTable) [
 }
 
 func (a *Assignment) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	a.Lvalue.TranslateToILoc(frag, table)
-	a.Expr.TranslateToILoc(frag, table)
-	regLoc := a.Expr.RegisterLoc
-	var inst ir.Instruction
-	if len(a.Lvalue.Idents) == 1 {
-		varName := a.Lvalue.Idents[0].Id
-		if entry, exist := table.ContainGlobally(varName); entry != nil && exist {
-			// global variable assignment
-			inst = ir.NewStr(*regLoc, -1, -1, varName, ir.GLOBALVAR)
-		} else {
-			lvReg := a.Lvalue.RegisterLoc
-			inst = ir.NewMov(lvReg, *regLoc, ir.AL, ir.REGISTER)
-		}
-	} else {
-		// struct field assignment
-		//get struct name
-		frag.Body = append(frag.Body, inst)
-		var structName string
-		countFields := 0
-		if stuctEntry, exist := table.Contain(a.Lvalue.Idents[0].Id); !exist {
-			panic("SA fail")
-		} else {
-			structName = stuctEntry.GetValue().EntryType.GetName()
-			for _, currField := range stuctEntry.GetValue().ParaNames {
-				if a.Lvalue.Idents[1].Id == currField {
-					break
-				}
-				countFields += 1
-			}
-
-		}
-		inst = ir.NewStrRef(*regLoc, a.Lvalue.RegisterLoc, a.Lvalue.Idents[1].Id, structName, countFields)
-		fmt.Println(inst)
+	if len(a.Lvalue.Selectors) != 0 {
+		// field or element assignment, the lvalue register holds the struct or the array
+		a.Lvalue.TranslateToILoc(frag, table)
 	}
-	frag.Body = append(frag.Body, inst)
+	a.Expr.TranslateToILoc(frag, table)
+	a.Lvalue.assign(frag, *a.Expr.RegisterLoc, table)
 }
 
-type Read struct {
+type CompoundAssignment struct {
 	Token *token.Token
-	Ident IdentLiteral
+	Span
+	Lvalue   *LValue
+	Operator string      // '+=' | '-=' | '*=' | '/=' | '%=' | '&=' | '|=' | '^=' | '&^=' | '<<=' | '>>=' | '++' | '--'
+	Expr     *Expression // nil for '++' and '--'
 }
 
-func NewRead(ident IdentLiteral) *Read {
-	return &Read{nil, ident}
+func NewCompoundAssignment(lvalue *LValue, operator string, expr *Expression) *CompoundAssignment {
+	return &CompoundAssignment{nil, Span{}, lvalue, operator, expr}
 }
 
-func (r *Read) TokenLiteral() string {
-	if r.Token != nil {
-		return r.Token.Literal
+func (a *CompoundAssignment) TokenLiteral() string {
+	if a.Token != nil {
+		return a.Token.Literal
 	}
-	panic("Could not determine token literals for read")
+	panic("Could not determine token literals for compound assignment")
 }
 
-func (r *Read) String() string {
+func (a *CompoundAssignment) String() string {
 	out := bytes.Buffer{}
-	out.WriteString("fmt")
-	out.WriteString(".")
-	out.WriteString("Scan")
-	out.WriteString("(")
-	out.WriteString("&")
-	out.WriteString(r.Ident.Id)
-	out.WriteString(")")
+	out.WriteString(a.Lvalue.String())
+	out.WriteString(a.Operator)
+	if a.Expr != nil {
+		out.WriteString(a.Expr.String())
+	}
 	out.WriteString(";")
+	out.WriteString("\n")
 	return out.String()
 }
 
-func (r *Read) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	return errors
+func (a *CompoundAssignment) operator() string {
+	//Binary operator applied to the lvalue, "++" and "--" add and subtract 1
+	if a.Expr == nil {
+		return a.Operator[:1]
+	}
+	return strings.TrimSuffix(a.Operator, "=")
 }
 
-func (r *Read) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	/*
-		Check whether read id has been declared
-	*/
-	if _, exist := symTable.Contain(r.Ident.Id); exist {
+func (a *CompoundAssignment) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	//The lvalue and the value must be ints, strings can only be concatenated with "+="
+	errors = a.Lvalue.TypeCheck(errors, symTable)
+	lt := a.Lvalue.GetType(symTable)
+	rt := types.Type(types.IntTySig)
+	if a.Expr != nil {
+		errors = a.Expr.TypeCheck(errors, symTable)
+		rt = a.Expr.GetType(symTable)
+	}
+	if lt == types.StringTySig && a.Operator != "+=" {
+		errors = append(errors, semanticError(a.Token, "Operator %s is not defined on string", a.Operator))
+	} else if lt == types.StringTySig && isKnown(rt) && rt != types.StringTySig {
+		errors = append(errors, semanticError(a.Token, "Operator + mismatched types: string and %s", rt.GetName()))
+	} else if lt == types.StringTySig {
 		return errors
+	} else if isKnown(lt) && lt != types.IntTySig {
+		errors = append(errors, semanticError(a.Token, "Operator %s expected: int, found: %s", a.Operator, lt.GetName()))
+	} else if isKnown(rt) && rt != types.IntTySig {
+		errors = append(errors, semanticError(a.Expr.Token, "Operator %s expected: int, found: %s", a.Operator, rt.GetName()))
 	}
-	errors = append(errors, fmt.Sprintf("%s has not been declared on line num:%d ", r.Ident.Id, r.Token.Rows))
 	return errors
 }
 
-func (r *Read) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	frag.Body = append(frag.Body, ir.NewRead(table.GetRegisterLoc(r.Ident.Id), r.Ident.Id))
+func (a *CompoundAssignment) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	return a.Lvalue.PerformSABuild(errors, symTable)
 }
 
-type Print struct {
-	Token       *token.Token
-	printMethod string // "Print" | "Println"
-	Ident       IdentLiteral
+func (a *CompoundAssignment) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	/*
+		The lvalue is evaluated once: what the last selector applies to and the
+		index it selects are computed before the value, then the lvalue is loaded
+		and the result stored through the same registers.
+	*/
+	elements, index := -1, -1
+	if len(a.Lvalue.Selectors) != 0 {
+		a.Lvalue.TranslateToILoc(frag, table)
+		if last := &a.Lvalue.Selectors[len(a.Lvalue.Selectors)-1]; last.Index != nil {
+			elements, index = last.element(frag, a.Lvalue.RegisterLoc, a.Lvalue.prefixType(table), table)
+		}
+	}
+	operand, opty := 1, ir.IMMEDIATE
+	if a.Expr != nil {
+		if right, isConst := a.Expr.constant(table); isConst && immediateOperator(a.operator()) {
+			operand = right.intValue
+		} else {
+			a.Expr.TranslateToILoc(frag, table)
+			operand, opty = *a.Expr.RegisterLoc, ir.REGISTER
+		}
+	}
+	current := a.Lvalue.load(frag, elements, index, table)
+	target := ir.NewRegister()
+	if a.Lvalue.GetType(table) == types.StringTySig {
+		frag.Body = append(frag.Body, ir.NewConcat(target, current, operand))
+	} else {
+		frag.Body = append(frag.Body, operatorInstruction(a.operator(), target, current, operand, opty))
+	}
+	a.Lvalue.store(frag, target, elements, index, table)
+}
+
+type TupleAssignment struct {
+	Token *token.Token
+	Span
+	Lvalues []LValue
+	Exprs   []Expression // A single call returning a value for each lvalue, or one value per lvalue
 }
 
-func NewPrint(printMethod string, ident IdentLiteral) *Print {
-	return &Print{nil, printMethod, ident}
+func NewTupleAssignment(lvalues []LValue, exprs []Expression) *TupleAssignment {
+	return &TupleAssignment{nil, Span{}, lvalues, exprs}
 }
 
-func (p *Print) TokenLiteral() string {
-	if p.Token != nil {
-		return p.Token.Literal
+func (a *TupleAssignment) TokenLiteral() string {
+	if a.Token != nil {
+		return a.Token.Literal
 	}
-	panic("Could not determine token literals for print")
+	panic("Could not determine token literals for tuple assignment")
 }
 
-func (p *Print) String() string {
+func (a *TupleAssignment) String() string {
 	out := bytes.Buffer{}
-	out.WriteString("fmt")
-	out.WriteString(".")
-	out.WriteString(p.printMethod)
-	out.WriteString("(")
-	out.WriteString(p.Ident.String())
-	out.WriteString(")")
+	for idx, lvalue := range a.Lvalues {
+		if idx > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(lvalue.String())
+	}
+	out.WriteString("=")
+	for idx, expr := range a.Exprs {
+		if idx > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(expr.String())
+	}
 	out.WriteString(";")
+	out.WriteString("\n")
 	return out.String()
 }
 
-func (p *Print) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+func (a *TupleAssignment) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	//Check the number of values and that each of them can be stored in its lvalue
+	for idx := range a.Lvalues {
+		errors = a.Lvalues[idx].TypeCheck(errors, symTable)
+	}
+	errors, valueTypes := checkValues(errors, a.Token, len(a.Lvalues), a.Exprs, symTable)
+	if valueTypes == nil {
+		return errors
+	}
+	for idx := range a.Lvalues {
+		lt := a.Lvalues[idx].GetType(symTable)
+		rt := valueTypes[idx]
+		if types.IsArray(lt) {
+			errors = append(errors, semanticError(a.Lvalues[idx].Token, "Cannot assign to the array %s, assign its elements", a.Lvalues[idx].String()))
+		} else if isKnown(lt) && isKnown(rt) && !types.AssignableTo(rt, lt) {
+			errors = append(errors, semanticError(a.Lvalues[idx].Token, "Assignment type error: Expected: %s, Actual: %s", types.TypeString(lt), types.TypeString(rt)))
+		}
+	}
 	return errors
 }
 
-func (p *Print) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	// Check whether print id has been declared
-	if _, exist := symTable.Contain(p.Ident.Id); exist {
-		return errors
-	}
-	errors = append(errors, fmt.Sprintf("%s has not been declared on line num: %d", p.Ident.Id, p.Token.Rows))
+func (a *TupleAssignment) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
 	return errors
 }
 
-func (p *Print) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	reg := table.GetRegisterLoc(p.Ident.Id)
-	if p.printMethod == "Print" {
-		frag.Body = append(frag.Body, ir.NewPrint(reg))
-	} else { //Println
-		frag.Body = append(frag.Body, ir.NewPrintln(reg))
+func (a *TupleAssignment) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	/*
+		Every value is computed and copied before the first store, so that
+		"a, b = b, a" swaps the variables
+	*/
+	values := translateValues(frag, len(a.Lvalues), a.Exprs, table)
+	for idx := range a.Lvalues {
+		if len(a.Lvalues[idx].Selectors) != 0 {
+			a.Lvalues[idx].TranslateToILoc(frag, table)
+		}
+		a.Lvalues[idx].assign(frag, values[idx], table)
 	}
 }
 
-type Conditional struct {
-	Token      *token.Token
-	Expr       *Expression
-	Block      *Block
-	ElseExists bool
-	ElseBlock  *Block
+func checkValues(errors []string, tok *token.Token, count int, exprs []Expression, symTable *st.SymbolTable) ([]string, []types.Type) {
+	//Check the values assigned to count variables and return their types, nil when their number is wrong
+	valueTypes := []types.Type{}
+	variables := "variables"
+	if count == 1 {
+		variables = "variable"
+	}
+	if call := singleCall(exprs); call != nil && (count > 1 || len(types.Results(call.GetType(symTable))) > 1) {
+		errors = call.checkCallee(errors, symTable)
+		callType := call.GetType(symTable)
+		if !isKnown(callType) {
+			return errors, nil
+		}
+		valueTypes = types.Results(callType)
+		if len(valueTypes) != count {
+			return append(errors, semanticError(tok, "Assignment mismatch: %d %s but %s returns %d values", count, variables, call.calleeName(), len(valueTypes))), nil
+		}
+		return errors, valueTypes
+	}
+	for idx := range exprs {
+		errors = exprs[idx].TypeCheck(errors, symTable)
+		valueTypes = append(valueTypes, exprs[idx].GetType(symTable))
+	}
+	if len(exprs) != count {
+		return append(errors, semanticError(tok, "Assignment mismatch: %d %s but %d values", count, variables, len(exprs))), nil
+	}
+	return errors, valueTypes
 }
 
-func NewConditional(expr *Expression, block *Block, elseExists bool, elseBlock *Block) *Conditional {
-	return &Conditional{nil, expr, block, elseExists, elseBlock}
+func translateValues(frag *ir.FuncFrag, count int, exprs []Expression, table *st.SymbolTable) []int {
+	/*
+		Registers holding the values assigned to count variables. Every value is
+		computed and copied before the first store, so that "a, b = b, a" swaps
+		the variables.
+	*/
+	if call := singleCall(exprs); call != nil && count > 1 {
+		call.TranslateToILoc(frag, table)
+		return call.results()
+	}
+	values := []int{}
+	for idx := range exprs {
+		exprs[idx].TranslateToILoc(frag, table)
+		value := ir.NewRegister()
+		frag.Body = append(frag.Body, ir.NewMov(value, *exprs[idx].RegisterLoc, ir.AL, ir.REGISTER))
+		values = append(values, value)
+	}
+	return values
 }
 
-func (c *Conditional) TokenLiteral() string {
-	if c.Token != nil {
-		return c.Token.Literal
-	}
-	panic("Could not determine token literals for conditional")
+type VarDecl struct {
+	Token *token.Token
+	Span
+	Short    bool // Whether it is "ids := values", which may also assign variables of the scope
+	Idents   []IdentLiteral
+	Type     *Type           // nil when the variables have the types of the values
+	Exprs    []Expression    // A single call returning a value for each variable, or one value per variable, nil for zero values
+	declared []bool          // Whether each variable is declared by the statement rather than assigned
+	localST  *st.SymbolTable // Scope of the statements after the declaration
 }
 
-func (c *Conditional) String() string {
-	out := bytes.Buffer{}
-	out.WriteString("if")
-	out.WriteString(" ")
-	out.WriteString("(")
-	out.WriteString(c.Expr.String())
-	out.WriteString(")")
-	out.WriteString(" ")
-	out.WriteString(c.Block.String())
-	if c.ElseExists {
-		out.WriteString("else")
-		out.WriteString(c.ElseBlock.String())
-	}
-	return out.String()
+func NewVarDecl(short bool, idents []IdentLiteral, Type *Type, exprs []Expression) *VarDecl {
+	return &VarDecl{nil, Span{}, short, idents, Type, exprs, nil, nil}
 }
 
-func (c *Conditional) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	errors = c.Block.TypeCheck(errors, symTable)
-	if c.ElseExists {
-		errors = c.ElseBlock.TypeCheck(errors, symTable)
+func (d *VarDecl) TokenLiteral() string {
+	if d.Token != nil {
+		return d.Token.Literal
 	}
-	exprType := c.Expr.GetType(symTable)
-	if exprType != types.BoolTySig {
-		errors = append(errors, fmt.Sprintf("Conditional expression type: %s ,expected: bool on line num: %d", exprType.GetName(), c.Expr.Token.Rows))
+	panic("Could not determine token literals for variable declaration")
+}
+
+func (d *VarDecl) String() string {
+	out := bytes.Buffer{}
+	ids := []string{}
+	for _, id := range d.Idents {
+		ids = append(ids, id.String())
+	}
+	exprs := []string{}
+	for _, expr := range d.Exprs {
+		exprs = append(exprs, expr.String())
+	}
+	if d.Short {
+		out.WriteString(strings.Join(ids, ","))
+		out.WriteString(":=")
+		out.WriteString(strings.Join(exprs, ","))
+		out.WriteString(";")
+		return out.String()
+	}
+	out.WriteString("var ")
+	out.WriteString(strings.Join(ids, ","))
+	if d.Type != nil {
+		out.WriteString(" ")
+		out.WriteString(d.Type.String())
+	}
+	if d.Exprs != nil {
+		out.WriteString("=")
+		out.WriteString(strings.Join(exprs, ","))
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (d *VarDecl) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	/*
+		The variables go into a table extending the scope, so that only the
+		statements after the declaration see them. A short declaration assigns the
+		variables already declared in the scope, it must declare one at least.
+	*/
+	d.localST = st.Extend(symTable)
+	d.declared = []bool{}
+	seen := make(map[string]bool)
+	for _, id := range d.Idents {
+		_, inScope := symTable.ContainLocally(id.Id)
+		if seen[id.Id] || (inScope && !d.Short) {
+			errors = append(errors, semanticError(id.Token, "%s ident has already been used", id.Id))
+		}
+		if seen[id.Id] || inScope {
+			d.declared = append(d.declared, false)
+			continue
+		}
+		seen[id.Id] = true
+		var typeSig types.Type
+		if d.Type != nil {
+			typeSig = d.Type.GetType(symTable)
+		}
+		d.localST.InsertWithNewReg(id.Id, typeSig)
+		d.declared = append(d.declared, true)
+	}
+	if d.Short && len(seen) == 0 {
+		errors = append(errors, semanticError(d.Token, "No new variables on the left side of :="))
+	}
+	return errors
+}
+
+func (d *VarDecl) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	//The values are checked in the scope before the declaration, a variable without a type gets the type of its value
+	if d.Type != nil {
+		errors = d.Type.TypeCheck(errors, symTable)
+	}
+	if d.Exprs == nil {
 		return errors
 	}
+	errors, valueTypes := checkValues(errors, d.Token, len(d.Idents), d.Exprs, symTable)
+	for idx, id := range d.Idents {
+		entry, _ := d.localST.Contain(id.Id)
+		if d.declared[idx] && d.Type == nil {
+			// a value that is not a single typed value has been reported or is nil, the uses of the variable are not
+			entry.GetValue().EntryType = types.NewUnknownTy(id.Id)
+			if valueTypes == nil {
+				continue
+			}
+			if valueTypes[idx] == types.NilTySig && singleCall(d.Exprs) == nil {
+				errors = append(errors, semanticError(id.Token, "Cannot infer the type of %s from nil", id.Id))
+			} else if isKnown(valueTypes[idx]) && len(types.Results(valueTypes[idx])) == 1 {
+				entry.GetValue().EntryType = valueTypes[idx]
+			}
+		}
+		if valueTypes == nil {
+			continue
+		}
+		lt := entry.GetValue().EntryType
+		rt := valueTypes[idx]
+		if _, isConst := d.localST.ContainConstant(id.Id); isConst {
+			errors = append(errors, semanticError(id.Token, "Cannot assign to the constant %s", id.Id))
+		} else if types.IsArray(lt) {
+			errors = append(errors, semanticError(id.Token, "Cannot assign to the array %s, assign its elements", id.Id))
+		} else if isKnown(lt) && isKnown(rt) && !types.AssignableTo(rt, lt) {
+			errors = append(errors, semanticError(id.Token, "Assignment type error: Expected: %s, Actual: %s", types.TypeString(lt), types.TypeString(rt)))
+		}
+	}
+	return errors
+}
+
+func (d *VarDecl) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	//The values are computed in the scope before the declaration, variables without one start zeroed
+	if d.Exprs == nil {
+		for _, id := range d.Idents {
+			entry, _ := d.localST.Contain(id.Id)
+			reg := entry.GetValue().RegisterLoc
+			if arrayTy, isArray := entry.GetValue().EntryType.(*types.ArrayTy); isArray {
+				frag.Body = append(frag.Body, ir.GetNewArrayInst(reg, arrayTy.Len(), ir.IMMEDIATE))
+			} else {
+				frag.Body = append(frag.Body, ir.NewMov(reg, 0, ir.AL, ir.IMMEDIATE))
+			}
+		}
+		return
+	}
+	values := translateValues(frag, len(d.Idents), d.Exprs, table)
+	for idx := range d.Idents {
+		NewLvalue(d.Idents[idx], nil).assign(frag, values[idx], d.localST)
+	}
+}
+
+type ConstDecl struct {
+	Token *token.Token
+	Span
+	Idents  []IdentLiteral
+	Type    *Type           // nil when the constants have the types of their values
+	Exprs   []Expression    // One constant expression per name
+	localST *st.SymbolTable // Scope of the statements after the declaration
+}
+
+func NewConstDecl(idents []IdentLiteral, Type *Type, exprs []Expression) *ConstDecl {
+	return &ConstDecl{nil, Span{}, idents, Type, exprs, nil}
+}
+
+func (d *ConstDecl) TokenLiteral() string {
+	if d.Token != nil {
+		return d.Token.Literal
+	}
+	panic("Could not determine token literals for constant declaration")
+}
+
+func (d *ConstDecl) String() string {
+	out := bytes.Buffer{}
+	ids := []string{}
+	for _, id := range d.Idents {
+		ids = append(ids, id.String())
+	}
+	exprs := []string{}
+	for _, expr := range d.Exprs {
+		exprs = append(exprs, expr.String())
+	}
+	out.WriteString("const ")
+	out.WriteString(strings.Join(ids, ","))
+	if d.Type != nil {
+		out.WriteString(" ")
+		out.WriteString(d.Type.String())
+	}
+	out.WriteString("=")
+	out.WriteString(strings.Join(exprs, ","))
+	out.WriteString(";")
+	return out.String()
+}
+
+func (d *ConstDecl) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	/*
+		The values are evaluated now, so that the constants declared after them and
+		every use of them can be folded. Global constants go into the global table,
+		the ones of a function into a table extending the scope like variables.
+	*/
+	d.localST = symTable
+	if _, isLocal := symTable.GetFatherSymbol(); isLocal {
+		d.localST = st.Extend(symTable)
+	}
+	if len(d.Idents) != len(d.Exprs) {
+		return append(errors, semanticError(d.Token, "Constant declaration mismatch: %d names but %d values", len(d.Idents), len(d.Exprs)))
+	}
+	var declType types.Type
+	if d.Type != nil {
+		errors = d.Type.TypeCheck(errors, symTable)
+		declType = d.Type.GetType(symTable)
+	}
+	for idx, id := range d.Idents {
+		if _, exist := d.localST.ContainLocally(id.Id); exist {
+			errors = append(errors, semanticError(id.Token, "%s ident has already been used", id.Id))
+			continue
+		}
+		// a value of unknown type, or which cannot be computed, has been reported by its own check
+		checked := len(errors)
+		errors = d.Exprs[idx].TypeCheck(errors, symTable)
+		valueType := d.Exprs[idx].GetType(symTable)
+		if !isKnown(valueType) || len(errors) > checked {
+			continue
+		}
+		if valueType != types.IntTySig && valueType != types.BoolTySig {
+			errors = append(errors, semanticError(id.Token, "Constant %s must be an int or a bool, found: %s", id.Id, types.TypeString(valueType)))
+			continue
+		}
+		value, isConst := d.Exprs[idx].constant(symTable)
+		if !isConst {
+			errors = append(errors, semanticError(id.Token, "The value of %s is not a constant expression", id.Id))
+			continue
+		}
+		if isKnown(declType) && !types.Equal(declType, valueType) {
+			errors = append(errors, semanticError(id.Token, "Assignment type error: Expected: %s, Actual: %s", types.TypeString(declType), types.TypeString(valueType)))
+			continue
+		}
+		d.localST.InsertConstant(id.Id, valueType, value.intValue, value.boolValue)
+	}
+	return errors
+}
+
+func (d *ConstDecl) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	//The values have been checked when they were evaluated
+	return errors
+}
+
+func (d *ConstDecl) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	//Constants take no registers, their uses are folded
+}
+
+func scopeAfter(stat Stat, symTable *st.SymbolTable) *st.SymbolTable {
+	//Scope of the statements after stat, which holds the variables or constants it declares
+	switch decl := stat.(type) {
+	case *VarDecl:
+		if decl.localST != nil {
+			return decl.localST
+		}
+	case *ConstDecl:
+		if decl.localST != nil {
+			return decl.localST
+		}
+	}
+	return symTable
+}
+
+type Read struct {
+	Token *token.Token
+	Span
+	Ident IdentLiteral
+}
+
+func NewRead(ident IdentLiteral) *Read {
+	return &Read{nil, Span{}, ident}
+}
+
+func (r *Read) TokenLiteral() string {
+	if r.Token != nil {
+		return r.Token.Literal
+	}
+	panic("Could not determine token literals for read")
+}
+
+func (r *Read) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("fmt")
+	out.WriteString(".")
+	out.WriteString("Scan")
+	out.WriteString("(")
+	out.WriteString("&")
+	out.WriteString(r.Ident.Id)
+	out.WriteString(")")
+	out.WriteString(";")
+	return out.String()
+}
+
+func (r *Read) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	if _, isConst := symTable.ContainConstant(r.Ident.Id); isConst {
+		errors = append(errors, semanticError(r.Token, "Cannot assign to the constant %s", r.Ident.Id))
+	} else if entry, exist := symTable.Contain(r.Ident.Id); exist && entry.GetValue().EntryType != types.IntTySig {
+		errors = append(errors, semanticError(r.Token, "fmt.Scan expects an int variable, %s has type %s", r.Ident.Id, entry.GetValue().EntryType.GetName()))
+	}
+	return errors
+}
+
+func (r *Read) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	/*
+		Check whether read id has been declared
+	*/
+	if _, exist := symTable.Contain(r.Ident.Id); exist {
+		return errors
+	}
+	errors = append(errors, semanticError(r.Token, "%s has not been declared", r.Ident.Id))
+	return errors
+}
+
+func (r *Read) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if _, isGlobal := table.ContainGlobally(r.Ident.Id); isGlobal {
+		reg := ir.NewRegister()
+		frag.Body = append(frag.Body, ir.NewRead(reg, r.Ident.Id), ir.NewStr(reg, -1, -1, r.Ident.Id, ir.GLOBALVAR))
+		return
+	}
+	frag.Body = append(frag.Body, ir.NewRead(table.GetRegisterLoc(r.Ident.Id), r.Ident.Id))
+}
+
+type Print struct {
+	Token *token.Token
+	Span
+	printMethod string // "Print" | "Println"
+	Expr        *Expression
+}
+
+func NewPrint(printMethod string, expr *Expression) *Print {
+	return &Print{nil, Span{}, printMethod, expr}
+}
+
+func (p *Print) TokenLiteral() string {
+	if p.Token != nil {
+		return p.Token.Literal
+	}
+	panic("Could not determine token literals for print")
+}
+
+func (p *Print) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("fmt")
+	out.WriteString(".")
+	out.WriteString(p.printMethod)
+	out.WriteString("(")
+	out.WriteString(p.Expr.String())
+	out.WriteString(")")
+	out.WriteString(";")
+	return out.String()
+}
+
+func (p *Print) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	errors = p.Expr.TypeCheck(errors, symTable)
+	if exprType := p.Expr.GetType(symTable); isKnown(exprType) && exprType != types.IntTySig && exprType != types.StringTySig {
+		errors = append(errors, semanticError(p.Token, "fmt.%s expects an int or a string, %s has type %s", p.printMethod, p.Expr.String(), exprType.GetName()))
+	}
+	return errors
+}
+
+func (p *Print) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	// The identifiers of the expression are checked with its types
+	return errors
+}
+
+func (p *Print) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	p.Expr.TranslateToILoc(frag, table)
+	reg := *p.Expr.RegisterLoc
+	isString := p.Expr.GetType(table) == types.StringTySig
+	if p.printMethod == "Print" {
+		frag.Body = append(frag.Body, ir.NewPrint(reg, isString))
+	} else { //Println
+		frag.Body = append(frag.Body, ir.NewPrintln(reg, isString))
+	}
+}
+
+type Conditional struct {
+	Token *token.Token
+	Span
+	Expr       *Expression
+	Block      *Block
+	ElseExists bool
+	ElseBlock  *Block
+}
+
+func NewConditional(expr *Expression, block *Block, elseExists bool, elseBlock *Block) *Conditional {
+	return &Conditional{nil, Span{}, expr, block, elseExists, elseBlock}
+}
+
+func (c *Conditional) TokenLiteral() string {
+	if c.Token != nil {
+		return c.Token.Literal
+	}
+	panic("Could not determine token literals for conditional")
+}
+
+func (c *Conditional) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("if")
+	out.WriteString(" ")
+	out.WriteString("(")
+	out.WriteString(c.Expr.String())
+	out.WriteString(")")
+	out.WriteString(" ")
+	out.WriteString(c.Block.String())
+	if c.ElseExists {
+		out.WriteString("else")
+		out.WriteString(c.ElseBlock.String())
+	}
+	return out.String()
+}
+
+func (c *Conditional) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	errors = c.Expr.TypeCheck(errors, symTable)
+	exprType := c.Expr.GetType(symTable)
+	if isKnown(exprType) && exprType != types.BoolTySig {
+		errors = append(errors, semanticError(c.Token, "Conditional expression type: %s ,expected: bool", exprType.GetName()))
+	}
+	errors = c.Block.TypeCheck(errors, symTable)
+	if c.ElseExists {
+		errors = c.ElseBlock.TypeCheck(errors, symTable)
+	}
 	return errors
 }
 
@@ -1173,49 +1969,55 @@ func (c *Conditional) PerformSABuild(errors []string, symTable *st.SymbolTable)
 
 func (c *Conditional) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	/*
-
-	 */
+		The then clause continues in the current fragment, the else clause and the
+		statements after the conditional start the fragments labelled else and done
+	*/
 	elseLabel := ir.NewLabelWithPre("else")
 	doneLabel := ir.NewLabelWithPre("done")
-	// conditional expression
-	c.Expr.TranslateToILoc(frag, table)
-	// translate cmp
-	cmpInstruct := ir.NewCmp(*c.Expr.RegisterLoc, 0, ir.IMMEDIATE)
-	frag.Body = append(frag.Body, cmpInstruct)
-	var brFalseInst ir.Instruction
+	// a false condition branches to the else clause, or past the conditional
 	if c.ElseExists {
-		brFalseInst = ir.NewBranch(ir.EQ, elseLabel)
+		c.Expr.branch(frag, table, false, elseLabel)
 	} else {
-		brFalseInst = ir.NewBranch(ir.EQ, doneLabel)
+		c.Expr.branch(frag, table, false, doneLabel)
 	}
-	frag.Body = append(frag.Body, brFalseInst)
-	// translate if clause
+	// translate if clause, it may end in a fragment of a nested statement
 	c.Block.TranslateToILoc(frag, table)
+	thenFrag := lastFrag()
+	thenFrag.Body = append(thenFrag.Body, ir.NewBranch(ir.AL, doneLabel))
 	// translate else clause
 	if c.ElseExists {
-		ir.ControlFlowFrags = append(ir.ControlFlowFrags, &ir.FuncFrag{})
-		ir.ControlFlowFrags[len(ir.ControlFlowFrags)-1].Label = elseLabel
-		//brEndInst := ir.NewBranch(ir.AL, doneLabel)
-		//elsLabelInst := ir.NewLabelStmt(elseLabel)
-		//frag.Body = append(frag.Body, elsLabelInst)
-		c.ElseBlock.TranslateToILoc(ir.ControlFlowFrags[len(ir.ControlFlowFrags)-1], table)
+		elseFrag := &ir.FuncFrag{Label: elseLabel, Body: []ir.Instruction{}}
+		ir.ControlFlowFrags = append(ir.ControlFlowFrags, elseFrag)
+		c.ElseBlock.TranslateToILoc(elseFrag, table)
 	}
-	//Add finish branch
-	frag.Body = append(frag.Body, ir.NewBranch(ir.AL, doneLabel))
-	ir.ControlFlowFrags = append(ir.ControlFlowFrags, &ir.FuncFrag{})
-	ir.ControlFlowFrags[len(ir.ControlFlowFrags)-1].Label = doneLabel
+	ir.ControlFlowFrags = append(ir.ControlFlowFrags, &ir.FuncFrag{Label: doneLabel, Body: []ir.Instruction{}})
 }
 
 type Loop struct {
 	Token *token.Token
-	Expr  *Expression
-	Block *Block
+	Span
+	Label   string      // Label naming the loop for break and continue, empty if there is none
+	Init    Stat        // Statement run once before the loop, nil if there is none
+	Expr    *Expression // Condition, nil for a loop that only ends with break or return
+	Post    Stat        // Statement run after each iteration, nil if there is none
+	Block   *Block
+	localST *st.SymbolTable // Scope of the loop, the init statement runs in it
+}
+
+func NewLoop(label string, init Stat, expr *Expression, post Stat, block *Block) *Loop {
+	return &Loop{nil, Span{}, label, init, expr, post, block, nil}
 }
 
-func NewLoop(expr *Expression, block *Block) *Loop {
-	return &Loop{nil, expr, block}
+// loopExit holds the ILOC labels break and continue branch to in a loop or switch being translated
+type loopExit struct {
+	label         string // Source label of the loop or switch
+	continueLabel string // Empty for a switch, continue goes on with the loop around it
+	doneLabel     string
 }
 
+// loopExits are the loops and switches enclosing the statement being translated, the innermost last
+var loopExits []loopExit
+
 func (p *Loop) TokenLiteral() string {
 	if p.Token != nil {
 		return p.Token.Literal
@@ -1225,73 +2027,410 @@ func (p *Loop) TokenLiteral() string {
 
 func (p *Loop) String() string {
 	out := bytes.Buffer{}
+	if p.Label != "" {
+		out.WriteString(p.Label)
+		out.WriteString(": ")
+	}
 	out.WriteString("for")
 	out.WriteString(" ")
-	out.WriteString("(")
-	out.WriteString(p.Expr.String())
-	out.WriteString(")")
-	out.WriteString(" ")
+	if p.Init != nil || p.Post != nil {
+		if p.Init != nil {
+			out.WriteString(strings.TrimSuffix(strings.TrimSpace(p.Init.String()), ";"))
+		}
+		out.WriteString("; ")
+		if p.Expr != nil {
+			out.WriteString(p.Expr.String())
+		}
+		out.WriteString("; ")
+		if p.Post != nil {
+			out.WriteString(strings.TrimSuffix(strings.TrimSpace(p.Post.String()), ";"))
+		}
+		out.WriteString(" ")
+	} else if p.Expr != nil {
+		out.WriteString(p.Expr.String())
+		out.WriteString(" ")
+	}
 	out.WriteString(p.Block.String())
 	return out.String()
 }
 
 func (p *Loop) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	errors = p.Block.TypeCheck(errors, symTable)
 	//Check whether the expression is the bool type
-	exprType := p.Expr.GetType(symTable)
-	if exprType != types.BoolTySig {
-		errors = append(errors, fmt.Sprintf("conditional expression is not a boolean value on line: %d", p.Token.Rows))
-		return errors
+	table := p.localST
+	if p.Init != nil {
+		errors = p.Init.TypeCheck(errors, table)
+		table = scopeAfter(p.Init, table)
+	}
+	if p.Expr != nil {
+		errors = p.Expr.TypeCheck(errors, table)
+		exprType := p.Expr.GetType(table)
+		if isKnown(exprType) && exprType != types.BoolTySig {
+			errors = append(errors, semanticError(p.Token, "conditional expression is not a boolean value"))
+		}
 	}
+	if p.Post != nil {
+		errors = p.Post.TypeCheck(errors, table)
+	}
+	errors = p.Block.TypeCheck(errors, table)
 	return errors
 }
 
 func (p *Loop) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	errors = p.Expr.PerformSABuild(errors, symTable)
-	errors = p.Block.PerformSABuild(errors, symTable)
+	// the scope is named after the function, which is how return finds its result types
+	p.localST = st.NewWithFather(symTable, symTable.String())
+	table := p.localST
+	if p.Init != nil {
+		errors = p.Init.PerformSABuild(errors, table)
+		table = scopeAfter(p.Init, table)
+	}
+	if p.Expr != nil {
+		errors = p.Expr.PerformSABuild(errors, table)
+	}
+	if p.Post != nil {
+		errors = p.Post.PerformSABuild(errors, table)
+	}
+	errors = p.Block.PerformSABuild(errors, table)
 	return errors
 }
 
 func (p *Loop) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	condLabel := ir.NewLabelWithPre("condLabel")
 	bodyLabel := ir.NewLabelWithPre("loopBody")
+	doneLabel := ir.NewLabelWithPre("loopDone")
+	// continue runs the post statement before the condition
+	continueLabel := condLabel
+	if p.Post != nil {
+		continueLabel = ir.NewLabelWithPre("loopPost")
+	}
+	table = p.localST
+	if p.Init != nil {
+		p.Init.TranslateToILoc(frag, table)
+		table = scopeAfter(p.Init, table)
+	}
 	// b condLabel1
 	frag.Body = append(frag.Body, ir.NewBranch(ir.AL, condLabel))
 
-	// loop body
+	// loop body, falls through into the post statement and the condition
 	loopFrag := ir.FuncFrag{}
 	loopFrag.Label = bodyLabel
 	ir.ControlFlowFrags = append(ir.ControlFlowFrags, &loopFrag)
+	loopExits = append(loopExits, loopExit{p.Label, continueLabel, doneLabel})
 	p.Block.TranslateToILoc(&loopFrag, table)
+	loopExits = loopExits[:len(loopExits)-1]
 
-	// conditional expression
+	if p.Post != nil {
+		postFrag := ir.FuncFrag{}
+		postFrag.Label = continueLabel
+		ir.ControlFlowFrags = append(ir.ControlFlowFrags, &postFrag)
+		p.Post.TranslateToILoc(&postFrag, table)
+	}
+
+	// conditional expression, a loop without one goes back to the body
 	conditionalFrag := ir.FuncFrag{}
 	conditionalFrag.Label = condLabel
 	ir.ControlFlowFrags = append(ir.ControlFlowFrags, &conditionalFrag)
-	p.Expr.TranslateToILoc(&conditionalFrag, table)
-	conditionalFrag.Body = append(conditionalFrag.Body, ir.NewCmp(*p.Expr.RegisterLoc, 1, ir.IMMEDIATE))
-	conditionalFrag.Body = append(conditionalFrag.Body, ir.NewBranch(ir.EQ, bodyLabel))
+	if p.Expr == nil {
+		conditionalFrag.Body = append(conditionalFrag.Body, ir.NewBranch(ir.AL, bodyLabel))
+	} else {
+		p.Expr.branch(&conditionalFrag, table, true, bodyLabel)
+	}
+
+	// statements after the loop
+	ir.ControlFlowFrags = append(ir.ControlFlowFrags, &ir.FuncFrag{Label: doneLabel, Body: []ir.Instruction{}})
+}
+
+type BranchStmt struct {
+	Token *token.Token
+	Span
+	Keyword string // break or continue
+	Label   string // Label of the loop or switch, empty for the innermost one
+}
+
+func NewBranchStmt(keyword string, label string) *BranchStmt {
+	return &BranchStmt{nil, Span{}, keyword, label}
+}
+
+func (b *BranchStmt) TokenLiteral() string {
+	if b.Token != nil {
+		return b.Token.Literal
+	}
+	panic("Could not determine token literals for branch statement")
+}
+
+func (b *BranchStmt) String() string {
+	out := bytes.Buffer{}
+	out.WriteString(b.Keyword)
+	if b.Label != "" {
+		out.WriteString(" ")
+		out.WriteString(b.Label)
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+func (b *BranchStmt) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	// the parser has checked that the statement is inside a loop with the label
+	return errors
+}
+
+func (b *BranchStmt) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	return errors
+}
+
+func (b *BranchStmt) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	//break leaves the loop or switch, continue goes on with the post statement and the condition of the loop
+	for idx := len(loopExits) - 1; idx >= 0; idx-- {
+		exit := loopExits[idx]
+		if b.Label != "" && b.Label != exit.label {
+			continue
+		}
+		if b.Keyword == "continue" && exit.continueLabel == "" {
+			continue
+		}
+		if b.Keyword == "break" {
+			frag.Body = append(frag.Body, ir.NewBranch(ir.AL, exit.doneLabel))
+		} else {
+			frag.Body = append(frag.Body, ir.NewBranch(ir.AL, exit.continueLabel))
+		}
+		return
+	}
+	panic("Fail parse")
+}
+
+type Switch struct {
+	Token *token.Token
+	Span
+	Label string      // Label naming the switch for break, empty if there is none
+	Tag   *Expression // Value compared with the cases, nil when the cases are conditions
+	Cases []CaseClause
+}
+
+func NewSwitch(label string, tag *Expression, cases []CaseClause) *Switch {
+	return &Switch{nil, Span{}, label, tag, cases}
+}
+
+// minJumpTable is the fewest integer cases a switch dispatches through a jump table
+const minJumpTable = 4
+
+func (s *Switch) TokenLiteral() string {
+	if s.Token != nil {
+		return s.Token.Literal
+	}
+	panic("Could not determine token literals for switch")
+}
+
+func (s *Switch) String() string {
+	out := bytes.Buffer{}
+	if s.Label != "" {
+		out.WriteString(s.Label)
+		out.WriteString(": ")
+	}
+	out.WriteString("switch ")
+	if s.Tag != nil {
+		out.WriteString(s.Tag.String())
+		out.WriteString(" ")
+	}
+	out.WriteString("{\n")
+	for _, clause := range s.Cases {
+		out.WriteString(clause.String())
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+func (s *Switch) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	/*
+		The cases of a switch on a value must have its type, which is an int or a
+		bool, and the cases of a switch without one are conditions. An integer
+		constant can only be the value of one case.
+	*/
+	tagType := types.Type(types.BoolTySig)
+	if s.Tag != nil {
+		errors = s.Tag.TypeCheck(errors, symTable)
+		tagType = s.Tag.GetType(symTable)
+		if isKnown(tagType) && tagType != types.IntTySig && tagType != types.BoolTySig {
+			errors = append(errors, semanticError(s.Token, "switch expression is not an int or bool value"))
+			tagType = nil
+		}
+	}
+	seen := make(map[int]bool)
+	for idx := range s.Cases {
+		clause := &s.Cases[idx]
+		for exprIdx := range clause.Exprs {
+			expr := &clause.Exprs[exprIdx]
+			errors = expr.TypeCheck(errors, symTable)
+			exprType := expr.GetType(symTable)
+			if isKnown(exprType) && isKnown(tagType) && !types.Equal(exprType, tagType) {
+				errors = append(errors, semanticError(clause.Token, "Case type error: Expected %s, Actual: %s", types.TypeString(tagType), types.TypeString(exprType)))
+			}
+			if value, isConst := intConstant(expr, symTable); isConst && s.Tag != nil {
+				if seen[value] {
+					errors = append(errors, semanticError(clause.Token, "duplicate case %v in switch", value))
+				}
+				seen[value] = true
+			}
+		}
+		errors = clause.Body.TypeCheck(errors, symTable)
+	}
+	return errors
+}
+
+func (s *Switch) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	for idx := range s.Cases {
+		errors = s.Cases[idx].Body.PerformSABuild(errors, symTable)
+	}
+	return errors
+}
+
+func (s *Switch) jumpTable(caseLabels []string, defaultLabel string, table *st.SymbolTable) (int, []string, bool) {
+	/*
+		The lowest value and the label of each value from it on when the cases are
+		dense integer constants, the values between the cases go to the default.
+	*/
+	if s.Tag == nil || s.Tag.GetType(table) != types.IntTySig {
+		return 0, nil, false
+	}
+	values := make(map[int]string)
+	low, high := 0, 0
+	for idx, clause := range s.Cases {
+		for exprIdx := range clause.Exprs {
+			value, isConst := intConstant(&clause.Exprs[exprIdx], table)
+			if !isConst {
+				return 0, nil, false
+			}
+			if len(values) == 0 || value < low {
+				low = value
+			}
+			if len(values) == 0 || value > high {
+				high = value
+			}
+			values[value] = caseLabels[idx]
+		}
+	}
+	if len(values) < minJumpTable || high-low+1 > 2*len(values) {
+		return 0, nil, false
+	}
+	labels := []string{}
+	for value := low; value <= high; value++ {
+		if label, exist := values[value]; exist {
+			labels = append(labels, label)
+		} else {
+			labels = append(labels, defaultLabel)
+		}
+	}
+	return low, labels, true
+}
+
+func (s *Switch) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	/*
+		The value is evaluated once and dispatched through a jump table when the
+		cases are dense integer constants, otherwise it is compared with the cases
+		in order. Every case is a fragment ending with a branch after the switch.
+	*/
+	doneLabel := ir.NewLabelWithPre("switchDone")
+	defaultLabel := doneLabel
+	caseLabels := []string{}
+	for _, clause := range s.Cases {
+		caseLabels = append(caseLabels, ir.NewLabelWithPre("switchCase"))
+		if clause.Exprs == nil {
+			defaultLabel = caseLabels[len(caseLabels)-1]
+		}
+	}
+	if s.Tag != nil {
+		s.Tag.TranslateToILoc(frag, table)
+	}
+	if low, labels, isDense := s.jumpTable(caseLabels, defaultLabel, table); isDense {
+		frag.Body = append(frag.Body, ir.NewJumpTable(*s.Tag.RegisterLoc, low, labels, defaultLabel))
+	} else {
+		for idx := range s.Cases {
+			clause := &s.Cases[idx]
+			for exprIdx := range clause.Exprs {
+				expr := &clause.Exprs[exprIdx]
+				if s.Tag == nil {
+					// a condition selects the case when it holds
+					expr.branch(frag, table, true, caseLabels[idx])
+					continue
+				} else if value, isConst := intConstant(expr, table); isConst {
+					frag.Body = append(frag.Body, ir.NewCmp(*s.Tag.RegisterLoc, value, ir.IMMEDIATE))
+				} else {
+					expr.TranslateToILoc(frag, table)
+					frag.Body = append(frag.Body, ir.NewCmp(*s.Tag.RegisterLoc, *expr.RegisterLoc, ir.REGISTER))
+				}
+				frag.Body = append(frag.Body, ir.NewBranch(ir.EQ, caseLabels[idx]))
+			}
+		}
+		frag.Body = append(frag.Body, ir.NewBranch(ir.AL, defaultLabel))
+	}
+
+	loopExits = append(loopExits, loopExit{s.Label, "", doneLabel})
+	for idx := range s.Cases {
+		caseFrag := &ir.FuncFrag{Label: caseLabels[idx], Body: []ir.Instruction{}}
+		ir.ControlFlowFrags = append(ir.ControlFlowFrags, caseFrag)
+		// the case may end in a fragment of a nested statement
+		s.Cases[idx].Body.TranslateToILoc(caseFrag, table)
+		lastFrag().Body = append(lastFrag().Body, ir.NewBranch(ir.AL, doneLabel))
+	}
+	loopExits = loopExits[:len(loopExits)-1]
+
+	// statements after the switch
+	ir.ControlFlowFrags = append(ir.ControlFlowFrags, &ir.FuncFrag{Label: doneLabel, Body: []ir.Instruction{}})
+}
+
+type CaseClause struct {
+	Token *token.Token
+	Span
+	Exprs []Expression // Values or conditions selecting the case, nil for the default
+	Body  *Block
+}
+
+func NewCaseClause(exprs []Expression, body *Block) *CaseClause {
+	return &CaseClause{nil, Span{}, exprs, body}
+}
+
+func (c *CaseClause) String() string {
+	out := bytes.Buffer{}
+	if c.Exprs == nil {
+		out.WriteString("default")
+	} else {
+		exprs := []string{}
+		for _, expr := range c.Exprs {
+			exprs = append(exprs, expr.String())
+		}
+		out.WriteString("case ")
+		out.WriteString(strings.Join(exprs, ", "))
+	}
+	out.WriteString(":\n")
+	out.WriteString(c.Body.stat.String())
+	return out.String()
 }
 
 type Return struct {
 	Token *token.Token
-	Expr  *Expression
+	Span
+	Exprs []Expression
 }
 
 func (r *Return) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	var retInst ir.Instruction
-	r.Expr.TranslateToILoc(frag, table)
-	if r.Expr == nil {
+	if len(r.Exprs) == 0 {
 		retInst = ir.NewRet(-1, ir.VOID)
 	} else {
-		retInst = ir.NewRet(*r.Expr.RegisterLoc, ir.REGISTER)
+		sources := []int{}
+		for idx := range r.Exprs {
+			r.Exprs[idx].TranslateToILoc(frag, table)
+			sources = append(sources, *r.Exprs[idx].RegisterLoc)
+		}
+		if call := singleCall(r.Exprs); call != nil {
+			// return f() passes on every result of f
+			sources = call.results()
+		}
+		retInst = ir.NewRetValues(sources)
 	}
-	lastFrag := ir.ControlFlowFrags[len(ir.ControlFlowFrags)-1]
-	lastFrag.Body = append(lastFrag.Body, retInst)
+	frag.Body = append(frag.Body, retInst)
 }
 
-func NewReturn(exprExists bool, expr *Expression) *Return {
-	return &Return{nil, expr}
+func NewReturn(exprs []Expression) *Return {
+	return &Return{nil, Span{}, exprs}
 }
 
 func (r *Return) TokenLiteral() string {
@@ -1305,10 +2444,12 @@ func (r *Return) String() string {
 	out := bytes.Buffer{}
 	out.WriteString("return")
 
-	if r.Expr != nil {
-		out.WriteString(" ")
-		out.WriteString(r.Expr.String())
+	for idx, expr := range r.Exprs {
+		if idx > 0 {
+			out.WriteString(",")
+		}
 		out.WriteString(" ")
+		out.WriteString(expr.String())
 	}
 	out.WriteString(";")
 	return out.String()
@@ -1316,13 +2457,38 @@ func (r *Return) String() string {
 
 func (r *Return) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
 	//get Function return type
-	if funcEntry, exist := symTable.Contain(symTable.String()); !exist {
-		panic(fmt.Sprintf("Function named %s not defined on row: %d", symTable, r.Token.Rows))
-	} else {
-		lt := r.Expr.GetType(symTable).GetName()
-		rt := funcEntry.GetValue().ReturnType.GetName()
-		if lt != rt {
-			errors = append(errors, fmt.Sprintf("Function named %s unmatched, expected: %s, got: %s on row: %d", symTable, lt, rt, r.Token.Rows))
+	funcEntry, exist := symTable.ContainFunction(symTable.String())
+	if !exist {
+		panic(fmt.Sprintf("%s: Function named %s not defined", r.Token.Pos, symTable))
+	}
+	returnType := funcEntry.GetValue().ReturnType
+	expected := types.Results(returnType)
+	if len(r.Exprs) == 0 {
+		if returnType != types.NilTySig {
+			errors = append(errors, semanticError(r.Token, "Function named %s must return a value of type %s", symTable, returnType.GetName()))
+		}
+		return errors
+	}
+	if call := singleCall(r.Exprs); call != nil && len(expected) > 1 {
+		// the results of a call returning several values are returned as they are
+		errors = call.checkCallee(errors, symTable)
+		if actual := call.GetType(symTable); isKnown(actual) && !types.Equal(actual, returnType) {
+			errors = append(errors, semanticError(r.Token, "Function named %s unmatched, expected: %s, got: %s", symTable, returnType.GetName(), actual.GetName()))
+		}
+		return errors
+	}
+	for idx := range r.Exprs {
+		errors = r.Exprs[idx].TypeCheck(errors, symTable)
+	}
+	if returnType == types.NilTySig {
+		return append(errors, semanticError(r.Token, "Function named %s does not return a value", symTable))
+	}
+	if len(r.Exprs) != len(expected) {
+		return append(errors, semanticError(r.Token, "Function named %s returns %d values, got %d", symTable, len(expected), len(r.Exprs)))
+	}
+	for idx := range r.Exprs {
+		if actual := r.Exprs[idx].GetType(symTable); isKnown(actual) && !types.AssignableTo(actual, expected[idx]) {
+			errors = append(errors, semanticError(r.Exprs[idx].Token, "Function named %s unmatched, expected: %s, got: %s", symTable, expected[idx].GetName(), actual.GetName()))
 		}
 	}
 	return errors
@@ -1333,14 +2499,15 @@ func (r *Return) PerformSABuild(errors []string, symTable *st.SymbolTable) []str
 }
 
 type Invocation struct {
-	Token        *token.Token
+	Token *token.Token
+	Span
 	Ident        IdentLiteral
 	Args         *Arguments
 	ReturnRegLoc int
 }
 
 func NewInvocation(ident IdentLiteral, args *Arguments) *Invocation {
-	return &Invocation{nil, ident, args, -1}
+	return &Invocation{nil, Span{}, ident, args, -1}
 }
 
 func (i *Invocation) TokenLiteral() string {
@@ -1359,75 +2526,84 @@ func (i *Invocation) String() string {
 }
 
 func (i *Invocation) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	errors = i.Args.TypeCheck(errors, symTable)
+	errors = checkCall(errors, &i.Ident, i.Args, symTable)
+	if i.Ident.Id == "len" || i.Ident.Id == "append" {
+		errors = append(errors, semanticError(i.Token, "The result of %s is not used", i.Ident.Id))
+	}
 	return errors
 }
 
 func (i *Invocation) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	// check whether the id is a function name
-	entry, exist := symTable.Contain(i.Ident.TokenLiteral())
-	if exist && entry.GetValue().EntryType != types.FuncTySig {
-		errors = append(errors, fmt.Sprintf("Function named: %s is not defiend on row num: %d", i.Ident.Id, i.Ident.Token.Rows))
-	}
+	// the callee may be declared after the caller, so it is resolved during type checking
 	errors1 := i.Args.PerformSABuild(errors, symTable)
 	return errors1
 }
 
 func (i *Invocation) GetType(symTable *st.SymbolTable) types.Type {
 	//Return the return type of the invocation function
-	f, _ := st.SymbolTableMap["global"].Contain(i.Ident.Id)
-	return f.GetValue().ReturnType
+	if f, exist := symTable.ContainFunction(i.Ident.Id); exist {
+		return f.GetValue().ReturnType
+	}
+	return types.UnknownTySig
 }
 
 func (invo *Invocation) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	//Handle the new case
-	if invo.Ident.TokenLiteral() == "new" {
-		if entry, exist := table.Contain(invo.Args.Exprs[0].Token.Literal); !exist {
-			panic("fail sa")
-		} else {
-			fmt.Println(invo.Args.Exprs[0].Token.Literal)
-			frag.Body = append(frag.Body, ir.GetNewStructInst(entry.GetValue().RegisterLoc, invo.Args.Exprs[0].Token.Literal, len(entry.GetValue().ParaNames)))
-		}
-		return
-	}
-	if invo.Ident.TokenLiteral() == "delete" {
-		if entry, exist := table.Contain(invo.Args.Exprs[0].Token.Literal); !exist {
-			panic("fail sa")
-		} else {
-			frag.Body = append(frag.Body, ir.NewDelete(entry.GetValue().RegisterLoc))
-		}
-		return
+	switch invo.Ident.Id {
+	case "new":
+		invo.ReturnRegLoc = translateNew(frag, invo.Args, table)
+	case "delete":
+		invo.Args.Exprs[0].TranslateToILoc(frag, table)
+		frag.Body = append(frag.Body, ir.NewDelete(*invo.Args.Exprs[0].RegisterLoc))
+	default:
+		invo.ReturnRegLoc = translateCall(frag, invo.Ident, invo.Args, table)[0]
 	}
+}
 
-	//Mov
-	//Get function name
-	funcName := table.String()
-	funcEntry, exist := table.Contain(funcName)
-	if !exist {
-		panic("Fail sa")
+type MethodInvocation struct {
+	Token *token.Token
+	Span
+	Call *SelectorTerm // Selector term ending with the method call, its results are dropped
+}
+
+func NewMethodInvocation(call *SelectorTerm) *MethodInvocation {
+	return &MethodInvocation{nil, Span{}, call}
+}
+
+func (m *MethodInvocation) TokenLiteral() string {
+	if m.Token != nil {
+		return m.Token.Literal
 	}
-	frag.Body = append(frag.Body, ir.NewPush(funcEntry.GetValue().ParametersRegisterLocList, invo.Ident.Id))
+	panic("Could not determine token literals for method invocation")
+}
 
-	// bl
-	frag.Body = append(frag.Body, ir.NewBl(invo.Ident.TokenLiteral()))
+func (m *MethodInvocation) String() string {
+	out := bytes.Buffer{}
+	out.WriteString(m.Call.String())
+	out.WriteString(";")
+	return out.String()
+}
 
-	// mov retrun result to tmp
-	invo.ReturnRegLoc = ir.NewRegister()
-	frag.Body = append(frag.Body, ir.NewMov(invo.ReturnRegLoc, 0, ir.MARG, ir.REGISTER))
+func (m *MethodInvocation) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	return m.Call.checkCallee(errors, symTable)
+}
 
-	//pop
+func (m *MethodInvocation) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
+	return errors
+}
 
-	frag.Body = append(frag.Body, ir.NewPop(funcEntry.GetValue().ParametersRegisterLocList, invo.Ident.Id))
+func (m *MethodInvocation) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	m.Call.TranslateToILoc(frag, table)
 }
 
 type Arguments struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	Exprs       []Expression
 	RegisterLoc *int
 }
 
 func NewArguments(exprs []Expression) *Arguments {
-	return &Arguments{nil, exprs, nil}
+	return &Arguments{nil, Span{}, exprs, nil}
 }
 
 func (a *Arguments) TokenLiteral() string {
@@ -1456,18 +2632,6 @@ func (a *Arguments) TypeCheck(errors []string, symTable *st.SymbolTable) []strin
 	for _, expr := range a.Exprs {
 		errors = expr.TypeCheck(errors, symTable)
 	}
-	if funcEntry, exist := symTable.Contain(symTable.String()); !exist {
-		panic(fmt.Sprintf("Function named %s not defined on row: %d", symTable, a.Token.Rows))
-	} else {
-		funcParaTypeList := funcEntry.GetValue().Parameters
-		for idx, funcParaType := range funcParaTypeList {
-			lt := funcParaType.GetName()
-			rt := a.Exprs[idx].GetType(symTable).GetName()
-			if lt != rt {
-				errors = append(errors, fmt.Sprintf("Unmatched funcion parameter type, expected %s, got %s on line num: %d", lt, rt, a.Exprs[idx].Token.Rows))
-			}
-		}
-	}
 	return errors
 }
 
@@ -1479,19 +2643,21 @@ func (a *Arguments) PerformSABuild(errors []string, symTable *st.SymbolTable) []
 }
 
 func (a *Arguments) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	for _, exp := range a.Exprs {
-		exp.TranslateToILoc(frag, table)
+	for idx := range a.Exprs {
+		a.Exprs[idx].TranslateToILoc(frag, table)
 	}
 }
 
 type LValue struct {
-	Token       *token.Token
-	Idents      []IdentLiteral
+	Token *token.Token
+	Span
+	Ident       IdentLiteral
+	Selectors   []Selector
 	RegisterLoc int
 }
 
-func NewLvalue(idents []IdentLiteral) *LValue {
-	return &LValue{nil, idents, -1}
+func NewLvalue(ident IdentLiteral, selectors []Selector) *LValue {
+	return &LValue{nil, Span{}, ident, selectors, -1}
 }
 
 func (l *LValue) TokenLiteral() string {
@@ -1503,70 +2669,111 @@ func (l *LValue) TokenLiteral() string {
 
 func (l *LValue) String() string {
 	out := bytes.Buffer{}
-	for idx, id := range l.Idents {
-		if idx >= 1 {
-			out.WriteString(".")
-		}
-		out.WriteString(id.String())
+	out.WriteString(l.Ident.String())
+	for _, selector := range l.Selectors {
+		out.WriteString(selector.String())
 	}
 	return out.String()
 }
 
 func (l *LValue) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	return errors
+	// check whether the ident is declared and every selector applies to the value before it
+	if _, isFunction := symTable.ContainFunction(l.Ident.Id); isFunction {
+		return append(errors, semanticError(l.Ident.Token, "Cannot assign to the function %s", l.Ident.Id))
+	}
+	curType := l.Ident.GetType(symTable)
+	if !isKnown(curType) {
+		errors = append(errors, semanticError(l.Ident.Token, "%s has not been declared", l.Ident.Id))
+		return errors
+	}
+	if _, isConst := symTable.ContainConstant(l.Ident.Id); isConst && len(l.Selectors) == 0 {
+		errors = append(errors, semanticError(l.Ident.Token, "Cannot assign to the constant %s", l.Ident.Id))
+		return errors
+	}
+	return checkSelectors(errors, curType, l.Selectors, symTable)
 }
 
 func (l *LValue) PerformSABuild(errors []string, symTable *st.SymbolTable) []string {
-	// check whether the declared idents exist in the structs
-	//stCopy := *symTable
-	//
-	//var entry st.Entry
-	//var exist bool
-	//for idx, id := range l.Idents {
-	//	varName := id.TokenLiteral()
-	//	if idx>=1{
-	//		fmt.Println("yo")
-	//		fmt.Println(stCopy)
-	//		stCopy = *entry.GetValue().LocalSymbolTable
-	//
-	//	}
-	//	if entry, exist = stCopy.Contain(varName); !exist {
-	//		errors = append(errors, fmt.Sprintf("%s has not been declared in symbol talble: %s on line num:%d",varName,symTable,id.Token.Rows))
-	//		break
-	//	}
-	//}
+	// fields are resolved during type checking once every struct has been declared
 	return errors
 }
 
 func (l *LValue) GetType(symTable *st.SymbolTable) types.Type {
-	return l.Idents[len(l.Idents)-1].GetType(symTable)
+	return selectorsType(l.Ident.GetType(symTable), l.Selectors, symTable)
+}
+
+func (l *LValue) prefixType(symTable *st.SymbolTable) types.Type {
+	//Type of the value the last selector applies to
+	return selectorsType(l.Ident.GetType(symTable), l.Selectors[:len(l.Selectors)-1], symTable)
+}
+
+func (l *LValue) assign(frag *ir.FuncFrag, value int, table *st.SymbolTable) {
+	//Store the value once TranslateToILoc has computed what the last selector applies to
+	varName := l.Ident.Id
+	if len(l.Selectors) == 0 {
+		if _, isGlobal := table.ContainGlobally(varName); isGlobal {
+			// global variable assignment
+			frag.Body = append(frag.Body, ir.NewStr(value, -1, -1, varName, ir.GLOBALVAR))
+		} else {
+			frag.Body = append(frag.Body, ir.NewMov(table.GetRegLoc(varName), value, ir.AL, ir.REGISTER))
+		}
+		return
+	}
+	last := len(l.Selectors) - 1
+	l.Selectors[last].store(frag, l.RegisterLoc, l.prefixType(table), value, table)
+}
+
+func (l *LValue) load(frag *ir.FuncFrag, elements int, index int, table *st.SymbolTable) int {
+	//Load the value of the lvalue once TranslateToILoc has computed what the last selector applies to, an element through the given registers
+	if len(l.Selectors) == 0 {
+		l.Ident.TranslateToILoc(frag, table)
+		return l.Ident.RegisterLoc
+	}
+	last := len(l.Selectors) - 1
+	if l.Selectors[last].Index == nil {
+		return l.Selectors[last].load(frag, l.RegisterLoc, l.prefixType(table), table)
+	}
+	target := ir.NewRegister()
+	frag.Body = append(frag.Body, ir.NewLoadIndex(target, elements, index))
+	return target
+}
+
+func (l *LValue) store(frag *ir.FuncFrag, value int, elements int, index int, table *st.SymbolTable) {
+	//Store the value like assign, an element through the registers given to load
+	if last := len(l.Selectors) - 1; last >= 0 && l.Selectors[last].Index != nil {
+		frag.Body = append(frag.Body, ir.NewStrIndex(value, elements, index))
+		return
+	}
+	l.assign(frag, value, table)
 }
 
 func (l *LValue) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	/*
-		Set the regisloc as the register loc of the first id
+		Set the regisloc as the register holding the value the last selector
+		applies to, or the register of the ident when there is no selector
 	*/
 	if table == nil {
 		panic("Nill symboltalbe in LValue")
 	}
-	l.Idents[0].TranslateToILoc(frag, table)
-	l.RegisterLoc = l.Idents[0].RegisterLoc
-	if len(l.Idents) == 1 {
-		l.RegisterLoc = table.GetRegLoc(l.Idents[0].Id)
+	if len(l.Selectors) == 0 {
+		l.RegisterLoc = table.GetRegLoc(l.Ident.Id)
 		return
 	}
+	l.Ident.TranslateToILoc(frag, table)
+	l.RegisterLoc = translateSelectors(frag, l.Ident.RegisterLoc, l.Ident.GetType(table), l.Selectors[:len(l.Selectors)-1], table)
 }
 
 type Expression struct {
 	Token *token.Token
-	Left  *BoolTerm
+	Span
+	Left *BoolTerm
 	//RightExists bool
 	Rights      []BoolTerm
 	RegisterLoc *int
 }
 
 func NewExpression(l *BoolTerm, rs []BoolTerm) *Expression {
-	return &Expression{nil, l, rs, nil}
+	return &Expression{nil, Span{}, l, rs, nil}
 }
 
 func (p *Expression) TokenLiteral() string {
@@ -1587,26 +2794,37 @@ func (p *Expression) String() string {
 }
 
 func (p *Expression) GetType(symTable *st.SymbolTable) types.Type {
-	lefType := p.Left.GetType(symTable)
-	//for _, rTerm := range p.Rights {
-	//	rigType := rTerm.GetType(symTable)
-	//	if rigType != lefType {
-	//		return types.UnknownTySig
-	//	}
-	//}
-	return lefType
+	if len(p.Rights) != 0 {
+		return types.BoolTySig
+	}
+	return p.Left.GetType(symTable)
 }
 
-func (p *Expression) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	lefType := p.Left.GetType(symTable)
+func (p *Expression) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.Left.constant(symTable)
 	for _, rTerm := range p.Rights {
-		rigType := rTerm.GetType(symTable)
-		if lefType != rigType {
-			errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{lefType.GetName()}, #{rigType.GetName()} found."))
-			break
+		right, isRightConst := rTerm.constant(symTable)
+		if !isConst || !isRightConst {
+			return constValue{}, false
 		}
+		value = constValue{boolValue: value.boolValue || right.boolValue}
 	}
+	return value, isConst
+}
 
+func (p *Expression) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	errors = p.Left.TypeCheck(errors, symTable)
+	for _, rTerm := range p.Rights {
+		errors = rTerm.TypeCheck(errors, symTable)
+	}
+	if len(p.Rights) == 0 {
+		return errors
+	}
+	for _, operand := range append([]BoolTerm{*p.Left}, p.Rights...) {
+		if opType := operand.GetType(symTable); isKnown(opType) && opType != types.BoolTySig {
+			errors = append(errors, semanticError(operand.Token, "Operator || expected: bool, found: %s", opType.GetName()))
+		}
+	}
 	return errors
 }
 
@@ -1615,32 +2833,68 @@ func (p *Expression) PerformSABuild(errors []string, table *st.SymbolTable) []st
 }
 
 func (p *Expression) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		reg := foldConstant(frag, value)
+		p.RegisterLoc = &reg
+		return
+	}
 	p.Left.TranslateToILoc(frag, table)
 	if p.Rights == nil || len(p.Rights) == 0 {
 		p.RegisterLoc = p.Left.RegisterLoc
 		return
 	}
 
-	leftSource := p.Left.RegisterLoc
-	for _, rTerm := range p.Rights {
-		rTerm.TranslateToILoc(frag, table)
-		target := ir.NewRegister()
-		// in this way, OperandTy is always REGISTER
-		instruction := ir.NewOr(target, *leftSource, *rTerm.RegisterLoc, ir.REGISTER)
-		frag.Body = append(frag.Body, instruction)
-		leftSource = &target
+	// an operand is only evaluated while the result is false, a true one branches past the others
+	target := ir.NewRegister()
+	doneLabel := ir.NewLabelWithPre("orDone")
+	frag.Body = append(frag.Body, ir.NewMov(target, *p.Left.RegisterLoc, ir.AL, ir.REGISTER))
+	for idx := range p.Rights {
+		frag.Body = append(frag.Body, ir.NewCmp(target, 1, ir.IMMEDIATE), ir.NewBranch(ir.EQ, doneLabel))
+		p.Rights[idx].TranslateToILoc(frag, table)
+		frag.Body = append(frag.Body, ir.NewMov(target, *p.Rights[idx].RegisterLoc, ir.AL, ir.REGISTER))
 	}
-	p.RegisterLoc = leftSource
+	continueAt(frag, doneLabel)
+	p.RegisterLoc = &target
+}
+
+func (p *Expression) branch(frag *ir.FuncFrag, table *st.SymbolTable, when bool, label string) {
+	/*
+		Branch to label when the expression evaluates to when and fall through
+		otherwise. Each operand of || is only evaluated when the ones before it
+		are false.
+	*/
+	if len(p.Rights) == 0 {
+		p.Left.branch(frag, table, when, label)
+		return
+	}
+	terms := []*BoolTerm{p.Left}
+	for idx := range p.Rights {
+		terms = append(terms, &p.Rights[idx])
+	}
+	if when {
+		for _, term := range terms {
+			term.branch(frag, table, true, label)
+		}
+		return
+	}
+	// a true operand skips the others and the branch
+	trueLabel := ir.NewLabelWithPre("orTrue")
+	for _, term := range terms[:len(terms)-1] {
+		term.branch(frag, table, true, trueLabel)
+	}
+	terms[len(terms)-1].branch(frag, table, false, label)
+	continueAt(frag, trueLabel)
 }
 
 type BoolTerm struct {
-	Token         *token.Token
+	Token *token.Token
+	Span
 	EqualTermList []EqualTerm
 	RegisterLoc   *int
 }
 
 func NewBoolTerm(rs []EqualTerm) *BoolTerm {
-	return &BoolTerm{nil, rs, nil}
+	return &BoolTerm{nil, Span{}, rs, nil}
 }
 
 func (p *BoolTerm) TokenLiteral() string {
@@ -1662,46 +2916,100 @@ func (p *BoolTerm) String() string {
 }
 
 func (p *BoolTerm) GetType(symTable *st.SymbolTable) types.Type {
+	if len(p.EqualTermList) > 1 {
+		return types.BoolTySig
+	}
 	return p.EqualTermList[0].GetType(symTable)
 }
 
+func (p *BoolTerm) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.EqualTermList[0].constant(symTable)
+	for _, rTerm := range p.EqualTermList[1:] {
+		right, isRightConst := rTerm.constant(symTable)
+		if !isConst || !isRightConst {
+			return constValue{}, false
+		}
+		value = constValue{boolValue: value.boolValue && right.boolValue}
+	}
+	return value, isConst
+}
+
 func (p *BoolTerm) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	for i, _ := range p.EqualTermList {
-		if i >= 1 && p.EqualTermList[i].GetType(symTable) != p.EqualTermList[i-1].GetType(symTable) {
-			errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{p. EqualTermList[i-1].GetType(symTable)} type, #{p.EqualTermList[i].GetType(symTable)} type found."))
-			break
+	for _, equalTerm := range p.EqualTermList {
+		errors = equalTerm.TypeCheck(errors, symTable)
+	}
+	if len(p.EqualTermList) == 1 {
+		return errors
+	}
+	for _, operand := range p.EqualTermList {
+		if opType := operand.GetType(symTable); isKnown(opType) && opType != types.BoolTySig {
+			errors = append(errors, semanticError(operand.Token, "Operator && expected: bool, found: %s", opType.GetName()))
 		}
 	}
 	return errors
 }
 
 func (p *BoolTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		reg := foldConstant(frag, value)
+		p.RegisterLoc = &reg
+		return
+	}
 	p.EqualTermList[0].TranslateToILoc(frag, table)
 	if len(p.EqualTermList) == 1 {
 		p.RegisterLoc = p.EqualTermList[0].RegisterLoc
+		return
 	}
 
-	leftSource := *p.EqualTermList[0].RegisterLoc
-	for _, rTerm := range p.EqualTermList[1:] {
-		rTerm.TranslateToILoc(frag, table)
-		target := ir.NewRegister()
-		// OperandTy is always REGISTER
-		instruction := ir.NewAnd(target, leftSource, *rTerm.RegisterLoc, ir.REGISTER)
-		frag.Body = append(frag.Body, instruction)
-		leftSource = target
+	// an operand is only evaluated while the result is true, a false one branches past the others
+	target := ir.NewRegister()
+	doneLabel := ir.NewLabelWithPre("andDone")
+	frag.Body = append(frag.Body, ir.NewMov(target, *p.EqualTermList[0].RegisterLoc, ir.AL, ir.REGISTER))
+	for idx := 1; idx < len(p.EqualTermList); idx++ {
+		frag.Body = append(frag.Body, ir.NewCmp(target, 0, ir.IMMEDIATE), ir.NewBranch(ir.EQ, doneLabel))
+		p.EqualTermList[idx].TranslateToILoc(frag, table)
+		frag.Body = append(frag.Body, ir.NewMov(target, *p.EqualTermList[idx].RegisterLoc, ir.AL, ir.REGISTER))
 	}
-	p.RegisterLoc = &leftSource
+	continueAt(frag, doneLabel)
+	p.RegisterLoc = &target
+}
+
+func (p *BoolTerm) branch(frag *ir.FuncFrag, table *st.SymbolTable, when bool, label string) {
+	/*
+		Branch to label when the term evaluates to when and fall through
+		otherwise. Each operand of && is only evaluated when the ones before it
+		are true.
+	*/
+	last := len(p.EqualTermList) - 1
+	if !when {
+		for idx := range p.EqualTermList {
+			p.EqualTermList[idx].branch(frag, table, false, label)
+		}
+		return
+	}
+	if last == 0 {
+		p.EqualTermList[0].branch(frag, table, true, label)
+		return
+	}
+	// a false operand skips the others and the branch
+	falseLabel := ir.NewLabelWithPre("andFalse")
+	for idx := 0; idx < last; idx++ {
+		p.EqualTermList[idx].branch(frag, table, false, falseLabel)
+	}
+	p.EqualTermList[last].branch(frag, table, true, label)
+	continueAt(frag, falseLabel)
 }
 
 type EqualTerm struct {
-	Token            *token.Token
+	Token *token.Token
+	Span
 	EqualOperator    []string
 	RelationTermList []RelationTerm
 	RegisterLoc      *int
 }
 
 func NewEqualTerm(operators []string, RelationTermList []RelationTerm) *EqualTerm {
-	return &EqualTerm{nil, operators, RelationTermList, nil}
+	return &EqualTerm{nil, Span{}, operators, RelationTermList, nil}
 }
 
 func (p *EqualTerm) TokenLiteral() string {
@@ -1723,32 +3031,84 @@ func (p *EqualTerm) String() string {
 }
 
 func (p *EqualTerm) GetType(symTable *st.SymbolTable) types.Type {
+	if len(p.EqualOperator) != 0 {
+		return types.BoolTySig
+	}
 	return p.RelationTermList[0].GetType(symTable)
 }
 
+func (p *EqualTerm) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.RelationTermList[0].constant(symTable)
+	for idx, rTerm := range p.RelationTermList[1:] {
+		right, isRightConst := rTerm.constant(symTable)
+		if !isConst || !isRightConst {
+			return constValue{}, false
+		}
+		if p.EqualOperator[idx] == "==" {
+			value = constValue{boolValue: value == right}
+		} else { // "!="
+			value = constValue{boolValue: value != right}
+		}
+	}
+	return value, isConst
+}
+
 func (p *EqualTerm) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	for i, _ := range p.RelationTermList {
-		if i >= 1 && p.RelationTermList[i].GetType(symTable) != p.RelationTermList[i-1].GetType(symTable) {
-			errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{p. RelationTermList[i-1].GetType(symTable)} type, #{p.RelationTermList[i].GetType(symTable)} type found."))
-			break
+	for _, relationTerm := range p.RelationTermList {
+		errors = relationTerm.TypeCheck(errors, symTable)
+	}
+	lefType := p.RelationTermList[0].GetType(symTable)
+	for idx, rTerm := range p.RelationTermList[1:] {
+		rigType := rTerm.GetType(symTable)
+		comparable := types.AssignableTo(lefType, rigType) || types.AssignableTo(rigType, lefType)
+		if isKnown(lefType) && isKnown(rigType) && !comparable {
+			errors = append(errors, semanticError(rTerm.Token, "Operator %s mismatched types: %s and %s", p.EqualOperator[idx], lefType.GetName(), rigType.GetName()))
+		} else if elementType(lefType) != nil && elementType(rigType) != nil {
+			// arrays and slices are addresses of heap cells, comparing them would compare the cells
+			errors = append(errors, semanticError(rTerm.Token, "Operator %s cannot compare %s, only a slice and nil can be compared", p.EqualOperator[idx], lefType.GetName()))
 		}
+		lefType = types.BoolTySig
 	}
 	return errors
 }
 
 func (p *EqualTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		reg := foldConstant(frag, value)
+		p.RegisterLoc = &reg
+		return
+	}
 	p.RelationTermList[0].TranslateToILoc(frag, table)
 	if len(p.RelationTermList) == 1 {
 		p.RegisterLoc = &p.RelationTermList[0].RegisterLoc
 	}
 
 	leftSource := p.RelationTermList[0].RegisterLoc
+	isString := p.RelationTermList[0].GetType(table) == types.StringTySig
 	for idx, rTerm := range p.RelationTermList[1:] {
-		// Put into a new register the "false" value ("false" = 0) before the cmp
-		rTerm.TranslateToILoc(frag, table)
+		right, isRightConst := rTerm.constant(table)
+		if !isRightConst || isString {
+			rTerm.TranslateToILoc(frag, table)
+		}
 		target := ir.NewRegister()
+		if isString {
+			// strings are equal when their bytes are, the addresses may differ
+			frag.Body = append(frag.Body, ir.NewStrEq(target, leftSource, rTerm.RegisterLoc))
+			if p.EqualOperator[idx] == "!=" {
+				equal := target
+				target = ir.NewRegister()
+				frag.Body = append(frag.Body, ir.NewNot(target, equal, ir.REGISTER))
+			}
+			leftSource = target
+			isString = false
+			continue
+		}
+		// Put into a new register the "false" value ("false" = 0) before the cmp, a constant is compared as an immediate
 		instruction1 := ir.NewMov(target, 0, ir.AL, ir.IMMEDIATE)
 		instruction2 := ir.NewCmp(leftSource, rTerm.RegisterLoc, ir.REGISTER)
+		if isRightConst {
+			instruction2 = ir.NewCmp(leftSource, right.bits(), ir.IMMEDIATE)
+		}
 		var instruction3 ir.Instruction
 		if p.EqualOperator[idx] == "==" {
 			instruction3 = ir.NewMov(target, 1, ir.EQ, ir.IMMEDIATE)
@@ -1762,9 +3122,69 @@ func (p *EqualTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	p.RegisterLoc = &leftSource
 }
 
+func (p *EqualTerm) branch(frag *ir.FuncFrag, table *st.SymbolTable, when bool, label string) {
+	//A single comparison branches on the flags its cmp sets, another operand is a boolean compared with 0
+	flag := ir.NE
+	_, isConst := p.constant(table)
+	if inner, negated := p.parenthesized(); inner != nil && !isConst {
+		// the operators inside the parentheses branch too, ! swaps the outcome
+		inner.branch(frag, table, when != negated, label)
+		return
+	}
+	if len(p.RelationTermList) == 2 && !isConst && p.RelationTermList[0].GetType(table) != types.StringTySig {
+		left, right := &p.RelationTermList[0], &p.RelationTermList[1]
+		left.TranslateToILoc(frag, table)
+		if value, isRightConst := right.constant(table); isRightConst {
+			frag.Body = append(frag.Body, ir.NewCmp(left.RegisterLoc, value.bits(), ir.IMMEDIATE))
+		} else {
+			right.TranslateToILoc(frag, table)
+			frag.Body = append(frag.Body, ir.NewCmp(left.RegisterLoc, right.RegisterLoc, ir.REGISTER))
+		}
+		if p.EqualOperator[0] == "==" {
+			flag = ir.EQ
+		}
+	} else if relation := &p.RelationTermList[0]; len(p.RelationTermList) == 1 && len(relation.Rights) == 1 && !isConst {
+		relation.Left.TranslateToILoc(frag, table)
+		if value, isRightConst := relation.Rights[0].constant(table); isRightConst {
+			frag.Body = append(frag.Body, ir.NewCmp(relation.Left.RegisterLoc, value.intValue, ir.IMMEDIATE))
+		} else {
+			relation.Rights[0].TranslateToILoc(frag, table)
+			frag.Body = append(frag.Body, ir.NewCmp(relation.Left.RegisterLoc, relation.Rights[0].RegisterLoc, ir.REGISTER))
+		}
+		flag = relationFlag(relation.RelationOperators[0])
+	} else {
+		p.TranslateToILoc(frag, table)
+		frag.Body = append(frag.Body, ir.NewCmp(*p.RegisterLoc, 0, ir.IMMEDIATE))
+	}
+	if !when {
+		flag = oppositeFlag(flag)
+	}
+	frag.Body = append(frag.Body, ir.NewBranch(flag, label))
+}
+
+func (p *EqualTerm) parenthesized() (*Expression, bool) {
+	//The expression of a term that is a parenthesized expression, and whether ! negates it
+	if len(p.RelationTermList) != 1 || len(p.RelationTermList[0].Rights) != 0 {
+		return nil, false
+	}
+	simpleTerm := p.RelationTermList[0].Left
+	if len(simpleTerm.Rights) != 0 || len(simpleTerm.Left.Rights) != 0 {
+		return nil, false
+	}
+	unaryTerm := simpleTerm.Left.Left
+	if unaryTerm.UnaryOperator != "" && unaryTerm.UnaryOperator != "!" || len(unaryTerm.SelectorTerm.Selectors) != 0 {
+		return nil, false
+	}
+	if priority, ok := unaryTerm.SelectorTerm.Fact.Expr.(*PriorityExpression); ok {
+		return priority.InnerExpression, unaryTerm.UnaryOperator == "!"
+	}
+	return nil, false
+}
+
 type RelationTerm struct {
 	Token *token.Token
-	Left  *SimpleTerm
+	Span
+	Left *SimpleTerm
 	//RightExists bool
 	RelationOperators []string // '>'| '<' | '<=' | '>='
 	Rights            []SimpleTerm
@@ -1772,7 +3192,7 @@ type RelationTerm struct {
 }
 
 func NewRelationTerm(l *SimpleTerm, operators []string, rs []SimpleTerm) *RelationTerm {
-	return &RelationTerm{nil, l, operators, rs, -1}
+	return &RelationTerm{nil, Span{}, l, operators, rs, -1}
 }
 
 func (p *RelationTerm) TokenLiteral() string {
@@ -1809,20 +3229,51 @@ func (p *RelationTerm) GetType(symTable *st.SymbolTable) types.Type {
 	}
 }
 
+func (p *RelationTerm) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.Left.constant(symTable)
+	for idx, rTerm := range p.Rights {
+		right, isRightConst := rTerm.constant(symTable)
+		if !isConst || !isRightConst {
+			return constValue{}, false
+		}
+		switch p.RelationOperators[idx] {
+		case ">":
+			value = constValue{boolValue: value.intValue > right.intValue}
+		case "<":
+			value = constValue{boolValue: value.intValue < right.intValue}
+		case "<=":
+			value = constValue{boolValue: value.intValue <= right.intValue}
+		default: // ">="
+			value = constValue{boolValue: value.intValue >= right.intValue}
+		}
+	}
+	return value, isConst
+}
+
 func (p *RelationTerm) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	lefType := p.Left.GetType(symTable)
+	errors = p.Left.TypeCheck(errors, symTable)
 	for _, rTerm := range p.Rights {
+		errors = rTerm.TypeCheck(errors, symTable)
+	}
+	lefType := p.Left.GetType(symTable)
+	for idx, rTerm := range p.Rights {
 		rigType := rTerm.GetType(symTable)
-		if lefType != rigType {
-			errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{lefType.GetName()}, #{rigType.GetName()} found."))
-			break
+		if isKnown(lefType) && lefType != types.IntTySig {
+			errors = append(errors, semanticError(p.Token, "Operator %s expected: int, found: %s", p.RelationOperators[idx], lefType.GetName()))
 		}
+		if isKnown(rigType) && rigType != types.IntTySig {
+			errors = append(errors, semanticError(rTerm.Token, "Operator %s expected: int, found: %s", p.RelationOperators[idx], rigType.GetName()))
+		}
+		lefType = types.BoolTySig
 	}
-
 	return errors
 }
 
 func (p *RelationTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		p.RegisterLoc = foldConstant(frag, value)
+		return
+	}
 	p.Left.TranslateToILoc(frag, table)
 	if p.Rights == nil || len(p.Rights) == 0 {
 		p.RegisterLoc = p.Left.RegisterLoc
@@ -1830,22 +3281,18 @@ func (p *RelationTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable)
 
 	leftSource := p.Left.RegisterLoc
 	for idx, rTerm := range p.Rights {
-		rTerm.TranslateToILoc(frag, table)
 		relationOperator := p.RelationOperators[idx]
-		// Put into a new register the "false" value ("false" = 0) before the cmp
+		// Put into a new register the "false" value ("false" = 0) before the cmp, a constant is compared as an immediate
 		target := ir.NewRegister()
 		instruction1 := ir.NewMov(target, 0, ir.AL, ir.IMMEDIATE)
-		instruction2 := ir.NewCmp(leftSource, rTerm.RegisterLoc, ir.REGISTER)
-		var instruction3 ir.Instruction
-		if relationOperator == ">" {
-			instruction3 = ir.NewMov(target, 1, ir.GT, ir.IMMEDIATE)
-		} else if relationOperator == "<" {
-			instruction3 = ir.NewMov(target, 1, ir.LT, ir.IMMEDIATE)
-		} else if relationOperator == "<=" {
-			instruction3 = ir.NewMov(target, 1, ir.LE, ir.IMMEDIATE)
-		} else { // >=
-			instruction3 = ir.NewMov(target, 1, ir.GE, ir.IMMEDIATE)
+		var instruction2 ir.Instruction
+		if right, isRightConst := rTerm.constant(table); isRightConst {
+			instruction2 = ir.NewCmp(leftSource, right.intValue, ir.IMMEDIATE)
+		} else {
+			rTerm.TranslateToILoc(frag, table)
+			instruction2 = ir.NewCmp(leftSource, rTerm.RegisterLoc, ir.REGISTER)
 		}
+		instruction3 := ir.NewMov(target, 1, relationFlag(relationOperator), ir.IMMEDIATE)
 
 		frag.Body = append(frag.Body, instruction1, instruction2, instruction3)
 		leftSource = target
@@ -1853,17 +3300,49 @@ func (p *RelationTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable)
 	p.RegisterLoc = leftSource
 }
 
+func relationFlag(operator string) ir.ApsrFlag {
+	//Flag holding after a cmp of the operands of the relation when it is true
+	switch operator {
+	case ">":
+		return ir.GT
+	case "<":
+		return ir.LT
+	case "<=":
+		return ir.LE
+	}
+	return ir.GE
+}
+
+func oppositeFlag(flag ir.ApsrFlag) ir.ApsrFlag {
+	//Flag holding exactly when the given one does not
+	switch flag {
+	case ir.GT:
+		return ir.LE
+	case ir.LT:
+		return ir.GE
+	case ir.GE:
+		return ir.LT
+	case ir.LE:
+		return ir.GT
+	case ir.EQ:
+		return ir.NE
+	}
+	return ir.EQ
+}
+
 type SimpleTerm struct {
 	Token *token.Token
-	Left  *Term
+	Span
+	Left *Term
 	//RightExists bool
-	SimpleTermOperators []string // '+' | '-'
+	SimpleTermOperators []string // '+' | '-' | '|' | '^'
+	OperatorTokens      []*token.Token
 	Rights              []Term
 	RegisterLoc         int
 }
 
 func NewSimpleTerm(l *Term, operators []string, rs []Term) *SimpleTerm {
-	return &SimpleTerm{nil, l, operators, rs, -1}
+	return &SimpleTerm{nil, Span{}, l, operators, nil, rs, -1}
 }
 
 func (p *SimpleTerm) TokenLiteral() string {
@@ -1893,27 +3372,69 @@ func (p *SimpleTerm) GetType(symTable *st.SymbolTable) types.Type {
 			return types.UnknownTySig
 		}
 	}
-	if len(p.Rights) != 0 {
+	if len(p.Rights) != 0 && lefType != types.StringTySig {
 		return types.IntTySig
 	} else {
 		return lefType
 	}
 }
 
+func (p *SimpleTerm) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.Left.constant(symTable)
+	for idx, rTerm := range p.Rights {
+		right, isRightConst := rTerm.constant(symTable)
+		if !isConst || !isRightConst {
+			return constValue{}, false
+		}
+		if value, isConst = foldOperator(p.SimpleTermOperators[idx], value, right); !isConst {
+			return constValue{}, false
+		}
+	}
+	return value, isConst
+}
+
 func (p *SimpleTerm) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	lefType := p.Left.GetType(symTable)
+	checked := len(errors)
+	errors = p.Left.TypeCheck(errors, symTable)
 	for _, rTerm := range p.Rights {
-		rigType := rTerm.GetType(symTable)
-		if lefType != rigType {
-			errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{lefType.GetName()}, #{rigType.GetName()} found."))
-			break
+		errors = rTerm.TypeCheck(errors, symTable)
+	}
+	if len(p.Rights) == 0 {
+		return errors
+	}
+	if lefType := p.Left.GetType(symTable); lefType == types.StringTySig {
+		// strings can only be concatenated
+		for idx, rTerm := range p.Rights {
+			if p.SimpleTermOperators[idx] != "+" {
+				errors = append(errors, semanticError(rTerm.Token, "Operator %s is not defined on string", p.SimpleTermOperators[idx]))
+			} else if rigType := rTerm.GetType(symTable); isKnown(rigType) && rigType != types.StringTySig {
+				errors = append(errors, semanticError(rTerm.Token, "Operator + mismatched types: string and %s", rigType.GetName()))
+			}
 		}
+		return errors
+	} else if isKnown(lefType) && lefType != types.IntTySig {
+		errors = append(errors, semanticError(p.Token, "Operator %s expected: int, found: %s", p.SimpleTermOperators[0], lefType.GetName()))
+	}
+	for idx, rTerm := range p.Rights {
+		if rigType := rTerm.GetType(symTable); isKnown(rigType) && rigType != types.IntTySig {
+			errors = append(errors, semanticError(rTerm.Token, "Operator %s expected: int, found: %s", p.SimpleTermOperators[idx], rigType.GetName()))
+		}
+	}
+	if len(errors) == checked {
+		operands := []constantTerm{p.Left}
+		for idx := range p.Rights {
+			operands = append(operands, &p.Rights[idx])
+		}
+		errors = checkFolding(errors, p.Token, p.SimpleTermOperators, p.OperatorTokens, operands, symTable)
 	}
-
 	return errors
 }
 
 func (p *SimpleTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		p.RegisterLoc = foldConstant(frag, value)
+		return
+	}
 	p.Left.TranslateToILoc(frag, table)
 	if p.Rights == nil {
 		p.RegisterLoc = p.Left.RegisterLoc
@@ -1921,16 +3442,19 @@ func (p *SimpleTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	}
 
 	leftSource := p.Left.RegisterLoc
+	isString := p.Left.GetType(table) == types.StringTySig
 	for idx, rTerm := range p.Rights {
-		rTerm.TranslateToILoc(frag, table)
 		target := ir.NewRegister()
-		var instruction ir.Instruction
-		if p.SimpleTermOperators[idx] == "+" {
-			instruction = ir.NewAdd(target, leftSource, rTerm.RegisterLoc, ir.REGISTER)
-		} else { // "-"
-			instruction = ir.NewSub(target, leftSource, rTerm.RegisterLoc, ir.REGISTER)
+		if isString {
+			rTerm.TranslateToILoc(frag, table)
+			frag.Body = append(frag.Body, ir.NewConcat(target, leftSource, rTerm.RegisterLoc))
+		} else if right, isRightConst := rTerm.constant(table); isRightConst {
+			// a constant is an immediate operand
+			frag.Body = append(frag.Body, operatorInstruction(p.SimpleTermOperators[idx], target, leftSource, right.intValue, ir.IMMEDIATE))
+		} else {
+			rTerm.TranslateToILoc(frag, table)
+			frag.Body = append(frag.Body, operatorInstruction(p.SimpleTermOperators[idx], target, leftSource, rTerm.RegisterLoc, ir.REGISTER))
 		}
-		frag.Body = append(frag.Body, instruction)
 		leftSource = target
 	}
 	p.RegisterLoc = leftSource
@@ -1939,15 +3463,17 @@ func (p *SimpleTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 
 type Term struct {
 	Token *token.Token
-	Left  *UnaryTerm
+	Span
+	Left *UnaryTerm
 	//RightExists bool
-	TermOperators []string // '*' | '/'
-	Rights        []UnaryTerm
-	RegisterLoc   int
+	TermOperators  []string // '*' | '/' | '%' | '<<' | '>>' | '&' | '&^'
+	OperatorTokens []*token.Token
+	Rights         []UnaryTerm
+	RegisterLoc    int
 }
 
 func NewTerm(l *UnaryTerm, operators []string, rs []UnaryTerm) *Term {
-	return &Term{nil, l, operators, rs, -1}
+	return &Term{nil, Span{}, l, operators, nil, rs, -1}
 }
 
 func (p *Term) TokenLiteral() string {
@@ -1984,51 +3510,204 @@ func (p *Term) GetType(symTable *st.SymbolTable) types.Type {
 	}
 }
 
+func (p *Term) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.Left.constant(symTable)
+	for idx, rTerm := range p.Rights {
+		right, isRightConst := rTerm.constant(symTable)
+		if !isConst || !isRightConst {
+			return constValue{}, false
+		}
+		if value, isConst = foldOperator(p.TermOperators[idx], value, right); !isConst {
+			return constValue{}, false
+		}
+	}
+	return value, isConst
+}
+
 func (p *Term) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	lefType := p.Left.GetType(symTable)
+	checked := len(errors)
+	errors = p.Left.TypeCheck(errors, symTable)
 	for _, rTerm := range p.Rights {
-		rigType := rTerm.GetType(symTable)
-		if lefType != rigType {
-			errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{lefType.GetName()}, #{rigType.GetName()} found."))
-			break
+		errors = rTerm.TypeCheck(errors, symTable)
+	}
+	if len(p.Rights) == 0 {
+		return errors
+	}
+	if lefType := p.Left.GetType(symTable); isKnown(lefType) && lefType != types.IntTySig {
+		errors = append(errors, semanticError(p.Token, "Operator %s expected: int, found: %s", p.TermOperators[0], lefType.GetName()))
+	}
+	for idx, rTerm := range p.Rights {
+		if rigType := rTerm.GetType(symTable); isKnown(rigType) && rigType != types.IntTySig {
+			errors = append(errors, semanticError(rTerm.Token, "Operator %s expected: int, found: %s", p.TermOperators[idx], rigType.GetName()))
 		}
 	}
-
+	if len(errors) == checked {
+		operands := []constantTerm{p.Left}
+		for idx := range p.Rights {
+			operands = append(operands, &p.Rights[idx])
+		}
+		errors = checkFolding(errors, p.Token, p.TermOperators, p.OperatorTokens, operands, symTable)
+	}
 	return errors
 }
 
 func (p *Term) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		p.RegisterLoc = foldConstant(frag, value)
+		return
+	}
 	p.Left.TranslateToILoc(frag, table)
 	if p.Rights == nil || len(p.Rights) == 0 {
 		p.RegisterLoc = p.Left.RegisterLoc
 		return
 	}
 
-	leftSource := p.Left.RegisterLoc
-	for idx, rTerm := range p.Rights {
-		rTerm.TranslateToILoc(frag, table)
-		target := ir.NewRegister()
-		var instruction ir.Instruction
-		if p.TermOperators[idx] == "*" {
-			instruction = ir.NewMul(target, leftSource, rTerm.RegisterLoc)
-		} else { // "/"
-			instruction = ir.NewDiv(target, leftSource, rTerm.RegisterLoc)
-		}
-		frag.Body = append(frag.Body, instruction)
-		leftSource = target
+	leftSource := p.Left.RegisterLoc
+	for idx, rTerm := range p.Rights {
+		target := ir.NewRegister()
+		operator := p.TermOperators[idx]
+		if right, isRightConst := rTerm.constant(table); isRightConst && immediateOperator(operator) {
+			// a constant is an immediate operand of the instructions taking one
+			frag.Body = append(frag.Body, operatorInstruction(operator, target, leftSource, right.intValue, ir.IMMEDIATE))
+		} else {
+			rTerm.TranslateToILoc(frag, table)
+			frag.Body = append(frag.Body, operatorInstruction(operator, target, leftSource, rTerm.RegisterLoc, ir.REGISTER))
+		}
+		leftSource = target
+	}
+	p.RegisterLoc = leftSource
+}
+
+// constantTerm is an operand of an operator chain, which may be computed at compile time
+type constantTerm interface {
+	constant(symTable *st.SymbolTable) (constValue, bool)
+}
+
+func checkFolding(errors []string, tok *token.Token, operators []string, opTokens []*token.Token, operands []constantTerm, symTable *st.SymbolTable) []string {
+	/*
+		Report an operator chain that cannot be computed at compile time: a constant
+		zero divisor at its operator, and constant operands overflowing int at the
+		expression. Like the folding, the chain is evaluated from the left.
+	*/
+	value, isConst := operands[0].constant(symTable)
+	for idx, operator := range operators {
+		right, isRightConst := operands[idx+1].constant(symTable)
+		if isRightConst && right.intValue == 0 && (operator == "/" || operator == "%") {
+			opTok := tok
+			if idx < len(opTokens) {
+				opTok = opTokens[idx]
+			}
+			return append(errors, semanticError(opTok, "division by zero"))
+		}
+		if !isConst || !isRightConst {
+			isConst = false
+			continue
+		}
+		if overflows(operator, value, right) {
+			return append(errors, semanticError(tok, "constant overflows int"))
+		}
+		value, isConst = foldOperator(operator, value, right)
+	}
+	return errors
+}
+
+func overflows(operator string, left constValue, right constValue) bool {
+	//Whether an int operator applied to constants has a result out of the range of int
+	l, r := left.intValue, right.intValue
+	switch operator {
+	case "+":
+		return (l < 0) == (r < 0) && (l+r < 0) != (l < 0)
+	case "-":
+		return (l < 0) != (r < 0) && (l-r < 0) != (l < 0)
+	case "*":
+		return l != 0 && ((l*r)/l != r || l == -1 && r == math.MinInt64)
+	case "/":
+		return l == math.MinInt64 && r == -1
+	case "<<":
+		return l != 0 && r >= 0 && (r >= 64 || l<<uint(r)>>uint(r) != l)
+	}
+	return false
+}
+
+func foldOperator(operator string, left constValue, right constValue) (constValue, bool) {
+	//Value of an int operator applied to constants, a negative shift is left to fail when the program runs
+	if overflows(operator, left, right) {
+		return constValue{}, false
+	}
+	switch operator {
+	case "+":
+		return constValue{intValue: left.intValue + right.intValue}, true
+	case "-":
+		return constValue{intValue: left.intValue - right.intValue}, true
+	case "|":
+		return constValue{intValue: left.intValue | right.intValue}, true
+	case "^":
+		return constValue{intValue: left.intValue ^ right.intValue}, true
+	case "*":
+		return constValue{intValue: left.intValue * right.intValue}, true
+	case "&":
+		return constValue{intValue: left.intValue & right.intValue}, true
+	case "&^":
+		return constValue{intValue: left.intValue &^ right.intValue}, true
+	}
+	if right.intValue == 0 && (operator == "/" || operator == "%") || right.intValue < 0 && (operator == "<<" || operator == ">>") {
+		return constValue{}, false
+	}
+	switch operator {
+	case "/":
+		return constValue{intValue: left.intValue / right.intValue}, true
+	case "%":
+		return constValue{intValue: left.intValue % right.intValue}, true
+	case "<<":
+		return constValue{intValue: left.intValue << uint(right.intValue)}, true
+	default: // ">>"
+		return constValue{intValue: left.intValue >> uint(right.intValue)}, true
+	}
+}
+
+func immediateOperator(operator string) bool {
+	//Whether the instruction of the int operator takes an immediate operand
+	return operator != "*" && operator != "/" && operator != "%"
+}
+
+func operatorInstruction(operator string, target int, leftSource int, operand int, opty ir.OperandTy) ir.Instruction {
+	//Instruction applying an int operator, "*", "/" and "%" only take a register operand
+	switch operator {
+	case "+":
+		return ir.NewAdd(target, leftSource, operand, opty)
+	case "-":
+		return ir.NewSub(target, leftSource, operand, opty)
+	case "|":
+		return ir.NewOr(target, leftSource, operand, opty)
+	case "^":
+		return ir.NewXor(target, leftSource, operand, opty)
+	case "&":
+		return ir.NewAnd(target, leftSource, operand, opty)
+	case "&^":
+		return ir.NewBic(target, leftSource, operand, opty)
+	case "<<":
+		return ir.NewLsl(target, leftSource, operand, opty)
+	case ">>":
+		return ir.NewAsr(target, leftSource, operand, opty)
+	case "*":
+		return ir.NewMul(target, leftSource, operand)
+	case "/":
+		return ir.NewDiv(target, leftSource, operand)
+	default: // "%"
+		return ir.NewMod(target, leftSource, operand)
 	}
-	p.RegisterLoc = leftSource
 }
 
 type UnaryTerm struct {
-	Token         *token.Token
-	UnaryOperator string // '!' | '-' | '' <- default
+	Token *token.Token
+	Span
+	UnaryOperator string // '!' | '-' | '^' | '' <- default
 	SelectorTerm  *SelectorTerm
 	RegisterLoc   int
 }
 
 func NewUnaryTerm(operator string, selectorTerm *SelectorTerm) *UnaryTerm {
-	return &UnaryTerm{nil, operator, selectorTerm, -1}
+	return &UnaryTerm{nil, Span{}, operator, selectorTerm, -1}
 }
 
 func (p *UnaryTerm) TokenLiteral() string {
@@ -2046,20 +3725,47 @@ func (p *UnaryTerm) String() string {
 }
 
 func (p *UnaryTerm) GetType(symTable *st.SymbolTable) types.Type {
+	if p.UnaryOperator == "!" {
+		return types.BoolTySig
+	} else if p.UnaryOperator == "-" || p.UnaryOperator == "^" {
+		return types.IntTySig
+	}
 	return p.SelectorTerm.GetType(symTable)
 }
 
+func (p *UnaryTerm) constant(symTable *st.SymbolTable) (constValue, bool) {
+	value, isConst := p.SelectorTerm.constant(symTable)
+	if p.UnaryOperator == "!" {
+		return constValue{boolValue: !value.boolValue}, isConst
+	} else if p.UnaryOperator == "-" {
+		return constValue{intValue: -value.intValue}, isConst && value.intValue != math.MinInt64
+	} else if p.UnaryOperator == "^" {
+		return constValue{intValue: ^value.intValue}, isConst
+	}
+	return value, isConst
+}
+
 func (p *UnaryTerm) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	errors = p.SelectorTerm.TypeCheck(errors, symTable)
 	seleType := p.SelectorTerm.GetType(symTable)
+	if !isKnown(seleType) {
+		return errors
+	}
 	if p.UnaryOperator == "!" && seleType != types.BoolTySig {
-		errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{types.BoolTySig.GetName()}, #{seleType.GetName()} found."))
-	} else if p.UnaryOperator == "-" && seleType != types.IntTySig {
-		errors = append(errors, fmt.Sprintf("#{p.Token.LineNum}: expected #{types.IntTySig.GetName()}, #{seleType.GetName()} found."))
+		errors = append(errors, semanticError(p.Token, "Operator ! expected: bool, found: %s", seleType.GetName()))
+	} else if (p.UnaryOperator == "-" || p.UnaryOperator == "^") && seleType != types.IntTySig {
+		errors = append(errors, semanticError(p.Token, "Operator %s expected: int, found: %s", p.UnaryOperator, seleType.GetName()))
+	} else if value, isConst := p.SelectorTerm.constant(symTable); p.UnaryOperator == "-" && isConst && value.intValue == math.MinInt64 {
+		errors = append(errors, semanticError(p.Token, "constant overflows int"))
 	}
 	return errors
 }
 
 func (p *UnaryTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	if value, isConst := p.constant(table); isConst {
+		p.RegisterLoc = foldConstant(frag, value)
+		return
+	}
 	p.SelectorTerm.TranslateToILoc(frag, table)
 	if p.UnaryOperator == "" {
 		p.RegisterLoc = p.SelectorTerm.RegisterLoc
@@ -2068,6 +3774,11 @@ func (p *UnaryTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 		instruction := ir.NewNot(target, p.SelectorTerm.RegisterLoc, ir.REGISTER)
 		frag.Body = append(frag.Body, instruction)
 		p.RegisterLoc = target
+	} else if p.UnaryOperator == "^" {
+		// the complement flips every bit
+		target := ir.NewRegister()
+		frag.Body = append(frag.Body, ir.NewXor(target, p.SelectorTerm.RegisterLoc, -1, ir.IMMEDIATE))
+		p.RegisterLoc = target
 	} else { // "-"
 		target1 := ir.NewRegister()
 		instruction1 := ir.NewMov(target1, 0, ir.AL, ir.IMMEDIATE) // mov r_x,#0
@@ -2079,14 +3790,15 @@ func (p *UnaryTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 }
 
 type SelectorTerm struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	Fact        *Factor
-	Idents      []IdentLiteral
+	Selectors   []Selector
 	RegisterLoc int
 }
 
-func NewSelectorTerm(factor *Factor, idents []IdentLiteral) *SelectorTerm {
-	return &SelectorTerm{nil, factor, idents, -1}
+func NewSelectorTerm(factor *Factor, selectors []Selector) *SelectorTerm {
+	return &SelectorTerm{nil, Span{}, factor, selectors, -1}
 }
 
 func (s *SelectorTerm) TokenLiteral() string {
@@ -2099,74 +3811,231 @@ func (s *SelectorTerm) TokenLiteral() string {
 func (s *SelectorTerm) String() string {
 	out := bytes.Buffer{}
 	out.WriteString(s.Fact.String())
-	for _, id := range s.Idents {
-		out.WriteString(".")
-		out.WriteString(id.String())
+	for _, selector := range s.Selectors {
+		out.WriteString(selector.String())
 	}
 	return out.String()
 }
 
 func (s *SelectorTerm) GetType(symTable *st.SymbolTable) types.Type {
-	facType := s.Fact.GetType(symTable)
-	if len(s.Idents) == 0 {
-		return facType
-	} else {
-		return types.StructTySig
+	return selectorsType(s.Fact.GetType(symTable), s.Selectors, symTable)
+}
+
+func (s *SelectorTerm) constant(symTable *st.SymbolTable) (constValue, bool) {
+	if len(s.Selectors) != 0 {
+		return constValue{}, false
 	}
+	return s.Fact.constant(symTable)
 }
 
 func (s *SelectorTerm) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	stCopy := *symTable
+	errors = s.Fact.TypeCheck(errors, symTable)
+	return checkSelectors(errors, s.Fact.GetType(symTable), s.Selectors, symTable)
+}
+
+func (s *SelectorTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	s.Fact.TranslateToILoc(frag, table)
+	s.RegisterLoc = translateSelectors(frag, s.Fact.RegisterLoc, s.Fact.GetType(table), s.Selectors, table)
+}
 
-	var entry st.Entry
-	var exist bool
-	for _, id := range s.Idents {
-		varName := id.TokenLiteral()
-		if entry, exist = stCopy.Contain(varName); exist {
-			errors = append(errors, fmt.Sprintf("%s has not been declared on line num:%d", varName, id.Token.Rows))
-			break
+type Selector struct {
+	Token *token.Token
+	Span
+	Field   *IdentLiteral // Field of a ".id" selector, or the method of a ".id(Arguments)" one
+	Index   *Expression   // Index of a "[Expression]" selector
+	Args    *Arguments    // Arguments of a method call
+	Results []int         // Registers of the results of a method call
+}
+
+func NewFieldSelector(field IdentLiteral) *Selector {
+	return &Selector{field.Token, field.Span, &field, nil, nil, nil}
+}
+
+func NewIndexSelector(index *Expression) *Selector {
+	return &Selector{nil, Span{}, nil, index, nil, nil}
+}
+
+func NewMethodSelector(method IdentLiteral, args *Arguments) *Selector {
+	return &Selector{method.Token, method.Span, &method, nil, args, nil}
+}
+
+func (s *Selector) String() string {
+	if s.Args != nil {
+		return "." + s.Field.String() + s.Args.String()
+	}
+	if s.Field != nil {
+		return "." + s.Field.String()
+	}
+	return "[" + s.Index.String() + "]"
+}
+
+func (s *Selector) method(curType types.Type, symTable *st.SymbolTable) (st.Entry, bool) {
+	//Entry of the method a method call selector calls on a value of type curType
+	if !types.IsStruct(curType) {
+		return nil, false
+	}
+	return symTable.ContainMethod(curType.GetName(), s.Field.Id)
+}
+
+func (s *Selector) apply(curType types.Type, symTable *st.SymbolTable) (types.Type, bool) {
+	//Type of the field or the element selected from a value of type curType, or the result of the method called on it
+	if s.Args != nil {
+		if methodEntry, exist := s.method(curType, symTable); exist {
+			return methodEntry.GetValue().ReturnType, true
 		}
-		stCopy = *entry.GetValue().LocalSymbolTable
+		return types.UnknownTySig, false
 	}
-	return errors
+	if s.Field != nil {
+		return fieldType(curType, s.Field.Id, symTable)
+	}
+	if elemType := elementType(curType); elemType != nil {
+		return elemType, true
+	}
+	return types.UnknownTySig, false
 }
 
-func (s *SelectorTerm) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	//TODO: support nested structure
-	s.Fact.TranslateToILoc(frag, table)
-	s.RegisterLoc = s.Fact.RegisterLoc
-	if s.Idents == nil {
+func (s *Selector) element(frag *ir.FuncFrag, reg int, curType types.Type, table *st.SymbolTable) (int, int) {
+	/*
+		Evaluate the index and check it against the length of the array or the
+		slice in reg. Return the registers holding the address of the elements
+		and the index.
+	*/
+	s.Index.TranslateToILoc(frag, table)
+	index := *s.Index.RegisterLoc
+	if arrayTy, isArray := curType.(*types.ArrayTy); isArray {
+		frag.Body = append(frag.Body, ir.NewCheckBounds(index, arrayTy.Len(), ir.IMMEDIATE))
+		return reg, index
+	}
+	length := ir.NewRegister()
+	elements := ir.NewRegister()
+	frag.Body = append(frag.Body, ir.NewLen(length, reg), ir.NewCheckBounds(index, length, ir.REGISTER))
+	frag.Body = append(frag.Body, ir.NewLoadRef(elements, reg, "data", "slice", ir.SliceData))
+	return elements, index
+}
+
+func (s *Selector) load(frag *ir.FuncFrag, reg int, curType types.Type, table *st.SymbolTable) int {
+	//Load the field or the element selected from the value in reg into a new register, or call the method on it
+	if s.Args != nil {
+		// the receiver is passed before the arguments
+		argRegs := []int{reg}
+		for idx := range s.Args.Exprs {
+			s.Args.Exprs[idx].TranslateToILoc(frag, table)
+			argRegs = append(argRegs, *s.Args.Exprs[idx].RegisterLoc)
+		}
+		methodEntry, _ := s.method(curType, table)
+		s.Results = emitCall(frag, st.MethodLabel(curType.GetName(), s.Field.Id), argRegs, methodEntry.GetValue().ReturnType)
+		return s.Results[0]
+	}
+	target := ir.NewRegister()
+	if s.Field != nil {
+		structName := curType.GetName()
+		frag.Body = append(frag.Body, ir.NewLoadRef(target, reg, s.Field.Id, structName, fieldIndex(structName, s.Field.Id, table)))
+		return target
+	}
+	elements, index := s.element(frag, reg, curType, table)
+	frag.Body = append(frag.Body, ir.NewLoadIndex(target, elements, index))
+	return target
+}
+
+func (s *Selector) store(frag *ir.FuncFrag, reg int, curType types.Type, value int, table *st.SymbolTable) {
+	//Store value into the field or the element selected from the value in reg
+	if s.Field != nil {
+		structName := curType.GetName()
+		frag.Body = append(frag.Body, ir.NewStrRef(value, reg, s.Field.Id, structName, fieldIndex(structName, s.Field.Id, table)))
 		return
 	}
-	newLoc := ir.NewRegister()
-	var structName string
-	var paraOffSet int
-	countFields := 0
-	if stuctEntry, exist := table.Contain(s.Fact.Expr.TokenLiteral()); !exist {
-		panic("SA fail")
-	} else {
-		structName = stuctEntry.GetValue().EntryType.GetName()
-		for _, currField := range stuctEntry.GetValue().ParaNames {
-			if s.Idents[0].Id == currField {
-				break
+	elements, index := s.element(frag, reg, curType, table)
+	frag.Body = append(frag.Body, ir.NewStrIndex(value, elements, index))
+}
+
+func selectorsType(curType types.Type, selectors []Selector, symTable *st.SymbolTable) types.Type {
+	for idx := range selectors {
+		curType, _ = selectors[idx].apply(curType, symTable)
+	}
+	return curType
+}
+
+func checkSelectors(errors []string, curType types.Type, selectors []Selector, symTable *st.SymbolTable) []string {
+	//Check every selector against the type of the value before it, indexes must be ints
+	for idx := range selectors {
+		selector := &selectors[idx]
+		if selector.Args != nil {
+			errors = checkMethod(errors, curType, selector, symTable)
+			if nextType, _ := selector.apply(curType, symTable); nextType == types.NilTySig {
+				errors = append(errors, semanticError(selector.Token, "Method %s does not return a value", selector.Field.Id))
+			} else if results := types.Results(nextType); len(results) > 1 {
+				errors = append(errors, semanticError(selector.Token, "Method %s returns %d values, it cannot be used as a single value", selector.Field.Id, len(results)))
+			}
+			curType, _ = selector.apply(curType, symTable)
+			continue
+		}
+		if selector.Index != nil {
+			errors = selector.Index.TypeCheck(errors, symTable)
+			if indexType := selector.Index.GetType(symTable); isKnown(indexType) && indexType != types.IntTySig {
+				errors = append(errors, semanticError(selector.Token, "Index expected: int, found: %s", indexType.GetName()))
 			}
-			countFields += 1
 		}
+		if !isKnown(curType) {
+			continue
+		}
+		nextType, exist := selector.apply(curType, symTable)
+		if !exist && selector.Field != nil {
+			errors = append(errors, semanticError(selector.Token, "%s has no field named %s", curType.GetName(), selector.Field.Id))
+		} else if !exist {
+			errors = append(errors, semanticError(selector.Token, "Cannot index %s, expected an array or a slice", curType.GetName()))
+		} else if selector.Index != nil {
+			errors = checkConstantIndex(errors, curType, selector.Index, symTable)
+		}
+		curType = nextType
+	}
+	return errors
+}
+
+func checkConstantIndex(errors []string, curType types.Type, index *Expression, symTable *st.SymbolTable) []string {
+	//A constant index is checked at compile time, against the length of an array or for being negative
+	value, isConst := index.constant(symTable)
+	if !isConst {
+		return errors
+	}
+	if arrayTy, isArray := curType.(*types.ArrayTy); isArray && (value.intValue < 0 || value.intValue >= arrayTy.Len()) {
+		return append(errors, semanticError(index.Token, "index %d out of bounds [0:%d]", value.intValue, arrayTy.Len()))
+	} else if value.intValue < 0 {
+		return append(errors, semanticError(index.Token, "index %d must not be negative", value.intValue))
 	}
+	return errors
+}
+
+func checkMethod(errors []string, curType types.Type, selector *Selector, symTable *st.SymbolTable) []string {
+	//Check that the method exists for the type of the receiver and that the arguments match its parameters
+	errors = selector.Args.TypeCheck(errors, symTable)
+	if !isKnown(curType) {
+		return errors
+	}
+	methodEntry, exist := selector.method(curType, symTable)
+	if !exist {
+		return append(errors, semanticError(selector.Token, "%s has no method named %s", types.TypeString(curType), selector.Field.Id))
+	}
+	return checkArguments(errors, selector.Field, methodEntry.GetValue().Parameters, selector.Args, symTable)
+}
 
-	frag.Body = append(frag.Body, ir.NewLoadRef(newLoc, s.RegisterLoc, s.Idents[0].Id, structName, paraOffSet))
-	s.RegisterLoc = newLoc
-	return
+func translateSelectors(frag *ir.FuncFrag, reg int, curType types.Type, selectors []Selector, table *st.SymbolTable) int {
+	//Apply the selectors one after the other to the value in reg, return the register holding the result
+	for idx := range selectors {
+		reg = selectors[idx].load(frag, reg, curType, table)
+		curType, _ = selectors[idx].apply(curType, table)
+	}
+	return reg
 }
 
 type Factor struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	Expr        Expr
 	RegisterLoc int
 }
 
 func NewFactor(expr *Expr) *Factor {
-	return &Factor{nil, *expr, -1}
+	return &Factor{nil, Span{}, *expr, -1}
 }
 
 func (p *Factor) TokenLiteral() string {
@@ -2186,8 +4055,25 @@ func (p *Factor) GetType(symTable *st.SymbolTable) types.Type {
 	return p.Expr.GetType(symTable)
 }
 
+func (p *Factor) constant(symTable *st.SymbolTable) (constValue, bool) {
+	//Literals, constants and parenthesized constant expressions have a value at compile time
+	switch expr := p.Expr.(type) {
+	case *IntLiteral:
+		return constValue{intValue: int(expr.Value)}, true
+	case *BoolLiteral:
+		return constValue{boolValue: expr.BoolValue}, true
+	case *IdentLiteral:
+		if entry, isConst := symTable.ContainConstant(expr.Id); isConst {
+			return constValue{entry.GetValue().IntValue, entry.GetValue().BoolValue}, true
+		}
+	case *PriorityExpression:
+		return expr.InnerExpression.constant(symTable)
+	}
+	return constValue{}, false
+}
+
 func (p *Factor) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
-	return errors
+	return p.Expr.TypeCheck(errors, symTable)
 }
 
 func (p *Factor) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
@@ -2196,7 +4082,8 @@ func (p *Factor) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 }
 
 type IntLiteral struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	Value       int64
 	RegisterLoc int
 }
@@ -2221,8 +4108,30 @@ func (il *IntLiteral) GetTargetReg() int {
 	return il.RegisterLoc
 }
 
+type StringLiteral struct {
+	Token *token.Token
+	Span
+	Value       string // The literal with its escape sequences decoded
+	RegisterLoc int
+}
+
+func (sl *StringLiteral) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	sl.RegisterLoc = ir.NewRegister()
+	frag.Body = append(frag.Body, ir.NewLoadStr(sl.RegisterLoc, sl.Value))
+}
+
+func (sl *StringLiteral) GetRegLoc() int {
+	return sl.RegisterLoc
+}
+
+func (sl *StringLiteral) TokenLiteral() string                                         { return sl.Token.Literal }
+func (sl *StringLiteral) String() string                                               { return sl.Token.Literal }
+func (sl *StringLiteral) GetType(symTable *st.SymbolTable) types.Type                  { return types.StringTySig }
+func (sl *StringLiteral) TypeCheck(errors []string, symTable *st.SymbolTable) []string { return errors }
+
 type IdentLiteral struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	Id          string //Token.Literal
 	RegisterLoc int
 }
@@ -2234,23 +4143,34 @@ func (idl *IdentLiteral) GetRegLoc() int {
 func (idl *IdentLiteral) TokenLiteral() string { return idl.Token.Literal }
 func (idl *IdentLiteral) String() string       { return idl.Token.Literal }
 func (idl *IdentLiteral) GetType(symTable *st.SymbolTable) types.Type {
+	//A function is not a value, its name only has a type when it is called
 	if idEntry, find := symTable.Contain(idl.TokenLiteral()); !find {
 		return types.UnknownTySig
+	} else if _, isFunction := symTable.ContainFunction(idl.Id); isFunction {
+		return types.UnknownTySig
 	} else {
 		return idEntry.GetValue().EntryType
 	}
 }
 
 func (idl *IdentLiteral) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	if _, isFunction := symTable.ContainFunction(idl.Id); isFunction {
+		return append(errors, semanticError(idl.Token, "%s (func) used as value", idl.Id))
+	}
 	idlTy := idl.GetType(symTable)
 	if idlTy == types.UnknownTySig {
-		errors = append(errors, fmt.Sprintf("[#{idl.Token.LineNum}]: #{idl.Id} has not been defined."))
+		errors = append(errors, semanticError(idl.Token, "%s has not been defined", idl.Id))
 	}
 	return errors
 }
 
 func (idl *IdentLiteral) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	if _, exist := table.ContainGlobally(idl.Id); exist { // if the ident is a global variable
+	if entry, isConst := table.ContainConstant(idl.Id); isConst {
+		idl.RegisterLoc = foldConstant(frag, constValue{entry.GetValue().IntValue, entry.GetValue().BoolValue})
+		return
+	}
+	if _, isGlobal := table.ContainGlobally(idl.Id); isGlobal { // if the ident is a global variable
+		idl.RegisterLoc = ir.NewRegister()
 		instruction := ir.NewLdr(idl.RegisterLoc, -1, -1, idl.Id, ir.GLOBALVAR)
 		frag.Body = append(frag.Body, instruction)
 	} else {
@@ -2263,7 +4183,8 @@ func (idl *IdentLiteral) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTabl
 }
 
 type BoolLiteral struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	BoolValue   bool
 	RegisterLoc int
 }
@@ -2287,16 +4208,18 @@ func (bl *BoolLiteral) TypeCheck(errors []string, symTable *st.SymbolTable) []st
 func (bl *BoolLiteral) GetType(symTable *st.SymbolTable) types.Type                  { return types.BoolTySig }
 
 type NilLiteral struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	RegisterLoc int
 }
 
 func (n *NilLiteral) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
 	n.RegisterLoc = ir.NewRegister()
+	frag.Body = append(frag.Body, ir.NewMov(n.RegisterLoc, 0, ir.AL, ir.IMMEDIATE))
 }
 
 func (n *NilLiteral) GetRegLoc() int {
-	panic("implement me")
+	return n.RegisterLoc
 }
 
 func (n *NilLiteral) TokenLiteral() string                                         { return n.Token.Literal }
@@ -2305,49 +4228,27 @@ func (n *NilLiteral) GetType(symTable *st.SymbolTable) types.Type
 func (n *NilLiteral) TypeCheck(errors []string, symTable *st.SymbolTable) []string { return errors }
 
 type InvocExpr struct {
-	Token       *token.Token
+	Token *token.Token
+	Span
 	Ident       IdentLiteral
 	InnerArgs   *Arguments
 	RegisterLoc int
+	Results     []int // Registers of every result, RegisterLoc is the first one
 }
 
 func (ie *InvocExpr) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
-	//Handle the new case
-	if ie.Ident.TokenLiteral() == "new" {
-		if entry, exist := table.Contain(ie.InnerArgs.Exprs[0].Token.Literal); !exist {
-			panic("fail sa")
-		} else {
-			frag.Body = append(frag.Body, ir.GetNewStructInst(entry.GetValue().RegisterLoc, ie.InnerArgs.Exprs[0].Token.Literal, len(entry.GetValue().ParaNames)))
-		}
-		return
-	}
-
-	//Mov
-	//Get function name
-	funcName := ie.Ident.Id
-	_, exist := table.Contain(funcName)
-	if !exist {
-		panic("Fail sa")
-	}
-	//Get arg int list
-	argIntList := []int{}
-	for _, arg := range ie.InnerArgs.Exprs {
-		arg.TranslateToILoc(frag, table)
-		argIntList = append(argIntList, *arg.RegisterLoc)
+	//Handle the builtins
+	switch ie.Ident.TokenLiteral() {
+	case "new":
+		ie.Results = []int{translateNew(frag, ie.InnerArgs, table)}
+	case "len":
+		ie.Results = []int{translateLen(frag, ie.InnerArgs, table)}
+	case "append":
+		ie.Results = []int{translateAppend(frag, ie.InnerArgs, table)}
+	default:
+		ie.Results = translateCall(frag, ie.Ident, ie.InnerArgs, table)
 	}
-	frag.Body = append(frag.Body, ir.NewPush(argIntList, ie.Ident.Id))
-
-	// bl
-	frag.Body = append(frag.Body, ir.NewBl(ie.Ident.TokenLiteral()))
-
-	// mov retrun result to tmp
-	ie.RegisterLoc = ir.NewRegister()
-	movInst := ir.NewMov(ie.RegisterLoc, 0, ir.MARG, ir.REGISTER)
-	movInst.SetRetFlag()
-	frag.Body = append(frag.Body, movInst)
-
-	//pop
-	frag.Body = append(frag.Body, ir.NewPop(argIntList, ie.Ident.Id))
+	ie.RegisterLoc = ie.Results[0]
 }
 
 func (ie *InvocExpr) GetRegLoc() int {
@@ -2369,23 +4270,137 @@ func (ie *InvocExpr) String() string {
 }
 
 func (ie *InvocExpr) GetType(symTable *st.SymbolTable) types.Type {
-	if funcEntry, find := symTable.Contain(ie.Ident.Id); find {
-		return funcEntry.GetValue().EntryType
+	if ie.Ident.Id == "new" {
+		if len(ie.InnerArgs.Exprs) == 1 {
+			if _, exist := symTable.ContainStructure(ie.InnerArgs.Exprs[0].String()); exist {
+				return types.NewStructTy(ie.InnerArgs.Exprs[0].String())
+			}
+		}
+		return types.UnknownTySig
+	}
+	if ie.Ident.Id == "len" {
+		return types.IntTySig
+	}
+	if ie.Ident.Id == "append" {
+		// append returns a slice of the type of its first argument
+		if len(ie.InnerArgs.Exprs) > 0 && types.IsSlice(ie.InnerArgs.Exprs[0].GetType(symTable)) {
+			return ie.InnerArgs.Exprs[0].GetType(symTable)
+		}
+		return types.UnknownTySig
+	}
+	if funcEntry, find := symTable.ContainFunction(ie.Ident.Id); find {
+		return funcEntry.GetValue().ReturnType
 	}
 	return types.UnknownTySig
 }
 func (ie *InvocExpr) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
 	// refer from Invocation.TypeCheck
-	funcName := ie.Ident.TokenLiteral()
-	_, find := symTable.Contain(funcName)
-	if !find {
-		errors = append(errors, fmt.Sprintf("#{ie.Token.LineNum}: function #{funcName} has not been defined"))
+	errors = checkCall(errors, &ie.Ident, ie.InnerArgs, symTable)
+	if ie.GetType(symTable) == types.NilTySig {
+		errors = append(errors, semanticError(ie.Token, "Function %s does not return a value", ie.Ident.Id))
+	} else if results := types.Results(ie.GetType(symTable)); len(results) > 1 {
+		errors = append(errors, semanticError(ie.Token, "Function %s returns %d values, it cannot be used as a single value", ie.Ident.Id, len(results)))
+	}
+	return errors
+}
+
+type MakeExpr struct {
+	Token *token.Token
+	Span
+	Type        *Type
+	Length      *Expression
+	Capacity    *Expression // Capacity of the slice, the length when it is nil
+	RegisterLoc int
+}
+
+func (m *MakeExpr) TranslateToILoc(frag *ir.FuncFrag, table *st.SymbolTable) {
+	//Allocate the elements and the header of the slice
+	m.Length.TranslateToILoc(frag, table)
+	length := *m.Length.RegisterLoc
+	capacity := length
+	if m.Capacity != nil {
+		m.Capacity.TranslateToILoc(frag, table)
+		capacity = *m.Capacity.RegisterLoc
+	}
+	// sizes known at compile time have been checked by TypeCheck
+	_, constLength := m.Length.constant(table)
+	constCapacity := constLength
+	if m.Capacity != nil {
+		_, constCapacity = m.Capacity.constant(table)
+	}
+	if !constLength || !constCapacity {
+		frag.Body = append(frag.Body, ir.NewCheckMake(length, capacity))
+	}
+	elements := ir.NewRegister()
+	m.RegisterLoc = ir.NewRegister()
+	frag.Body = append(frag.Body, ir.GetNewArrayInst(elements, capacity, ir.REGISTER), ir.GetNewStructInst(m.RegisterLoc, "slice", ir.SliceHeaderSize))
+	frag.Body = append(frag.Body, ir.NewStrRef(length, m.RegisterLoc, "len", "slice", ir.SliceLen))
+	frag.Body = append(frag.Body, ir.NewStrRef(capacity, m.RegisterLoc, "cap", "slice", ir.SliceCap))
+	frag.Body = append(frag.Body, ir.NewStrRef(elements, m.RegisterLoc, "data", "slice", ir.SliceData))
+}
+
+func (m *MakeExpr) GetRegLoc() int {
+	return m.RegisterLoc
+}
+
+func (m *MakeExpr) TokenLiteral() string {
+	if m.Token != nil {
+		return m.Token.Literal
+	}
+	panic("Could not determine token literal for make expression inside Factor")
+}
+
+func (m *MakeExpr) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("make(")
+	out.WriteString(m.Type.String())
+	out.WriteString(",")
+	out.WriteString(m.Length.String())
+	if m.Capacity != nil {
+		out.WriteString(",")
+		out.WriteString(m.Capacity.String())
+	}
+	out.WriteString(")")
+	return out.String()
+}
+
+func (m *MakeExpr) GetType(symTable *st.SymbolTable) types.Type {
+	if typeSig := m.Type.GetType(symTable); types.IsSlice(typeSig) {
+		return typeSig
+	}
+	return types.UnknownTySig
+}
+
+func (m *MakeExpr) TypeCheck(errors []string, symTable *st.SymbolTable) []string {
+	//make builds a slice from an int length and capacity
+	errors = m.Type.TypeCheck(errors, symTable)
+	if typeSig := m.Type.GetType(symTable); isKnown(typeSig) && !types.IsSlice(typeSig) {
+		errors = append(errors, semanticError(m.Type.Token, "make expects a slice type, found: %s", typeSig.GetName()))
+	}
+	for _, size := range []*Expression{m.Length, m.Capacity} {
+		if size == nil {
+			continue
+		}
+		errors = size.TypeCheck(errors, symTable)
+		if sizeType := size.GetType(symTable); isKnown(sizeType) && sizeType != types.IntTySig {
+			errors = append(errors, semanticError(size.Token, "make expects an int size, found: %s", sizeType.GetName()))
+		} else if value, isConst := size.constant(symTable); isConst && value.intValue < 0 {
+			errors = append(errors, semanticError(size.Token, "make expects a non-negative size, found: %d", value.intValue))
+		}
+	}
+	if m.Capacity != nil {
+		length, constLength := m.Length.constant(symTable)
+		capacity, constCapacity := m.Capacity.constant(symTable)
+		if constLength && constCapacity && capacity.intValue >= 0 && length.intValue > capacity.intValue {
+			errors = append(errors, semanticError(m.Length.Token, "make expects a length no larger than the capacity, found: %d > %d", length.intValue, capacity.intValue))
+		}
 	}
 	return errors
 }
 
 type PriorityExpression struct {
-	Token           *token.Token
+	Token *token.Token
+	Span
 	InnerExpression *Expression
 	RegisterLoc     int
 }
@@ -2476,3 +4491,259 @@ func (f *Factor) GetRegLoc() int {
 //func (n *NilNode) GetRegLoc() int {
 //	return n.RegisterLoc
 //}
+
+func checkCall(errors []string, ident *IdentLiteral, args *Arguments, symTable *st.SymbolTable) []string {
+	/*
+		Check a call against the callee's entry: the builtins new and delete take a
+		struct, len an array, a slice or a string and append a slice followed by elements.
+		Any other callee must be a function whose parameter count and types
+		match the arguments
+	*/
+	if ident.Id == "new" {
+		if len(args.Exprs) != 1 {
+			return append(errors, semanticError(ident.Token, "new expects 1 argument, got %d", len(args.Exprs)))
+		}
+		if _, exist := symTable.ContainStructure(args.Exprs[0].String()); !exist {
+			errors = append(errors, semanticError(args.Exprs[0].Token, "new expects a struct type, %s is not a struct", args.Exprs[0].String()))
+		}
+		return errors
+	}
+	errors = args.TypeCheck(errors, symTable)
+	if ident.Id == "len" {
+		if len(args.Exprs) != 1 {
+			return append(errors, semanticError(ident.Token, "len expects 1 argument, got %d", len(args.Exprs)))
+		}
+		if argType := args.Exprs[0].GetType(symTable); isKnown(argType) && elementType(argType) == nil && argType != types.StringTySig {
+			errors = append(errors, semanticError(args.Exprs[0].Token, "len expects an array, a slice or a string, found: %s", argType.GetName()))
+		}
+		return errors
+	}
+	if ident.Id == "append" {
+		if len(args.Exprs) == 0 {
+			return append(errors, semanticError(ident.Token, "append expects a slice and the values to append"))
+		}
+		sliceType := args.Exprs[0].GetType(symTable)
+		if !isKnown(sliceType) {
+			return errors
+		}
+		if !types.IsSlice(sliceType) {
+			return append(errors, semanticError(args.Exprs[0].Token, "append expects a slice, found: %s", sliceType.GetName()))
+		}
+		for _, arg := range args.Exprs[1:] {
+			if argType := arg.GetType(symTable); isKnown(argType) && !types.AssignableTo(argType, elementType(sliceType)) {
+				errors = append(errors, semanticError(arg.Token, "Cannot append %s to %s", argType.GetName(), sliceType.GetName()))
+			}
+		}
+		return errors
+	}
+	if ident.Id == "delete" {
+		if len(args.Exprs) != 1 {
+			return append(errors, semanticError(ident.Token, "delete expects 1 argument, got %d", len(args.Exprs)))
+		}
+		if argType := args.Exprs[0].GetType(symTable); isKnown(argType) && !types.IsStruct(argType) {
+			errors = append(errors, semanticError(args.Exprs[0].Token, "delete expects a struct pointer, found: %s", argType.GetName()))
+		}
+		return errors
+	}
+	funcEntry, exist := symTable.ContainFunction(ident.Id)
+	if !exist {
+		return append(errors, semanticError(ident.Token, "Function named: %s is not defined", ident.Id))
+	}
+	return checkArguments(errors, ident, funcEntry.GetValue().Parameters, args, symTable)
+}
+
+func checkArguments(errors []string, ident *IdentLiteral, funcParaTypeList []types.Type, args *Arguments, symTable *st.SymbolTable) []string {
+	//Check the arguments of a call of the function or the method ident against its parameter types
+	if len(funcParaTypeList) != len(args.Exprs) {
+		return append(errors, semanticError(ident.Token, "Function named: %s expects %d arguments, got %d", ident.Id, len(funcParaTypeList), len(args.Exprs)))
+	}
+	for idx, funcParaType := range funcParaTypeList {
+		argType := args.Exprs[idx].GetType(symTable)
+		if isKnown(argType) && !types.AssignableTo(argType, funcParaType) {
+			errors = append(errors, semanticError(args.Exprs[idx].Token, "Unmatched funcion parameter type, expected %s, got %s", funcParaType.GetName(), argType.GetName()))
+		}
+	}
+	return errors
+}
+
+func lastFrag() *ir.FuncFrag {
+	//The fragment the translation currently appends to
+	return ir.ControlFlowFrags[len(ir.ControlFlowFrags)-1]
+}
+
+func continueAt(frag *ir.FuncFrag, label string) {
+	/*
+		Go on with the translation in a new fragment labelled label. An operator
+		branching within an expression does not know the fragment its caller
+		appends to next, so frag stays that fragment: it becomes the labelled one
+		and the instructions it has so far move to a fragment taking its place.
+	*/
+	if frag != lastFrag() {
+		panic("Fail translating a branch out of the last fragment")
+	}
+	ir.ControlFlowFrags[len(ir.ControlFlowFrags)-1] = &ir.FuncFrag{Label: frag.Label, Body: frag.Body}
+	frag.Label = label
+	frag.Body = []ir.Instruction{}
+	ir.ControlFlowFrags = append(ir.ControlFlowFrags, frag)
+}
+
+func fieldIndex(structName string, field string, table *st.SymbolTable) int {
+	//Position of the field in its struct, which is its slot in the heap cell
+	if entry, exist := table.ContainStructure(structName); exist {
+		for idx, name := range entry.GetValue().ParaNames {
+			if name == field {
+				return idx
+			}
+		}
+	}
+	panic("SA fail")
+}
+
+func translateNew(frag *ir.FuncFrag, args *Arguments, table *st.SymbolTable) int {
+	structName := args.Exprs[0].String()
+	entry, exist := table.ContainStructure(structName)
+	if !exist {
+		panic("fail sa")
+	}
+	target := ir.NewRegister()
+	frag.Body = append(frag.Body, ir.GetNewStructInst(target, structName, len(entry.GetValue().ParaNames)))
+	return target
+}
+
+func translateLen(frag *ir.FuncFrag, args *Arguments, table *st.SymbolTable) int {
+	//The length of an array is known from its type, the length of a slice or a string is in its first word
+	args.Exprs[0].TranslateToILoc(frag, table)
+	target := ir.NewRegister()
+	if arrayTy, isArray := args.Exprs[0].GetType(table).(*types.ArrayTy); isArray {
+		frag.Body = append(frag.Body, ir.NewMov(target, arrayTy.Len(), ir.AL, ir.IMMEDIATE))
+	} else {
+		frag.Body = append(frag.Body, ir.NewLen(target, *args.Exprs[0].RegisterLoc))
+	}
+	return target
+}
+
+func translateAppend(frag *ir.FuncFrag, args *Arguments, table *st.SymbolTable) int {
+	//Append the values one after the other, each append creates a new header
+	args.TranslateToILoc(frag, table)
+	slice := *args.Exprs[0].RegisterLoc
+	for _, value := range args.Exprs[1:] {
+		target := ir.NewRegister()
+		frag.Body = append(frag.Body, ir.NewAppend(target, slice, *value.RegisterLoc))
+		slice = target
+	}
+	return slice
+}
+
+func translateCall(frag *ir.FuncFrag, ident IdentLiteral, args *Arguments, table *st.SymbolTable) []int {
+	/*
+		Evaluate the arguments, call the function and move each returned value
+		into a new register. A function without results still gets one.
+	*/
+	funcEntry, exist := table.ContainFunction(ident.Id)
+	if !exist {
+		panic("Fail sa")
+	}
+	argIntList := []int{}
+	for idx := range args.Exprs {
+		args.Exprs[idx].TranslateToILoc(frag, table)
+		argIntList = append(argIntList, *args.Exprs[idx].RegisterLoc)
+	}
+	return emitCall(frag, ident.Id, argIntList, funcEntry.GetValue().ReturnType)
+}
+
+func emitCall(frag *ir.FuncFrag, label string, argIntList []int, returnType types.Type) []int {
+	//Push the evaluated arguments, call the function at label and move its results into new registers
+	frag.Body = append(frag.Body, ir.NewPush(argIntList, label))
+	// bl
+	frag.Body = append(frag.Body, ir.NewBl(label))
+	// mov retrun results to tmp
+	retRegs := []int{}
+	for idx := 0; idx == 0 || idx < len(types.Results(returnType)); idx++ {
+		retReg := ir.NewRegister()
+		movInst := ir.NewMov(retReg, 0, ir.MARG, ir.REGISTER)
+		movInst.SetRetFlag()
+		movInst.SetRetIndex(idx)
+		frag.Body = append(frag.Body, movInst)
+		retRegs = append(retRegs, retReg)
+	}
+	//pop
+	frag.Body = append(frag.Body, ir.NewPop(argIntList, label))
+	return retRegs
+}
+
+// callExpr is a call of a function or a method, whose value is every result of the callee
+type callExpr interface {
+	Expr
+	calleeName() string
+	checkCallee(errors []string, symTable *st.SymbolTable) []string // Check the call without requiring a single value
+	results() []int
+}
+
+func (ie *InvocExpr) calleeName() string { return ie.Ident.Id }
+func (ie *InvocExpr) results() []int     { return ie.Results }
+
+func (ie *InvocExpr) checkCallee(errors []string, symTable *st.SymbolTable) []string {
+	return checkCall(errors, &ie.Ident, ie.InnerArgs, symTable)
+}
+
+func (s *SelectorTerm) calleeName() string { return s.Selectors[len(s.Selectors)-1].Field.Id }
+func (s *SelectorTerm) results() []int     { return s.Selectors[len(s.Selectors)-1].Results }
+
+func (s *SelectorTerm) checkCallee(errors []string, symTable *st.SymbolTable) []string {
+	errors = s.Fact.TypeCheck(errors, symTable)
+	prefix := s.Selectors[:len(s.Selectors)-1]
+	errors = checkSelectors(errors, s.Fact.GetType(symTable), prefix, symTable)
+	receiverType := selectorsType(s.Fact.GetType(symTable), prefix, symTable)
+	return checkMethod(errors, receiverType, &s.Selectors[len(s.Selectors)-1], symTable)
+}
+
+func singleCall(exprs []Expression) callExpr {
+	//The call when the expressions are a single call of a function or a method, nil otherwise
+	if len(exprs) != 1 {
+		return nil
+	}
+	selectorTerm := soleSelectorTerm(&exprs[0])
+	if selectorTerm == nil {
+		return nil
+	}
+	if selectors := selectorTerm.Selectors; len(selectors) != 0 {
+		if selectors[len(selectors)-1].Args == nil {
+			return nil
+		}
+		return selectorTerm
+	}
+	call, isCall := selectorTerm.Fact.Expr.(*InvocExpr)
+	if !isCall {
+		return nil
+	}
+	return call
+}
+
+func soleSelectorTerm(expr *Expression) *SelectorTerm {
+	//The selector term when it is the whole expression, nil when the expression has operators
+	if len(expr.Rights) != 0 || len(expr.Left.EqualTermList) != 1 {
+		return nil
+	}
+	equalTerm := expr.Left.EqualTermList[0]
+	if len(equalTerm.RelationTermList) != 1 || len(equalTerm.RelationTermList[0].Rights) != 0 {
+		return nil
+	}
+	simpleTerm := equalTerm.RelationTermList[0].Left
+	if len(simpleTerm.Rights) != 0 || len(simpleTerm.Left.Rights) != 0 {
+		return nil
+	}
+	unaryTerm := simpleTerm.Left.Left
+	if unaryTerm.UnaryOperator != "" {
+		return nil
+	}
+	return unaryTerm.SelectorTerm
+}
+
+func intConstant(expr *Expression, symTable *st.SymbolTable) (int, bool) {
+	//The value of the expression when it is an integer constant
+	if expr.GetType(symTable) != types.IntTySig {
+		return 0, false
+	}
+	value, isConst := expr.constant(symTable)
+	return value.intValue, isConst
+}
@@ -0,0 +1,631 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"proj/ir"
+)
+
+// Registers carrying the first integer arguments in the System V AMD64 ABI
+var amd64ArgRegs = []string{"%rdi", "%rsi", "%rdx", "%rcx", "%r8", "%r9"}
+
+// Registers carrying the results of a golite function, the first one is the ABI's %rax
+var amd64RetRegs = []string{"%rax", "%rdx", "%rcx", "%rsi", "%rdi", "%r8", "%r9", "%r10"}
+
+// amd64 emits GNU assembler code for x86-64 Linux, %rax, %rcx and %rdx are scratch registers
+type amd64 struct {
+	frame        *Frame // Frame of the function being emitted
+	stackArgs    int    // Arguments of the pending call passed on the stack, with padding
+	printExist   bool
+	printlnExist bool
+	scanExist    bool
+	indexExist   bool // Whether a bounds check jumps to .Lgolite_index
+	makeExist    bool // Whether a make check jumps to .Lgolite_makelen and .Lgolite_makecap
+	appendExist  bool // Whether .Lgolite_append is called
+	concatExist  bool // Whether .Lgolite_concat is called
+	strEqExist   bool // Whether .Lgolite_streq is called
+	printStr     bool // Whether a string is printed with .PRINT_STR
+	printlnStr   bool // Whether a string is printed with .PRINT_STR_LN
+	strings      stringPool
+	tables       int // Number of jump tables emitted so far
+}
+
+func NewAmd64() Target {
+	return &amd64{}
+}
+
+func (target *amd64) Header(globals []string) []string {
+	insList := []string{}
+	for _, global := range globals {
+		insList = append(insList, fmt.Sprintf("\t.comm %v,8,8", global))
+	}
+	return append(insList, "\t.text")
+}
+
+func (target *amd64) Function(frame *Frame) []string {
+	insList := []string{}
+	name := frame.Name
+	target.frame = frame
+
+	insList = append(insList, "\t.globl "+name)
+	insList = append(insList, "\t.type "+name+", @function")
+	insList = append(insList, fmt.Sprintf("%v:", name))
+	insList = append(insList, "\tpushq %rbp")
+	insList = append(insList, "\tmovq %rsp, %rbp")
+	insList = append(insList, fmt.Sprintf("\tsubq $%v, %%rsp", frame.Size))
+	// move the parameters into their stack slots
+	for idx, reg := range frame.Params {
+		if idx < len(amd64ArgRegs) {
+			insList = append(insList, fmt.Sprintf("\tmovq %v, %v", amd64ArgRegs[idx], target.slot(reg)))
+		} else {
+			insList = append(insList, fmt.Sprintf("\tmovq %v(%%rbp), %%rax", 16+8*(idx-len(amd64ArgRegs))))
+			insList = append(insList, fmt.Sprintf("\tmovq %%rax, %v", target.slot(reg)))
+		}
+	}
+
+	for idx, frag := range frame.Frags {
+		if idx != 0 {
+			insList = append(insList, fmt.Sprintf("%v:", amd64Label(frag.Label)))
+		}
+		for _, instruction := range frag.Body {
+			if instruction != nil {
+				insList = append(insList, target.translate(instruction)...)
+			}
+		}
+	}
+
+	// falling off the end of a function returns 0
+	insList = append(insList, "\tmovq $0, %rax")
+	insList = append(insList, "\tleave")
+	insList = append(insList, "\tret")
+	insList = append(insList, "\t.size "+name+", .-"+name)
+	return insList
+}
+
+func (target *amd64) Footer() []string {
+	insList := []string{}
+	if target.indexExist {
+		insList = append(insList, amd64Panic(".Lgolite_index", ".INDEX")...)
+	}
+	if target.makeExist {
+		insList = append(insList, amd64Panic(".Lgolite_makelen", ".MAKE_LEN")...)
+		insList = append(insList, amd64Panic(".Lgolite_makecap", ".MAKE_CAP")...)
+	}
+	if target.appendExist {
+		insList = append(insList, amd64Append...)
+	}
+	if target.concatExist {
+		insList = append(insList, amd64Concat...)
+	}
+	if target.strEqExist {
+		insList = append(insList, amd64StrEq...)
+	}
+	insList = append(insList, "\t.section .rodata")
+	insList = append(insList, target.strings.data()...)
+	if target.indexExist {
+		insList = append(insList, ".INDEX:")
+		insList = append(insList, "\t.string \"panic: runtime error: index out of range\\n\"")
+	}
+	if target.makeExist {
+		insList = append(insList, ".MAKE_LEN:")
+		insList = append(insList, "\t.string \"panic: runtime error: makeslice: len out of range\\n\"")
+		insList = append(insList, ".MAKE_CAP:")
+		insList = append(insList, "\t.string \"panic: runtime error: makeslice: cap out of range\\n\"")
+	}
+	if target.printExist {
+		insList = append(insList, ".PRINT:")
+		insList = append(insList, "\t.string \"%ld\"")
+	}
+	if target.printlnExist {
+		insList = append(insList, ".PRINT_LN:")
+		insList = append(insList, "\t.string \"%ld\\n\"")
+	}
+	if target.printStr {
+		insList = append(insList, ".PRINT_STR:")
+		insList = append(insList, "\t.string \"%.*s\"")
+	}
+	if target.printlnStr {
+		insList = append(insList, ".PRINT_STR_LN:")
+		insList = append(insList, "\t.string \"%.*s\\n\"")
+	}
+	if target.scanExist {
+		insList = append(insList, ".READ:")
+		insList = append(insList, "\t.string \"%ld\"")
+	}
+	return append(insList, "\t.section .note.GNU-stack,\"\",@progbits")
+}
+
+func amd64Panic(label string, message string) []string {
+	//Print the panic message on stderr, exit flushes the output printed so far
+	return []string{
+		label + ":",
+		"\tmovq stderr@GOTPCREL(%rip), %rsi",
+		"\tmovq (%rsi), %rsi",
+		"\tleaq " + message + "(%rip), %rdi",
+		"\tcall fputs@PLT",
+		"\tmovl $1, %edi",
+		"\tcall exit@PLT",
+	}
+}
+
+// amd64Append returns in %rax the header of the slice in %rdi with %rsi appended,
+// the elements move to a cell twice as large, at least 4, when the capacity is exhausted
+var amd64Append = []string{
+	".Lgolite_append:",
+	"\tpushq %rbp",
+	"\tmovq %rsp, %rbp",
+	"\tpushq %rbx",
+	"\tpushq %r12",
+	"\tpushq %r13",
+	"\tpushq %r14",
+	"\tpushq %r15",
+	"\tsubq $8, %rsp",
+	"\tmovq %rsi, %r15",
+	"\txorl %r12d, %r12d",
+	"\txorl %r13d, %r13d",
+	"\txorl %r14d, %r14d",
+	"\ttestq %rdi, %rdi",
+	"\tje 1f",
+	"\tmovq (%rdi), %r12",
+	"\tmovq 8(%rdi), %r13",
+	"\tmovq 16(%rdi), %r14",
+	"1:",
+	"\tcmpq %r13, %r12",
+	"\tjl 2f",
+	"\taddq %r13, %r13",
+	"\tmovq $4, %rax",
+	"\tcmpq %rax, %r13",
+	"\tcmovlq %rax, %r13",
+	"\tmovq %r13, %rdi",
+	"\tmovq $8, %rsi",
+	"\tcall calloc@PLT",
+	"\tmovq %rax, %rbx",
+	"\tmovq %rax, %rdi",
+	"\tmovq %r14, %rsi",
+	"\tleaq 0(,%r12,8), %rdx",
+	"\tcall memcpy@PLT",
+	"\tmovq %rbx, %r14",
+	"2:",
+	"\tmovq %r15, (%r14,%r12,8)",
+	"\tmovq $24, %rdi",
+	"\tcall malloc@PLT",
+	"\tleaq 1(%r12), %rcx",
+	"\tmovq %rcx, (%rax)",
+	"\tmovq %r13, 8(%rax)",
+	"\tmovq %r14, 16(%rax)",
+	"\taddq $8, %rsp",
+	"\tpopq %r15",
+	"\tpopq %r14",
+	"\tpopq %r13",
+	"\tpopq %r12",
+	"\tpopq %rbx",
+	"\tpopq %rbp",
+	"\tret",
+}
+
+// amd64Concat returns in %rax a new string holding the bytes of the string in %rdi
+// followed by the bytes of the string in %rsi
+var amd64Concat = []string{
+	".Lgolite_concat:",
+	"\tpushq %rbp",
+	"\tmovq %rsp, %rbp",
+	"\tpushq %rbx",
+	"\tpushq %r12",
+	"\tpushq %r13",
+	"\tpushq %r14",
+	"\tpushq %r15",
+	"\tsubq $8, %rsp",
+	"\tmovq %rdi, %r12",
+	"\tmovq %rsi, %r13",
+	"\txorl %r14d, %r14d",
+	"\txorl %r15d, %r15d",
+	"\ttestq %r12, %r12",
+	"\tje 1f",
+	"\tmovq (%r12), %r14",
+	"1:",
+	"\ttestq %r13, %r13",
+	"\tje 2f",
+	"\tmovq (%r13), %r15",
+	"2:",
+	"\tleaq 8(%r14,%r15), %rdi",
+	"\tcall malloc@PLT",
+	"\tmovq %rax, %rbx",
+	"\tleaq (%r14,%r15), %rcx",
+	"\tmovq %rcx, (%rbx)",
+	"\ttestq %r14, %r14",
+	"\tje 3f",
+	"\tleaq 8(%rbx), %rdi",
+	"\tleaq 8(%r12), %rsi",
+	"\tmovq %r14, %rdx",
+	"\tcall memcpy@PLT",
+	"3:",
+	"\ttestq %r15, %r15",
+	"\tje 4f",
+	"\tleaq 8(%rbx,%r14), %rdi",
+	"\tleaq 8(%r13), %rsi",
+	"\tmovq %r15, %rdx",
+	"\tcall memcpy@PLT",
+	"4:",
+	"\tmovq %rbx, %rax",
+	"\taddq $8, %rsp",
+	"\tpopq %r15",
+	"\tpopq %r14",
+	"\tpopq %r13",
+	"\tpopq %r12",
+	"\tpopq %rbx",
+	"\tpopq %rbp",
+	"\tret",
+}
+
+// amd64StrEq returns in %rax 1 when the strings in %rdi and %rsi hold the same bytes, 0 otherwise
+var amd64StrEq = []string{
+	".Lgolite_streq:",
+	"\tpushq %rbp",
+	"\tmovq %rsp, %rbp",
+	"\txorl %ecx, %ecx",
+	"\txorl %edx, %edx",
+	"\ttestq %rdi, %rdi",
+	"\tje 1f",
+	"\tmovq (%rdi), %rcx",
+	"1:",
+	"\ttestq %rsi, %rsi",
+	"\tje 2f",
+	"\tmovq (%rsi), %rdx",
+	"2:",
+	"\txorl %eax, %eax",
+	"\tcmpq %rdx, %rcx",
+	"\tjne 3f",
+	"\tmovl $1, %eax",
+	"\ttestq %rcx, %rcx",
+	"\tje 3f",
+	"\taddq $8, %rdi",
+	"\taddq $8, %rsi",
+	"\tcall memcmp@PLT",
+	"\ttestl %eax, %eax",
+	"\tsete %al",
+	"\tmovzbl %al, %eax",
+	"3:",
+	"\tpopq %rbp",
+	"\tret",
+}
+
+func amd64Label(label string) string {
+	return ".L" + label
+}
+
+func amd64Cond(flag ir.ApsrFlag) string {
+	switch flag {
+	case ir.GT:
+		return "g"
+	case ir.LT:
+		return "l"
+	case ir.GE:
+		return "ge"
+	case ir.LE:
+		return "le"
+	case ir.EQ:
+		return "e"
+	case ir.NE:
+		return "ne"
+	}
+	return ""
+}
+
+func (target *amd64) slot(reg int) string {
+	return fmt.Sprintf("%v(%%rbp)", target.frame.Slots[reg])
+}
+
+func (target *amd64) operand(instr ir.Instruction, idx int) string {
+	//The idx-th source operand, which is the immediate if the instruction has one
+	if imm := instr.GetImmediate(); imm != nil {
+		return fmt.Sprintf("$%v", *imm)
+	}
+	return target.slot(instr.GetSources()[idx])
+}
+
+func (target *amd64) wideOperand(instr ir.Instruction, idx int, reg string) ([]string, string) {
+	/*
+		The idx-th source operand of an arithmetic or compare instruction. Those
+		only take a sign extended 32-bit immediate, a wider one is first moved into
+		reg with movabsq.
+	*/
+	if imm := instr.GetImmediate(); imm != nil && (*imm < math.MinInt32 || *imm > math.MaxInt32) {
+		return []string{fmt.Sprintf("\tmovabsq $%v, %v", *imm, reg)}, reg
+	}
+	return []string{}, target.operand(instr, idx)
+}
+
+func (target *amd64) translate(iloc ir.Instruction) []string {
+	instruction := []string{}
+	emit := func(format string, args ...interface{}) {
+		instruction = append(instruction, "\t"+fmt.Sprintf(format, args...))
+	}
+	switch instr := iloc.(type) {
+	case *ir.Add:
+		instruction = target.binary("addq", instr)
+	case *ir.Sub:
+		instruction = target.binary("subq", instr)
+	case *ir.Mul:
+		instruction = target.binary("imulq", instr)
+	case *ir.And:
+		instruction = target.binary("andq", instr)
+	case *ir.Or:
+		instruction = target.binary("orq", instr)
+	case *ir.Xor:
+		instruction = target.binary("xorq", instr)
+	case *ir.Bic:
+		emit("movq %v, %%rcx", target.operand(instr, 1))
+		emit("notq %%rcx")
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("andq %%rcx, %%rax")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Div:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("cqto")
+		emit("movq %v, %%rcx", target.operand(instr, 1))
+		emit("idivq %%rcx")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Mod:
+		// idivq leaves the remainder in rdx
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("cqto")
+		emit("movq %v, %%rcx", target.operand(instr, 1))
+		emit("idivq %%rcx")
+		emit("movq %%rdx, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Lsl, *ir.Asr:
+		instruction = target.shift(instr)
+	case *ir.Not:
+		emit("movq %v, %%rax", target.operand(instr, 0))
+		emit("xorq $1, %%rax")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Mov:
+		slot := target.slot(instr.GetTargets()[0])
+		if instr.GetRetFlag() {
+			emit("movq %v, %v", amd64RetRegs[instr.GetRetIndex()], slot)
+		} else if instr.GetFlag() == ir.AL || instr.GetFlag() == ir.MARG {
+			emit("movq %v, %%rax", target.operand(instr, 0))
+			emit("movq %%rax, %v", slot)
+		} else {
+			// mov does not change the flags set by the last cmp
+			emit("movq %v, %%rcx", target.operand(instr, 0))
+			emit("movq %v, %%rax", slot)
+			emit("cmov%vq %%rcx, %%rax", amd64Cond(instr.GetFlag()))
+			emit("movq %%rax, %v", slot)
+		}
+	case *ir.Cmp:
+		load, operand := target.wideOperand(instr, 1, "%rcx")
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		instruction = append(instruction, load...)
+		emit("cmpq %v, %%rax", operand)
+	case *ir.Branch:
+		if instr.GetFlag() == ir.AL {
+			emit("jmp %v", amd64Label(instr.GetLabel()))
+		} else {
+			emit("j%v %v", amd64Cond(instr.GetFlag()), amd64Label(instr.GetLabel()))
+		}
+	case *ir.JumpTable:
+		// the table holds the offset of each label from the start of the table
+		table := fmt.Sprintf(".Ljt%v", target.tables)
+		target.tables += 1
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("movq $%v, %%rcx", instr.GetLow())
+		emit("subq %%rcx, %%rax")
+		emit("movq $%v, %%rcx", len(instr.GetLabels()))
+		emit("cmpq %%rcx, %%rax")
+		emit("jae %v", amd64Label(instr.GetLabel()))
+		emit("leaq %v(%%rip), %%rcx", table)
+		emit("movslq (%%rcx,%%rax,4), %%rax")
+		emit("addq %%rcx, %%rax")
+		emit("jmp *%%rax")
+		instruction = append(instruction, table+":")
+		for _, label := range instr.GetLabels() {
+			emit(".long %v-%v", amd64Label(label), table)
+		}
+	case *ir.Ldr:
+		emit("movq %v(%%rip), %%rax", instr.GetGlobal())
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Str:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("movq %%rax, %v(%%rip)", instr.GetGlobal())
+	case *ir.NewStruct:
+		size := instr.GetSize() * 8
+		if size == 0 {
+			size = 8
+		}
+		emit("movq $%v, %%rdi", size)
+		emit("call malloc@PLT")
+		for idx := 0; idx < instr.GetSize(); idx++ {
+			emit("movq $0, %v(%%rax)", idx*8)
+		}
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Delete:
+		emit("movq %v, %%rdi", target.slot(instr.GetSources()[0]))
+		emit("call free@PLT")
+	case *ir.LoadRef:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("movq %v(%%rax), %%rax", instr.GetFieldIndex()*8)
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.StrRef:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[1]))
+		emit("movq %v, %%rcx", target.slot(instr.GetSources()[0]))
+		emit("movq %%rcx, %v(%%rax)", instr.GetFieldIndex()*8)
+	case *ir.NewArray:
+		emit("movq %v, %%rdi", target.operand(instr, 0))
+		emit("movq $8, %%rsi")
+		emit("call calloc@PLT")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.LoadIndex:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("movq %v, %%rcx", target.slot(instr.GetSources()[1]))
+		emit("movq (%%rax,%%rcx,8), %%rax")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.StrIndex:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[1]))
+		emit("movq %v, %%rcx", target.slot(instr.GetSources()[2]))
+		emit("movq %v, %%rdx", target.slot(instr.GetSources()[0]))
+		emit("movq %%rdx, (%%rax,%%rcx,8)")
+	case *ir.Len:
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("testq %%rax, %%rax")
+		emit("je 1f")
+		emit("movq (%%rax), %%rax")
+		instruction = append(instruction, "1:")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.CheckBounds:
+		// a negative index is a large unsigned one
+		target.indexExist = true
+		load, operand := target.wideOperand(instr, 1, "%rcx")
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		instruction = append(instruction, load...)
+		emit("cmpq %v, %%rax", operand)
+		emit("jae .Lgolite_index")
+	case *ir.CheckMake:
+		target.makeExist = true
+		emit("movq %v, %%rax", target.slot(instr.GetSources()[0]))
+		emit("cmpq $0, %%rax")
+		emit("jl .Lgolite_makelen")
+		emit("cmpq %v, %%rax", target.slot(instr.GetSources()[1]))
+		emit("jg .Lgolite_makecap")
+	case *ir.Append:
+		target.appendExist = true
+		emit("movq %v, %%rdi", target.slot(instr.GetSources()[0]))
+		emit("movq %v, %%rsi", target.slot(instr.GetSources()[1]))
+		emit("call .Lgolite_append")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Push:
+		// the stack arguments are pushed in reverse order, keeping %rsp 16 byte aligned
+		args := instr.GetSources()
+		target.stackArgs = 0
+		if len(args) > len(amd64ArgRegs) {
+			target.stackArgs = len(args) - len(amd64ArgRegs)
+			if target.stackArgs%2 != 0 {
+				target.stackArgs += 1
+				emit("subq $8, %%rsp")
+			}
+			for idx := len(args) - 1; idx >= len(amd64ArgRegs); idx-- {
+				emit("pushq %v", target.slot(args[idx]))
+			}
+		}
+		for idx := 0; idx < len(args) && idx < len(amd64ArgRegs); idx++ {
+			emit("movq %v, %v", target.slot(args[idx]), amd64ArgRegs[idx])
+		}
+	case *ir.Bl:
+		emit("call %v", instr.GetLabel())
+	case *ir.Pop:
+		if target.stackArgs > 0 {
+			emit("addq $%v, %%rsp", target.stackArgs*8)
+		}
+		target.stackArgs = 0
+	case *ir.Ret:
+		if instr.GetImmediate() != nil {
+			emit("movq $%v, %%rax", *instr.GetImmediate())
+		} else if len(instr.GetSources()) > 0 {
+			for idx, source := range instr.GetSources() {
+				emit("movq %v, %v", target.slot(source), amd64RetRegs[idx])
+			}
+		} else {
+			emit("movq $0, %%rax")
+		}
+		emit("leave")
+		emit("ret")
+	case *ir.Read:
+		target.scanExist = true
+		emit("leaq %v, %%rsi", target.slot(instr.GetTargets()[0]))
+		emit("leaq .READ(%%rip), %%rdi")
+		emit("movl $0, %%eax")
+		emit("call scanf@PLT")
+	case *ir.LoadStr:
+		emit("leaq %v(%%rip), %%rax", target.strings.label(instr.GetValue()))
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Concat:
+		target.concatExist = true
+		emit("movq %v, %%rdi", target.slot(instr.GetSources()[0]))
+		emit("movq %v, %%rsi", target.slot(instr.GetSources()[1]))
+		emit("call .Lgolite_concat")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.StrEq:
+		target.strEqExist = true
+		emit("movq %v, %%rdi", target.slot(instr.GetSources()[0]))
+		emit("movq %v, %%rsi", target.slot(instr.GetSources()[1]))
+		emit("call .Lgolite_streq")
+		emit("movq %%rax, %v", target.slot(instr.GetTargets()[0]))
+	case *ir.Print:
+		if instr.IsString() {
+			target.printStr = true
+			instruction = append(instruction, target.printString(instr.GetSources()[0], ".PRINT_STR")...)
+			break
+		}
+		target.printExist = true
+		emit("movq %v, %%rsi", target.slot(instr.GetSources()[0]))
+		emit("leaq .PRINT(%%rip), %%rdi")
+		emit("movl $0, %%eax")
+		emit("call printf@PLT")
+	case *ir.Println:
+		if instr.IsString() {
+			target.printlnStr = true
+			instruction = append(instruction, target.printString(instr.GetSources()[0], ".PRINT_STR_LN")...)
+			break
+		}
+		target.printlnExist = true
+		emit("movq %v, %%rsi", target.slot(instr.GetSources()[0]))
+		emit("leaq .PRINT_LN(%%rip), %%rdi")
+		emit("movl $0, %%eax")
+		emit("call printf@PLT")
+	default:
+		panic(fmt.Sprintf("amd64: unsupported instruction %v", iloc))
+	}
+	return instruction
+}
+
+func (target *amd64) printString(reg int, format string) []string {
+	//Print the bytes of the string with the %.*s format, the nil string has no bytes
+	return []string{
+		fmt.Sprintf("\tmovq %v, %%rax", target.slot(reg)),
+		"\txorl %esi, %esi",
+		"\tmovq %rax, %rdx",
+		"\ttestq %rax, %rax",
+		"\tje 1f",
+		"\tmovq (%rax), %rsi",
+		"\tleaq 8(%rax), %rdx",
+		"1:",
+		fmt.Sprintf("\tleaq %v(%%rip), %%rdi", format),
+		"\tmovl $0, %eax",
+		"\tcall printf@PLT",
+	}
+}
+
+func (target *amd64) shift(instr ir.Instruction) []string {
+	/*
+		A shift by 64 or more, or by a negative count taken as unsigned, gives 0
+		to the left and the sign to the right as in Go, the hardware only uses
+		the low 6 bits of the count.
+	*/
+	_, isLsl := instr.(*ir.Lsl)
+	operator := "sarq"
+	if isLsl {
+		operator = "shlq"
+	}
+	instruction := []string{fmt.Sprintf("\tmovq %v, %%rax", target.slot(instr.GetSources()[0]))}
+	if imm := instr.GetImmediate(); imm != nil {
+		if *imm >= 0 && *imm < 64 {
+			instruction = append(instruction, fmt.Sprintf("\t%v $%v, %%rax", operator, *imm))
+		} else if isLsl {
+			instruction = append(instruction, "\tmovq $0, %rax")
+		} else {
+			instruction = append(instruction, "\tsarq $63, %rax")
+		}
+	} else if isLsl {
+		instruction = append(instruction, fmt.Sprintf("\tmovq %v, %%rcx", target.operand(instr, 1)), "\tshlq %cl, %rax", "\txorl %edx, %edx", "\tcmpq $63, %rcx", "\tcmova %rdx, %rax")
+	} else {
+		instruction = append(instruction, fmt.Sprintf("\tmovq %v, %%rcx", target.operand(instr, 1)), "\tmovl $63, %edx", "\tcmpq $63, %rcx", "\tcmova %rdx, %rcx", "\tsarq %cl, %rax")
+	}
+	return append(instruction, fmt.Sprintf("\tmovq %%rax, %v", target.slot(instr.GetTargets()[0])))
+}
+
+func (target *amd64) binary(operator string, instr ir.Instruction) []string {
+	instruction, operand := target.wideOperand(instr, 1, "%rcx")
+	instruction = append(instruction, fmt.Sprintf("\tmovq %v, %%rax", target.slot(instr.GetSources()[0])))
+	instruction = append(instruction, fmt.Sprintf("\t%v %v, %%rax", operator, operand))
+	instruction = append(instruction, fmt.Sprintf("\tmovq %%rax, %v", target.slot(instr.GetTargets()[0])))
+	return instruction
+}
@@ -0,0 +1,161 @@
+package codegen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"proj/internal/golitetest"
+	"proj/ir"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAmd64Calls(t *testing.T) {
+	frags, symTable := CompileSource(t, `package main;
+import "fmt";
+func sum(a int, b int, c int, d int, e int, f int, g int, h int) int {
+	return a + b + c + d + e + f + g + h / 2;
+}
+func main() {
+	var x int;
+	fmt.Scan(&x);
+	fmt.Println(sum(x, 2, 3, 4, 5, 6, 7, x % 3));
+}
+`)
+	asm := strings.Join(Generate(NewAmd64(), frags, symTable), "\n")
+	for _, want := range []string{
+		// the callee copies its register and stack arguments into their slots
+		`movq %rdi, -\d+\(%rbp\)\n\tmovq %rsi, -\d+\(%rbp\)\n\tmovq %rdx, -\d+\(%rbp\)\n\tmovq %rcx, -\d+\(%rbp\)\n\tmovq %r8, -\d+\(%rbp\)\n\tmovq %r9, -\d+\(%rbp\)\n\tmovq 16\(%rbp\), %rax\n\tmovq %rax, -\d+\(%rbp\)\n\tmovq 24\(%rbp\), %rax`,
+		// the quotient is in %rax, the remainder in %rdx
+		`cqto\n\tmovq -\d+\(%rbp\), %rcx\n\tidivq %rcx\n\tmovq %rax, `,
+		`cqto\n\tmovq -\d+\(%rbp\), %rcx\n\tidivq %rcx\n\tmovq %rdx, `,
+		// the last two arguments are pushed in reverse order and popped after the call
+		`pushq -\d+\(%rbp\)\n\tpushq -\d+\(%rbp\)\n\tmovq -\d+\(%rbp\), %rdi\n(\tmovq -\d+\(%rbp\), %r\w+\n){5}\tcall sum\n\tmovq %rax, -\d+\(%rbp\)\n\taddq \$16, %rsp`,
+	} {
+		if !regexp.MustCompile(want).MatchString(asm) {
+			t.Fatalf("expected the program to emit %q", want)
+		}
+	}
+}
+
+func TestAmd64WideImmediates(t *testing.T) {
+	frags, symTable := CompileSource(t, `package main;
+import "fmt";
+func main() {
+	var x int;
+	fmt.Scan(&x);
+	x = x + 81985529216486895;
+	fmt.Println(x);
+}
+`)
+	// add and cmp only take a 32-bit immediate on amd64
+	asm := strings.Join(Generate(NewAmd64(), frags, symTable), "\n")
+	if !strings.Contains(asm, "movabsq $81985529216486895, %rcx") || strings.Contains(asm, "addq $81985529216486895") {
+		t.Fatalf("expected amd64 to move the wide immediate into a register")
+	}
+}
+
+func TestAmd64Run(t *testing.T) {
+	// Assemble the programs with gcc and run them, on x86-64 hosts only
+	gcc, err := exec.LookPath("gcc")
+	if err != nil || runtime.GOARCH != "amd64" || runtime.GOOS != "linux" {
+		t.Skip("gcc targeting x86-64 Linux is not available")
+	}
+	tests := []struct {
+		name   string
+		source string
+		input  string
+		want   string
+		panic  string // Message printed on stderr when the program is expected to abort
+	}{
+		{"stack arguments", `package main;
+import "fmt";
+func sum(a int, b int, c int, d int, e int, f int, g int, h int) int {
+	return a + b + c + d + e + f + g + h / 2;
+}
+func main() {
+	var x int;
+	fmt.Scan(&x);
+	fmt.Println(sum(x, 2, 3, 4, 5, 6, 7, x % 3));
+}
+`, "10", "37\n", ""},
+		{"recursion and loops", `package main;
+import "fmt";
+var total int;
+func fib(n int) int {
+	if (n < 2) {
+		return n;
+	}
+	return fib(n - 1) + fib(n - 2);
+}
+func main() {
+	var i int;
+	for i = 0; i < 10; i = i + 1 {
+		total = total + fib(i);
+	}
+	fmt.Println(total);
+}
+`, "", "88\n", ""},
+		{"structs and slices", `package main;
+import "fmt";
+type Node struct {
+	val int;
+	next *Node;
+};
+func main() {
+	var list *Node;
+	var s []int;
+	var i int;
+	list = new(Node);
+	list.next = new(Node);
+	list.next.val = 7;
+	for i = 0; i < 5; i = i + 1 {
+		s = append(s, i * list.next.val);
+	}
+	fmt.Println(len(s));
+	fmt.Println(s[4]);
+}
+`, "", "5\n28\n", ""},
+		{"index out of range", `package main;
+import "fmt";
+func main() {
+	var s []int;
+	var n int;
+	fmt.Scan(&n);
+	s = make([]int, 2);
+	fmt.Println(1);
+	s[n] = 3;
+	fmt.Println(2);
+}
+`, "2", "1\n", "panic: runtime error: index out of range\n"},
+	}
+	for _, tt := range tests {
+		program := golitetest.Compile(t, tt.source)
+		asm := Generate(NewAmd64(), ir.ControlFlowFrags, program.GlobalSymbolTable)
+		dir := t.TempDir()
+		source := filepath.Join(dir, "prog.s")
+		if err := os.WriteFile(source, []byte(strings.Join(asm, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		binary := filepath.Join(dir, "prog")
+		if out, err := exec.Command(gcc, "-o", binary, source).CombinedOutput(); err != nil {
+			t.Fatalf("FAILED[%s] - gcc: %v\n%s", tt.name, err, out)
+		}
+		cmd := exec.Command(binary)
+		cmd.Stdin = strings.NewReader(tt.input)
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if tt.panic == "" && err != nil {
+			t.Fatalf("FAILED[%s] - %v\n%s", tt.name, err, stderr.String())
+		}
+		if tt.panic != "" && (err == nil || stderr.String() != tt.panic) {
+			t.Fatalf("FAILED[%s] - expected the program to abort with %q, got %v and %q", tt.name, tt.panic, err, stderr.String())
+		}
+		if string(out) != tt.want {
+			t.Fatalf("FAILED[%s] - incorrect output.\nexpected=%q\ngot=%q\n", tt.name, tt.want, string(out))
+		}
+	}
+}
@@ -0,0 +1,896 @@
+package codegen
+
+import (
+	"fmt"
+	"proj/ir"
+	"sort"
+)
+
+// Number of arguments passed in x0..x7 by the AAPCS64
+const arm64ArgRegs = 8
+
+// Registers handed out by the allocator, x16 and x17 are kept as scratch registers, x15 addresses
+// the deep stack slots and x18 is reserved
+var (
+	arm64CallerSaved = []int{9, 10, 11, 12, 13, 14}
+	arm64CalleeSaved = []int{19, 20, 21, 22, 23, 24, 25, 26, 27, 28}
+)
+
+// arm64 emits AArch64 code, virtual registers live in the physical registers chosen by LinearScan
+type arm64 struct {
+	frame        *Frame      // Frame of the function being emitted
+	alloc        *Allocation // Registers of the function being emitted
+	slots        map[int]int // Offset from x29 of the spilled virtual registers
+	savedSlots   map[int]int // Offset from x29 where each callee-saved register is saved
+	callSlots    map[int]int // Offset from x29 where each caller-saved register is saved around calls
+	readSlot     int         // Offset from x29 scanf writes to
+	stackArgs    int         // Bytes of stack holding the arguments of the pending call beyond x7
+	size         int
+	printExist   bool
+	printlnExist bool
+	scanExist    bool
+	indexExist   bool // Whether a bounds check branches to .Lgolite_index
+	makeExist    bool // Whether a make check branches to .Lgolite_makelen and .Lgolite_makecap
+	appendExist  bool // Whether .Lgolite_append is called
+	concatExist  bool // Whether .Lgolite_concat is called
+	strEqExist   bool // Whether .Lgolite_streq is called
+	printStr     bool // Whether a string is printed with .PRINT_STR
+	printlnStr   bool // Whether a string is printed with .PRINT_STR_LN
+	strings      stringPool
+	tables       int // Number of jump tables emitted so far
+}
+
+func NewArm64() Target {
+	return &arm64{}
+}
+
+func (target *arm64) Header(globals []string) []string {
+	armInsList := []string{"\t.arch armv8-a"}
+	for _, global := range globals {
+		armInsList = append(armInsList, fmt.Sprintf("\t.comm %v,8,8", global))
+	}
+	return append(armInsList, "\t.text")
+}
+
+func (target *arm64) Function(frame *Frame) []string {
+	armInsList := []string{}
+	name := frame.Name
+	target.layout(frame)
+
+	armInsList = append(armInsList, "\t.type "+name+",%function")
+	armInsList = append(armInsList, "\t.global "+name)
+	armInsList = append(armInsList, "\t.p2align\t\t2")
+	armInsList = append(armInsList, fmt.Sprintf("%v:", name))
+	armInsList = append(armInsList, "\tsub sp,sp,16")
+	armInsList = append(armInsList, "\tstp x29,x30,[sp]")
+	armInsList = append(armInsList, "\tmov x29,sp")
+	if size, ok := addImmediate(target.size); ok && target.size > 0 {
+		armInsList = append(armInsList, fmt.Sprintf("\tsub sp,sp,%v", size))
+	} else if target.size > 0 {
+		armInsList = append(armInsList, loadImmediate("x16", target.size)...)
+		armInsList = append(armInsList, "\tsub sp,sp,x16")
+	}
+	for _, phys := range target.alloc.Saved {
+		armInsList = append(armInsList, target.storeSlot(fmt.Sprintf("x%v", phys), target.savedSlots[phys])...)
+	}
+	// move the parameters into their registers
+	isParam := make(map[int]bool)
+	for idx, reg := range frame.Params {
+		isParam[reg] = true
+		if idx < arm64ArgRegs {
+			armInsList = append(armInsList, target.moveFrom(reg, fmt.Sprintf("x%v", idx))...)
+		} else {
+			// the caller left the other arguments at its sp, right above the frame record
+			armInsList = append(armInsList, fmt.Sprintf("\tldr x16,[x29,#%v]", 16+8*(idx-arm64ArgRegs)))
+			armInsList = append(armInsList, target.moveFrom(reg, "x16")...)
+		}
+	}
+	// locals read before being written start at zero
+	for _, reg := range sortedKeys(target.alloc.LiveIn) {
+		if !isParam[reg] {
+			armInsList = append(armInsList, target.moveFrom(reg, "xzr")...)
+		}
+	}
+
+	pos := 0 // Index of the instruction in the liveness of the function
+	for idx, frag := range frame.Frags {
+		if idx != 0 {
+			armInsList = append(armInsList, fmt.Sprintf("%v:", frag.Label))
+		}
+		for _, instruction := range frag.Body {
+			if instruction == nil {
+				continue
+			}
+			// a call clobbers x0..x18, the live values it holds are kept in their slots meanwhile
+			saves := target.alloc.CallSaves[pos]
+			for _, phys := range saves {
+				armInsList = append(armInsList, target.storeSlot(fmt.Sprintf("x%v", phys), target.callSlots[phys])...)
+			}
+			armInsList = append(armInsList, target.translate(instruction)...)
+			for _, phys := range saves {
+				armInsList = append(armInsList, target.loadSlot(fmt.Sprintf("x%v", phys), target.callSlots[phys])...)
+			}
+			pos += 1
+		}
+	}
+
+	// falling off the end of a function returns 0
+	armInsList = append(armInsList, "\tmov x0,#0")
+	armInsList = append(armInsList, target.epilogue()...)
+	armInsList = append(armInsList, "\t.size "+name+",(.-"+name+")")
+	return armInsList
+}
+
+func (target *arm64) Footer() []string {
+	armInsList := []string{}
+	if target.indexExist {
+		armInsList = append(armInsList, arm64Panic(".Lgolite_index", ".INDEX")...)
+	}
+	if target.makeExist {
+		armInsList = append(armInsList, arm64Panic(".Lgolite_makelen", ".MAKE_LEN")...)
+		armInsList = append(armInsList, arm64Panic(".Lgolite_makecap", ".MAKE_CAP")...)
+	}
+	if target.appendExist {
+		armInsList = append(armInsList, arm64Append...)
+	}
+	if target.concatExist {
+		armInsList = append(armInsList, arm64Concat...)
+	}
+	if target.strEqExist {
+		armInsList = append(armInsList, arm64StrEq...)
+	}
+	armInsList = append(armInsList, "\t.section .rodata")
+	armInsList = append(armInsList, target.strings.data()...)
+	if target.indexExist {
+		armInsList = append(armInsList, ".INDEX:")
+		armInsList = append(armInsList, "\t.asciz\t\"panic: runtime error: index out of range\\n\"")
+	}
+	if target.makeExist {
+		armInsList = append(armInsList, ".MAKE_LEN:")
+		armInsList = append(armInsList, "\t.asciz\t\"panic: runtime error: makeslice: len out of range\\n\"")
+		armInsList = append(armInsList, ".MAKE_CAP:")
+		armInsList = append(armInsList, "\t.asciz\t\"panic: runtime error: makeslice: cap out of range\\n\"")
+	}
+	if target.printExist {
+		armInsList = append(armInsList, ".PRINT:")
+		armInsList = append(armInsList, "\t.asciz\t\"%ld\"")
+		armInsList = append(armInsList, "\t.size\t.PRINT, 4")
+	}
+	if target.printlnExist {
+		armInsList = append(armInsList, ".PRINT_LN:")
+		armInsList = append(armInsList, "\t.asciz\t\"%ld\\n\"")
+		armInsList = append(armInsList, "\t.size\t.PRINT_LN, 5")
+	}
+	if target.printStr {
+		armInsList = append(armInsList, ".PRINT_STR:")
+		armInsList = append(armInsList, "\t.asciz\t\"%.*s\"")
+		armInsList = append(armInsList, "\t.size\t.PRINT_STR, 5")
+	}
+	if target.printlnStr {
+		armInsList = append(armInsList, ".PRINT_STR_LN:")
+		armInsList = append(armInsList, "\t.asciz\t\"%.*s\\n\"")
+		armInsList = append(armInsList, "\t.size\t.PRINT_STR_LN, 6")
+	}
+	if target.scanExist {
+		armInsList = append(armInsList, ".READ:")
+		armInsList = append(armInsList, "\t.asciz\t\"%ld\"")
+		armInsList = append(armInsList, "\t.size\t.READ, 4")
+	}
+	return armInsList
+}
+
+func arm64Panic(label string, message string) []string {
+	//Print the panic message on stderr, exit flushes the output printed so far
+	return []string{
+		label + ":",
+		"\tadrp x1,:got:stderr",
+		"\tldr x1,[x1,:got_lo12:stderr]",
+		"\tldr x1,[x1]",
+		"\tadrp x0," + message,
+		"\tadd x0,x0,:lo12:" + message,
+		"\tbl fputs",
+		"\tmov x0,#1",
+		"\tbl exit",
+	}
+}
+
+// arm64Append returns in x0 the header of the slice in x0 with x1 appended,
+// the elements move to a cell twice as large, at least 4, when the capacity is exhausted
+var arm64Append = []string{
+	".Lgolite_append:",
+	"\tstp x29,x30,[sp,#-64]!",
+	"\tmov x29,sp",
+	"\tstp x19,x20,[sp,#16]",
+	"\tstp x21,x22,[sp,#32]",
+	"\tstr x23,[sp,#48]",
+	"\tmov x23,x1",
+	"\tmov x19,#0",
+	"\tmov x20,#0",
+	"\tmov x21,#0",
+	"\tcbz x0,1f",
+	"\tldr x19,[x0]",
+	"\tldr x20,[x0,#8]",
+	"\tldr x21,[x0,#16]",
+	"1:",
+	"\tcmp x19,x20",
+	"\tb.lt 2f",
+	"\tadd x20,x20,x20",
+	"\tmov x16,#4",
+	"\tcmp x20,x16",
+	"\tcsel x20,x16,x20,lt",
+	"\tmov x0,x20",
+	"\tmov x1,#8",
+	"\tbl calloc",
+	"\tmov x22,x0",
+	"\tmov x1,x21",
+	"\tlsl x2,x19,#3",
+	"\tbl memcpy",
+	"\tmov x21,x22",
+	"2:",
+	"\tstr x23,[x21,x19,lsl #3]",
+	"\tmov x0,#24",
+	"\tbl malloc",
+	"\tadd x16,x19,#1",
+	"\tstr x16,[x0]",
+	"\tstr x20,[x0,#8]",
+	"\tstr x21,[x0,#16]",
+	"\tldp x19,x20,[sp,#16]",
+	"\tldp x21,x22,[sp,#32]",
+	"\tldr x23,[sp,#48]",
+	"\tldp x29,x30,[sp],#64",
+	"\tret",
+}
+
+// arm64Concat returns in x0 a new string holding the bytes of the string in x0
+// followed by the bytes of the string in x1
+var arm64Concat = []string{
+	".Lgolite_concat:",
+	"\tstp x29,x30,[sp,#-64]!",
+	"\tmov x29,sp",
+	"\tstp x19,x20,[sp,#16]",
+	"\tstp x21,x22,[sp,#32]",
+	"\tstr x23,[sp,#48]",
+	"\tmov x19,x0",
+	"\tmov x20,x1",
+	"\tmov x21,#0",
+	"\tmov x22,#0",
+	"\tcbz x19,1f",
+	"\tldr x21,[x19]",
+	"1:",
+	"\tcbz x20,2f",
+	"\tldr x22,[x20]",
+	"2:",
+	"\tadd x0,x21,x22",
+	"\tadd x0,x0,#8",
+	"\tbl malloc",
+	"\tmov x23,x0",
+	"\tadd x16,x21,x22",
+	"\tstr x16,[x23]",
+	"\tcbz x21,3f",
+	"\tadd x0,x23,#8",
+	"\tadd x1,x19,#8",
+	"\tmov x2,x21",
+	"\tbl memcpy",
+	"3:",
+	"\tcbz x22,4f",
+	"\tadd x0,x23,#8",
+	"\tadd x0,x0,x21",
+	"\tadd x1,x20,#8",
+	"\tmov x2,x22",
+	"\tbl memcpy",
+	"4:",
+	"\tmov x0,x23",
+	"\tldp x19,x20,[sp,#16]",
+	"\tldp x21,x22,[sp,#32]",
+	"\tldr x23,[sp,#48]",
+	"\tldp x29,x30,[sp],#64",
+	"\tret",
+}
+
+// arm64StrEq returns in x0 1 when the strings in x0 and x1 hold the same bytes, 0 otherwise
+var arm64StrEq = []string{
+	".Lgolite_streq:",
+	"\tstp x29,x30,[sp,#-16]!",
+	"\tmov x29,sp",
+	"\tmov x2,#0",
+	"\tmov x3,#0",
+	"\tcbz x0,1f",
+	"\tldr x2,[x0]",
+	"1:",
+	"\tcbz x1,2f",
+	"\tldr x3,[x1]",
+	"2:",
+	"\tcmp x2,x3",
+	"\tb.ne 3f",
+	"\tcbz x2,4f",
+	"\tadd x0,x0,#8",
+	"\tadd x1,x1,#8",
+	"\tbl memcmp",
+	"\tcmp w0,#0",
+	"\tcset x0,eq",
+	"\tb 5f",
+	"3:",
+	"\tmov x0,#0",
+	"\tb 5f",
+	"4:",
+	"\tmov x0,#1",
+	"5:",
+	"\tldp x29,x30,[sp],#16",
+	"\tret",
+}
+
+func (target *arm64) layout(frame *Frame) {
+	/*
+		Allocate the registers of the function and lay out its stack below x29:
+		the saved callee-saved registers, the caller-saved registers saved
+		around calls, the spilled virtual registers, then the word scanf writes to.
+	*/
+	target.frame = frame
+	target.alloc = LinearScan(NewLiveness(frame), frame.Params, arm64CallerSaved, arm64CalleeSaved)
+	target.slots = make(map[int]int)
+	target.savedSlots = make(map[int]int)
+	target.callSlots = make(map[int]int)
+	target.size = 0
+	for _, phys := range target.alloc.Saved {
+		target.size += 8
+		target.savedSlots[phys] = -target.size
+	}
+	for _, phys := range arm64CallerSaved {
+		for _, saves := range target.alloc.CallSaves {
+			if contains(saves, phys) {
+				target.size += 8
+				target.callSlots[phys] = -target.size
+				break
+			}
+		}
+	}
+	for _, reg := range target.alloc.Spilled {
+		target.size += 8
+		target.slots[reg] = -target.size
+	}
+	target.size += 8
+	target.readSlot = -target.size
+	if target.size%16 != 0 {
+		target.size += 8
+	}
+}
+
+func (target *arm64) epilogue() []string {
+	epiInst := []string{}
+	for _, phys := range target.alloc.Saved {
+		epiInst = append(epiInst, target.loadSlot(fmt.Sprintf("x%v", phys), target.savedSlots[phys])...)
+	}
+	epiInst = append(epiInst, "\tmov sp,x29")
+	epiInst = append(epiInst, "\tldp x29,x30,[sp]")
+	epiInst = append(epiInst, "\tadd sp,sp,16")
+	epiInst = append(epiInst, "\tret")
+	return epiInst
+}
+
+func arm64Cond(flag ir.ApsrFlag) string {
+	switch flag {
+	case ir.GT:
+		return "gt"
+	case ir.LT:
+		return "lt"
+	case ir.GE:
+		return "ge"
+	case ir.LE:
+		return "le"
+	case ir.EQ:
+		return "eq"
+	case ir.NE:
+		return "ne"
+	}
+	return "al"
+}
+
+func slotAddress(offset int) (string, []string) {
+	/*
+		Address of a stack slot below x29. ldur only reaches 256 bytes below
+		x29, deeper slots are addressed through x15. It is not one of the
+		scratch registers, which may hold the value being loaded or stored.
+	*/
+	if offset >= -256 {
+		return fmt.Sprintf("[x29,#%v]", offset), []string{}
+	}
+	return "[x29,x15]", loadImmediate("x15", offset)
+}
+
+func (target *arm64) loadSlot(phys string, offset int) []string {
+	address, instruction := slotAddress(offset)
+	return append(instruction, fmt.Sprintf("\tldr %v,%v", phys, address))
+}
+
+func (target *arm64) storeSlot(phys string, offset int) []string {
+	address, instruction := slotAddress(offset)
+	return append(instruction, fmt.Sprintf("\tstr %v,%v", phys, address))
+}
+
+func (target *arm64) use(reg int, scratch string) (string, []string) {
+	//Register holding the value of a virtual register, a spilled value is loaded into scratch
+	if phys, exist := target.alloc.Regs[reg]; exist {
+		return fmt.Sprintf("x%v", phys), []string{}
+	}
+	return scratch, target.loadSlot(scratch, target.slots[reg])
+}
+
+func (target *arm64) def(reg int) (string, []string) {
+	//Register to compute a virtual register into and the code storing it back when it is spilled
+	if phys, exist := target.alloc.Regs[reg]; exist {
+		return fmt.Sprintf("x%v", phys), []string{}
+	}
+	return "x16", target.storeSlot("x16", target.slots[reg])
+}
+
+func (target *arm64) moveTo(phys string, reg int) []string {
+	//Copy a virtual register into a physical register
+	if allocated, exist := target.alloc.Regs[reg]; exist {
+		return []string{fmt.Sprintf("\tmov %v,x%v", phys, allocated)}
+	}
+	return target.loadSlot(phys, target.slots[reg])
+}
+
+func (target *arm64) moveFrom(reg int, phys string) []string {
+	//Copy a physical register into a virtual register
+	if allocated, exist := target.alloc.Regs[reg]; exist {
+		return []string{fmt.Sprintf("\tmov x%v,%v", allocated, phys)}
+	}
+	return target.storeSlot(phys, target.slots[reg])
+}
+
+func (target *arm64) operand(instr ir.Instruction, idx int, immOk bool) (string, []string) {
+	/*
+		The idx-th source operand, which is the immediate if the instruction has
+		one. The immediate is encoded in the instruction when immOk and it fits
+		the add/sub form, otherwise it is moved into x17. A negative immediate is
+		encoded by its absolute value, the caller picks the opposite instruction
+		with negated.
+	*/
+	if imm := instr.GetImmediate(); imm != nil {
+		if encoded, ok := addImmediate(*imm); ok && immOk {
+			return encoded, []string{}
+		}
+		return "x17", loadImmediate("x17", *imm)
+	}
+	return target.use(instr.GetSources()[idx], "x17")
+}
+
+func (target *arm64) translate(iloc ir.Instruction) []string {
+	instruction := []string{}
+	emit := func(format string, args ...interface{}) {
+		instruction = append(instruction, "\t"+fmt.Sprintf(format, args...))
+	}
+	switch instr := iloc.(type) {
+	case *ir.Add:
+		instruction = target.binary(negated("add", "sub", instr), instr, true)
+	case *ir.Sub:
+		instruction = target.binary(negated("sub", "add", instr), instr, true)
+	case *ir.Mul:
+		instruction = target.binary("mul", instr, false)
+	case *ir.Div:
+		instruction = target.binary("sdiv", instr, false)
+	case *ir.And:
+		instruction = target.binary("and", instr, false)
+	case *ir.Or:
+		instruction = target.binary("orr", instr, false)
+	case *ir.Xor:
+		instruction = target.binary("eor", instr, false)
+	case *ir.Bic:
+		instruction = target.binary("bic", instr, false)
+	case *ir.Mod:
+		instruction = target.remainder(instr)
+	case *ir.Lsl, *ir.Asr:
+		instruction = target.shift(instr)
+	case *ir.Not:
+		source, load := target.operand(instr, 0, false)
+		result, store := target.def(instr.GetTargets()[0])
+		instruction = append(instruction, load...)
+		emit("eor %v,%v,#1", result, source)
+		instruction = append(instruction, store...)
+	case *ir.Mov:
+		result, store := target.def(instr.GetTargets()[0])
+		if instr.GetRetFlag() {
+			emit("mov %v,x%v", result, instr.GetRetIndex())
+		} else if !isConditionalMov(instr) {
+			if imm := instr.GetImmediate(); imm != nil {
+				instruction = append(instruction, loadImmediate(result, *imm)...)
+			} else {
+				source, load := target.use(instr.GetSources()[0], "x17")
+				if source == result {
+					// both virtual registers got the same physical register
+					return instruction
+				}
+				return append(load, target.moveFrom(instr.GetTargets()[0], source)...)
+			}
+		} else {
+			// mov does not change the flags set by the last cmp
+			source, load := target.operand(instr, 0, false)
+			old, loadOld := target.use(instr.GetTargets()[0], "x16")
+			instruction = append(instruction, load...)
+			instruction = append(instruction, loadOld...)
+			emit("csel %v,%v,%v,%v", result, source, old, arm64Cond(instr.GetFlag()))
+		}
+		instruction = append(instruction, store...)
+	case *ir.Cmp:
+		left, loadLeft := target.use(instr.GetSources()[0], "x16")
+		right, loadRight := target.operand(instr, 1, true)
+		instruction = append(instruction, loadLeft...)
+		instruction = append(instruction, loadRight...)
+		emit("%v %v,%v", negated("cmp", "cmn", instr), left, right)
+	case *ir.Branch:
+		if instr.GetFlag() == ir.AL {
+			emit("b %v", instr.GetLabel())
+		} else {
+			emit("b.%v %v", arm64Cond(instr.GetFlag()), instr.GetLabel())
+		}
+	case *ir.JumpTable:
+		// the table holds the offset of each label from the start of the table
+		table := fmt.Sprintf(".Ljt%v", target.tables)
+		target.tables += 1
+		value, load := target.use(instr.GetSources()[0], "x16")
+		instruction = append(instruction, load...)
+		instruction = append(instruction, loadImmediate("x17", instr.GetLow())...)
+		emit("sub x16,%v,x17", value)
+		instruction = append(instruction, loadImmediate("x17", len(instr.GetLabels()))...)
+		emit("cmp x16,x17")
+		emit("b.hs %v", instr.GetLabel())
+		emit("adr x17,%v", table)
+		emit("ldrsw x16,[x17,x16,lsl #2]")
+		emit("add x16,x17,x16")
+		emit("br x16")
+		instruction = append(instruction, table+":")
+		for _, label := range instr.GetLabels() {
+			emit(".word %v-%v", label, table)
+		}
+	case *ir.Ldr:
+		result, store := target.def(instr.GetTargets()[0])
+		emit("adrp x17,%v", instr.GetGlobal())
+		emit("add x17,x17,:lo12:%v", instr.GetGlobal())
+		emit("ldr %v,[x17]", result)
+		instruction = append(instruction, store...)
+	case *ir.Str:
+		value, load := target.use(instr.GetSources()[0], "x16")
+		instruction = append(instruction, load...)
+		emit("adrp x17,%v", instr.GetGlobal())
+		emit("add x17,x17,:lo12:%v", instr.GetGlobal())
+		emit("str %v,[x17]", value)
+	case *ir.NewStruct:
+		size := instr.GetSize() * 8
+		if size == 0 {
+			size = 8
+		}
+		instruction = append(instruction, loadImmediate("x0", size)...)
+		emit("bl malloc")
+		for idx := 0; idx < instr.GetSize(); idx++ {
+			emit("str xzr,[x0,#%v]", idx*8)
+		}
+		instruction = append(instruction, target.moveFrom(instr.GetTargets()[0], "x0")...)
+	case *ir.Delete:
+		instruction = append(instruction, target.moveTo("x0", instr.GetSources()[0])...)
+		emit("bl free")
+	case *ir.LoadRef:
+		ptr, load := target.use(instr.GetSources()[0], "x16")
+		result, store := target.def(instr.GetTargets()[0])
+		instruction = append(instruction, load...)
+		emit("ldr %v,[%v,#%v]", result, ptr, instr.GetFieldIndex()*8)
+		instruction = append(instruction, store...)
+	case *ir.StrRef:
+		value, loadValue := target.use(instr.GetSources()[0], "x16")
+		ptr, loadPtr := target.use(instr.GetSources()[1], "x17")
+		instruction = append(instruction, loadValue...)
+		instruction = append(instruction, loadPtr...)
+		emit("str %v,[%v,#%v]", value, ptr, instr.GetFieldIndex()*8)
+	case *ir.NewArray:
+		length, load := target.operand(instr, 0, false)
+		instruction = append(instruction, load...)
+		emit("mov x0,%v", length)
+		emit("mov x1,#8")
+		emit("bl calloc")
+		instruction = append(instruction, target.moveFrom(instr.GetTargets()[0], "x0")...)
+	case *ir.LoadIndex:
+		base, loadBase := target.use(instr.GetSources()[0], "x16")
+		index, loadIndex := target.use(instr.GetSources()[1], "x17")
+		result, store := target.def(instr.GetTargets()[0])
+		instruction = append(instruction, loadBase...)
+		instruction = append(instruction, loadIndex...)
+		emit("ldr %v,[%v,%v,lsl #3]", result, base, index)
+		instruction = append(instruction, store...)
+	case *ir.StrIndex:
+		base, loadBase := target.use(instr.GetSources()[1], "x17")
+		index, loadIndex := target.use(instr.GetSources()[2], "x16")
+		value, loadValue := target.use(instr.GetSources()[0], "x16")
+		instruction = append(instruction, loadBase...)
+		instruction = append(instruction, loadIndex...)
+		emit("add x17,%v,%v,lsl #3", base, index)
+		instruction = append(instruction, loadValue...)
+		emit("str %v,[x17]", value)
+	case *ir.Len:
+		source, load := target.use(instr.GetSources()[0], "x16")
+		result, store := target.def(instr.GetTargets()[0])
+		instruction = append(instruction, load...)
+		emit("mov x17,#0")
+		emit("cbz %v,1f", source)
+		emit("ldr x17,[%v]", source)
+		instruction = append(instruction, "1:")
+		emit("mov %v,x17", result)
+		instruction = append(instruction, store...)
+	case *ir.CheckBounds:
+		// a negative index is a large unsigned one
+		target.indexExist = true
+		index, loadIndex := target.use(instr.GetSources()[0], "x16")
+		length, loadLength := target.operand(instr, 1, true)
+		instruction = append(instruction, loadIndex...)
+		instruction = append(instruction, loadLength...)
+		emit("%v %v,%v", negated("cmp", "cmn", instr), index, length)
+		emit("b.hs .Lgolite_index")
+	case *ir.CheckMake:
+		target.makeExist = true
+		length, loadLength := target.use(instr.GetSources()[0], "x16")
+		capacity, loadCapacity := target.use(instr.GetSources()[1], "x17")
+		instruction = append(instruction, loadLength...)
+		instruction = append(instruction, loadCapacity...)
+		emit("cmp %v,#0", length)
+		emit("b.lt .Lgolite_makelen")
+		emit("cmp %v,%v", length, capacity)
+		emit("b.gt .Lgolite_makecap")
+	case *ir.Append:
+		target.appendExist = true
+		instruction = append(instruction, target.moveTo("x0", instr.GetSources()[0])...)
+		instruction = append(instruction, target.moveTo("x1", instr.GetSources()[1])...)
+		emit("bl .Lgolite_append")
+		instruction = append(instruction, target.moveFrom(instr.GetTargets()[0], "x0")...)
+	case *ir.Push:
+		// the arguments beyond x7 go on the stack in order, keeping sp 16 byte aligned
+		args := instr.GetSources()
+		target.stackArgs = 0
+		if len(args) > arm64ArgRegs {
+			target.stackArgs = 8 * (len(args) - arm64ArgRegs)
+			if target.stackArgs%16 != 0 {
+				target.stackArgs += 8
+			}
+			emit("sub sp,sp,#%v", target.stackArgs)
+			for idx := arm64ArgRegs; idx < len(args); idx++ {
+				value, load := target.use(args[idx], "x16")
+				instruction = append(instruction, load...)
+				emit("str %v,[sp,#%v]", value, 8*(idx-arm64ArgRegs))
+			}
+		}
+		// no virtual register lives in x0..x7, so the arguments can be moved in any order
+		for idx := 0; idx < len(args) && idx < arm64ArgRegs; idx++ {
+			instruction = append(instruction, target.moveTo(fmt.Sprintf("x%v", idx), args[idx])...)
+		}
+	case *ir.Bl:
+		emit("bl %v", instr.GetLabel())
+	case *ir.Pop:
+		if target.stackArgs > 0 {
+			emit("add sp,sp,#%v", target.stackArgs)
+		}
+		target.stackArgs = 0
+	case *ir.Ret:
+		if instr.GetImmediate() != nil {
+			instruction = append(instruction, loadImmediate("x0", *instr.GetImmediate())...)
+		} else if len(instr.GetSources()) > 0 {
+			// the results go in x0, x1... like the arguments
+			for idx, source := range instr.GetSources() {
+				instruction = append(instruction, target.moveTo(fmt.Sprintf("x%v", idx), source)...)
+			}
+		} else {
+			emit("mov x0,#0")
+		}
+		instruction = append(instruction, target.epilogue()...)
+	case *ir.Read:
+		target.scanExist = true
+		reg := instr.GetTargets()[0]
+		offset := target.readSlot
+		if _, exist := target.alloc.Regs[reg]; !exist {
+			offset = target.slots[reg]
+		}
+		instruction = append(instruction, loadImmediate("x1", offset)...)
+		emit("add x1,x29,x1")
+		emit("adrp x0,.READ")
+		emit("add x0,x0,:lo12:.READ")
+		emit("bl scanf")
+		if phys, exist := target.alloc.Regs[reg]; exist {
+			instruction = append(instruction, target.loadSlot(fmt.Sprintf("x%v", phys), offset)...)
+		}
+	case *ir.LoadStr:
+		label := target.strings.label(instr.GetValue())
+		result, store := target.def(instr.GetTargets()[0])
+		emit("adrp %v,%v", result, label)
+		emit("add %v,%v,:lo12:%v", result, result, label)
+		instruction = append(instruction, store...)
+	case *ir.Concat:
+		target.concatExist = true
+		instruction = append(instruction, target.moveTo("x0", instr.GetSources()[0])...)
+		instruction = append(instruction, target.moveTo("x1", instr.GetSources()[1])...)
+		emit("bl .Lgolite_concat")
+		instruction = append(instruction, target.moveFrom(instr.GetTargets()[0], "x0")...)
+	case *ir.StrEq:
+		target.strEqExist = true
+		instruction = append(instruction, target.moveTo("x0", instr.GetSources()[0])...)
+		instruction = append(instruction, target.moveTo("x1", instr.GetSources()[1])...)
+		emit("bl .Lgolite_streq")
+		instruction = append(instruction, target.moveFrom(instr.GetTargets()[0], "x0")...)
+	case *ir.Print:
+		if instr.IsString() {
+			target.printStr = true
+			instruction = append(instruction, target.printString(instr.GetSources()[0], ".PRINT_STR")...)
+			break
+		}
+		target.printExist = true
+		instruction = append(instruction, target.moveTo("x1", instr.GetSources()[0])...)
+		emit("adrp x0,.PRINT")
+		emit("add x0,x0,:lo12:.PRINT")
+		emit("bl printf")
+	case *ir.Println:
+		if instr.IsString() {
+			target.printlnStr = true
+			instruction = append(instruction, target.printString(instr.GetSources()[0], ".PRINT_STR_LN")...)
+			break
+		}
+		target.printlnExist = true
+		instruction = append(instruction, target.moveTo("x1", instr.GetSources()[0])...)
+		emit("adrp x0,.PRINT_LN")
+		emit("add x0,x0,:lo12:.PRINT_LN")
+		emit("bl printf")
+	default:
+		panic(fmt.Sprintf("arm64: unsupported instruction %v", iloc))
+	}
+	return instruction
+}
+
+func (target *arm64) printString(reg int, format string) []string {
+	//Print the bytes of the string with the %.*s format, the nil string has no bytes
+	instruction := target.moveTo("x2", reg)
+	return append(instruction,
+		"\tmov x1,#0",
+		"\tcbz x2,1f",
+		"\tldr x1,[x2]",
+		"\tadd x2,x2,#8",
+		"1:",
+		fmt.Sprintf("\tadrp x0,%v", format),
+		fmt.Sprintf("\tadd x0,x0,:lo12:%v", format),
+		"\tbl printf")
+}
+
+func (target *arm64) binary(operator string, instr ir.Instruction, immOk bool) []string {
+	left, loadLeft := target.use(instr.GetSources()[0], "x16")
+	right, loadRight := target.operand(instr, 1, immOk)
+	result, store := target.def(instr.GetTargets()[0])
+	instruction := append(loadLeft, loadRight...)
+	instruction = append(instruction, fmt.Sprintf("\t%v %v,%v,%v", operator, result, left, right))
+	return append(instruction, store...)
+}
+
+func (target *arm64) remainder(instr *ir.Mod) []string {
+	/*
+		The remainder is left - left/right*right. The quotient takes the scratch
+		register of an operand held in a physical register, when both operands
+		are spilled the left one is kept on the stack meanwhile.
+	*/
+	left, loadLeft := target.use(instr.GetSources()[0], "x16")
+	right, loadRight := target.operand(instr, 1, false)
+	result, store := target.def(instr.GetTargets()[0])
+	instruction := append(loadLeft, loadRight...)
+	if left != "x16" {
+		instruction = append(instruction, fmt.Sprintf("\tsdiv x16,%v,%v", left, right), fmt.Sprintf("\tmsub %v,x16,%v,%v", result, right, left))
+	} else if right != "x17" {
+		instruction = append(instruction, fmt.Sprintf("\tsdiv x17,x16,%v", right), fmt.Sprintf("\tmsub %v,x17,%v,x16", result, right))
+	} else {
+		instruction = append(instruction, "\tstr x16,[sp,#-16]!", "\tsdiv x16,x16,x17", "\tmul x16,x16,x17", "\tldr x17,[sp],#16", fmt.Sprintf("\tsub %v,x17,x16", result))
+	}
+	return append(instruction, store...)
+}
+
+func (target *arm64) shift(instr ir.Instruction) []string {
+	/*
+		A shift by 64 or more, or by a negative count taken as unsigned, gives 0
+		to the left and the sign to the right as in Go, the hardware only uses
+		the low 6 bits of the count.
+	*/
+	_, isLsl := instr.(*ir.Lsl)
+	left, loadLeft := target.use(instr.GetSources()[0], "x16")
+	result, store := target.def(instr.GetTargets()[0])
+	instruction := loadLeft
+	if imm := instr.GetImmediate(); imm != nil {
+		if *imm >= 0 && *imm < 64 && isLsl {
+			instruction = append(instruction, fmt.Sprintf("\tlsl %v,%v,#%v", result, left, *imm))
+		} else if *imm >= 0 && *imm < 64 {
+			instruction = append(instruction, fmt.Sprintf("\tasr %v,%v,#%v", result, left, *imm))
+		} else if isLsl {
+			instruction = append(instruction, fmt.Sprintf("\tmov %v,#0", result))
+		} else {
+			instruction = append(instruction, fmt.Sprintf("\tasr %v,%v,#63", result, left))
+		}
+		return append(instruction, store...)
+	}
+	right, loadRight := target.operand(instr, 1, false)
+	instruction = append(instruction, loadRight...)
+	instruction = append(instruction, fmt.Sprintf("\tcmp %v,#63", right))
+	if isLsl {
+		instruction = append(instruction, fmt.Sprintf("\tlsl x16,%v,%v", left, right), fmt.Sprintf("\tcsel %v,x16,xzr,ls", result))
+	} else {
+		// an all ones count shifts by 63
+		instruction = append(instruction, fmt.Sprintf("\tcsinv x17,%v,xzr,ls", right), fmt.Sprintf("\tasr %v,%v,x17", result, left))
+	}
+	return append(instruction, store...)
+}
+
+func negated(operator string, opposite string, instr ir.Instruction) string {
+	//Instruction encoding the immediate of operand, the opposite one for a negative immediate
+	if imm := instr.GetImmediate(); imm != nil && *imm < 0 {
+		if _, ok := addImmediate(*imm); ok {
+			return opposite
+		}
+	}
+	return operator
+}
+
+func addImmediate(value int) (string, bool) {
+	/*
+		Immediate operand of add, sub, cmp and cmn encoding the absolute value:
+		12 bits, optionally shifted left by 12. Other values do not fit the
+		instruction and have to be moved into a register.
+	*/
+	magnitude := uint64(value)
+	if value < 0 {
+		magnitude = -magnitude
+	}
+	if magnitude < 1<<12 {
+		return fmt.Sprintf("#%v", magnitude), true
+	}
+	if magnitude&0xfff == 0 && magnitude < 1<<24 {
+		return fmt.Sprintf("#%v,lsl #12", magnitude>>12), true
+	}
+	return "", false
+}
+
+func loadImmediate(reg string, value int) []string {
+	/*
+		Move a 64-bit constant into reg. mov takes a constant with a single 16
+		bit chunk that is not all zeros, or not all ones. Other constants start
+		with movz, or movn when there are more chunks of ones, and movk sets the
+		chunks that differ.
+	*/
+	var chunks [4]int
+	zeros, ones := 0, 0
+	for idx := range chunks {
+		chunks[idx] = int(uint64(value) >> (16 * idx) & 0xffff)
+		if chunks[idx] == 0 {
+			zeros += 1
+		} else if chunks[idx] == 0xffff {
+			ones += 1
+		}
+	}
+	if zeros >= 3 || ones >= 3 {
+		return []string{fmt.Sprintf("\tmov %v,#%v", reg, value)}
+	}
+	fill := 0
+	if ones > zeros {
+		fill = 0xffff
+	}
+	instruction := []string{}
+	for idx, chunk := range chunks {
+		if chunk == fill {
+			continue
+		}
+		if len(instruction) > 0 {
+			instruction = append(instruction, fmt.Sprintf("\tmovk %v,#%v,lsl #%v", reg, chunk, 16*idx))
+		} else if fill == 0 {
+			instruction = append(instruction, fmt.Sprintf("\tmovz %v,#%v,lsl #%v", reg, chunk, 16*idx))
+		} else {
+			instruction = append(instruction, fmt.Sprintf("\tmovn %v,#%v,lsl #%v", reg, chunk^0xffff, 16*idx))
+		}
+	}
+	return instruction
+}
+
+func sortedKeys(regs map[int]bool) []int {
+	keys := []int{}
+	for reg := range regs {
+		keys = append(keys, reg)
+	}
+	sort.Ints(keys)
+	return keys
+}
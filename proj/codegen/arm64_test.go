@@ -0,0 +1,183 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStackArguments(t *testing.T) {
+	frags, symTable := CompileSource(t, `package main;
+import "fmt";
+func zero() int {
+	return 7;
+}
+func eight(a int, b int, c int, d int, e int, f int, g int, h int) int {
+	return a + 2 * b + 3 * c + 4 * d + 5 * e + 6 * f + 7 * g + 8 * h;
+}
+func twelve(a int, b int, c int, d int, e int, f int, g int, h int, i int, j int, k int, l int) int {
+	return a - b + c - d + e - f + g - h + i * 1000 + j * 100 + k * 10 + l + eight(i, j, k, l, a, b, c, d);
+}
+func main() {
+	var x, y int;
+	x = zero();
+	fmt.Println(x);
+	y = eight(1, 2, 3, 4, 5, 6, 7, x);
+	fmt.Println(y);
+	y = twelve(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, y + x);
+	fmt.Println(y);
+}
+`)
+	asm := strings.Join(Generate(NewArm64(), frags, symTable), "\n")
+	// the call of twelve passes its last 4 arguments in 32 bytes at sp
+	for _, want := range []string{"sub sp,sp,#32", "[sp,#0]", "[sp,#24]", "add sp,sp,#32"} {
+		if !strings.Contains(asm, want) {
+			t.Fatalf("expected the caller of twelve to emit %q", want)
+		}
+	}
+	// twelve reads them right above its frame record
+	for _, want := range []string{"ldr x16,[x29,#16]", "ldr x16,[x29,#40]"} {
+		if !strings.Contains(asm, want) {
+			t.Fatalf("expected twelve to emit %q", want)
+		}
+	}
+	if strings.Contains(asm, "[x29,#48]") {
+		t.Fatalf("twelve reads a stack argument beyond the 12th")
+	}
+}
+
+func TestLoadImmediate(t *testing.T) {
+	// run the mov, movz, movn and movk sequence to check the value it leaves in the register
+	for _, want := range []int{0, 1, -1, 4095, 65535, 65536, -65536, -70000, 123456789, -123456789012345, 1 << 48, -1 << 63, 1<<63 - 1, 0x123456789abcdef0, -0x123456789abcdef0} {
+		var reg uint64
+		for _, line := range loadImmediate("x9", want) {
+			var op, dest string
+			var chunk, shift int64
+			fields := strings.NewReplacer(",", " ", "#", "", "lsl", "").Replace(strings.TrimSpace(line))
+			if _, err := fmt.Sscan(fields, &op, &dest, &chunk); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Sscan(fields, &op, &dest, &chunk, &shift)
+			switch op {
+			case "mov":
+				reg = uint64(chunk)
+			case "movz":
+				reg = uint64(chunk) << uint(shift)
+			case "movn":
+				reg = ^(uint64(chunk) << uint(shift))
+			case "movk":
+				reg = reg&^(0xffff<<uint(shift)) | uint64(chunk)<<uint(shift)
+			}
+		}
+		if int(reg) != want {
+			t.Fatalf("FAILED[%d] - %v leaves %d", want, loadImmediate("x9", want), int(reg))
+		}
+	}
+}
+
+func TestNegativeImmediates(t *testing.T) {
+	frags, symTable := CompileSource(t, `package main;
+import "fmt";
+func main() {
+	var x int;
+	fmt.Scan(&x);
+	x = x + -5;
+	if (x > -3) {
+		x = 123456789;
+	}
+	fmt.Println(x);
+}
+`)
+	asm := strings.Join(Generate(NewArm64(), frags, symTable), "\n")
+	// small negative immediates are encoded by the opposite instruction, large constants are built in chunks
+	for _, want := range []string{",#5\n", "cmn ", ",#3\n", "movz ", "movk "} {
+		if !strings.Contains(asm, want) {
+			t.Fatalf("expected the program to emit %q", want)
+		}
+	}
+	if strings.Contains(asm, "x17,#-") {
+		t.Fatalf("expected no negative immediate moved into a register")
+	}
+}
+
+func TestAddImmediate(t *testing.T) {
+	tests := []struct {
+		value   int
+		encoded string
+		ok      bool
+	}{
+		{0, "#0", true},
+		{4095, "#4095", true},
+		{-4095, "#4095", true},
+		{4096, "#1,lsl #12", true},
+		{-0xfff000, "#4095,lsl #12", true},
+		{4097, "", false},
+		{0x1000000, "", false},
+		{-1 << 63, "", false},
+	}
+	for _, tt := range tests {
+		encoded, ok := addImmediate(tt.value)
+		if encoded != tt.encoded || ok != tt.ok {
+			t.Fatalf("FAILED[%d] - expected %q %v, got %q %v", tt.value, tt.encoded, tt.ok, encoded, ok)
+		}
+	}
+}
+
+func TestWideImmediates(t *testing.T) {
+	frags, symTable := CompileSource(t, `package main;
+import "fmt";
+func main() {
+	var x int;
+	fmt.Scan(&x);
+	x = x + 81985529216486895;
+	if (x > 8192) {
+		x = x - 5000;
+	}
+	fmt.Println(x);
+}
+`)
+	// 8192 is shifted by 12 and 5000 does not fit the add/sub immediate
+	asm := strings.Join(Generate(NewArm64(), frags, symTable), "\n")
+	for _, want := range []string{",#2,lsl #12", "mov x17,#5000"} {
+		if !strings.Contains(asm, want) {
+			t.Fatalf("expected the program to emit %q", want)
+		}
+	}
+}
+
+func TestDeepSlots(t *testing.T) {
+	// 40 values live across the subtraction, the frame is well over 256 bytes
+	names := []string{}
+	reads := ""
+	for idx := 0; idx < 40; idx++ {
+		names = append(names, fmt.Sprintf("v%v", idx))
+		reads += fmt.Sprintf("\tfmt.Scan(&v%v);\n", idx)
+	}
+	frags, symTable := CompileSource(t, fmt.Sprintf(`package main;
+import "fmt";
+func main() {
+	var %s int;
+%s	v0 = v37 - v38;
+	fmt.Println(%s);
+}
+`, strings.Join(names, ", "), reads, strings.Join(names, " + ")))
+	asm := strings.Join(Generate(NewArm64(), frags, symTable), "\n")
+	if !strings.Contains(asm, "[x29,x15]") {
+		t.Fatalf("expected slots deeper than 256 bytes")
+	}
+	// both operands are loaded before the subtraction, the second address does not overwrite the first operand
+	want := regexp.MustCompile(`mov x15,#-\d+\n\tldr x16,\[x29,x15\]\n\tmov x15,#-\d+\n\tldr x17,\[x29,x15\]\n\tsub x\d+,x16,x17`)
+	if !want.MatchString(asm) {
+		t.Fatalf("expected the operands of v37 - v38 to be loaded from their slots through x15")
+	}
+	// x15 only ever holds a slot offset
+	for _, line := range strings.Split(asm, "\n") {
+		if strings.Contains(line, "x15") && !strings.HasPrefix(line, "\tmov x15,#") && !strings.Contains(line, "[x29,x15]") {
+			t.Fatalf("x15 is used by %q", line)
+		}
+		if strings.Contains(line, "[x29,x16]") || strings.Contains(line, "[x29,x17]") {
+			t.Fatalf("a slot is addressed through a scratch register in %q", line)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package codegen
+
+import (
+	"fmt"
+	"proj/ir"
+	"proj/ir/cfg"
+	st "proj/symboltable"
+	"strings"
+)
+
+// Target emits the assembly of one instruction set from ILOC instructions
+type Target interface {
+	Header(globals []string) []string // Directives opening the program and declaring the global variables
+
+	Function(frame *Frame) []string // Code of the function laid out by frame
+
+	Footer() []string // Data and directives closing the program
+}
+
+// Frame is the activation record of a function, every virtual register lives in a stack slot
+type Frame struct {
+	Name   string         // Function name
+	Params []int          // Registers of the parameters in the order of the signature
+	Frags  []*ir.FuncFrag // Fragment of the function followed by its control flow fragments
+	Slots  map[int]int    // Offset from the frame pointer of the stack slot of each virtual register
+	Size   int            // Size of the slots in bytes, a multiple of 16
+}
+
+func NewTarget(name string) (Target, error) {
+	switch name {
+	case "arm64":
+		return NewArm64(), nil
+	case "amd64":
+		return NewAmd64(), nil
+	}
+	return nil, fmt.Errorf("unknown target %s, expected arm64 or amd64", name)
+}
+
+func NewFrame(frags []*ir.FuncFrag, params []int) *Frame {
+	frame := &Frame{frags[0].Label, params, frags, make(map[int]int), 0}
+	for _, reg := range params {
+		frame.addSlot(reg)
+	}
+	for _, frag := range frags {
+		for _, instruction := range frag.Body {
+			if instruction == nil {
+				continue
+			}
+			if _, isRet := instruction.(*ir.Ret); !isRet {
+				for _, reg := range instruction.GetTargets() {
+					frame.addSlot(reg)
+				}
+			}
+			for _, reg := range instruction.GetSources() {
+				frame.addSlot(reg)
+			}
+		}
+	}
+	if frame.Size%16 != 0 {
+		frame.Size += 8
+	}
+	return frame
+}
+
+func (frame *Frame) addSlot(reg int) {
+	if _, exist := frame.Slots[reg]; !exist {
+		frame.Size += 8
+		frame.Slots[reg] = -frame.Size
+	}
+}
+
+func Generate(target Target, funcfrags []*ir.FuncFrag, symTable *st.SymbolTable) []string {
+	//Emit the whole program: the global variables, then every function with its own frame
+	globals := []string{}
+	if len(funcfrags) > 0 && funcfrags[0].Label == ir.GlobalFragLabel {
+		for _, instruction := range funcfrags[0].Body {
+			if instruction.GetGlobal() != "" {
+				globals = append(globals, instruction.GetGlobal())
+			}
+		}
+		funcfrags = funcfrags[1:]
+	}
+
+	insList := target.Header(globals)
+	for _, function := range cfg.GroupByFunction(funcfrags, symTable) {
+		params := []int{}
+		if entry, exist := symTable.ContainFunction(function[0].Label); exist {
+			params = entry.GetValue().ParametersRegisterLocList
+		}
+		insList = append(insList, target.Function(NewFrame(function, params))...)
+	}
+	return append(insList, target.Footer()...)
+}
+
+// stringPool gives a label in read-only data to every distinct string constant
+type stringPool struct {
+	labels map[string]string
+	values []string // Constants in the order of their labels
+}
+
+func (pool *stringPool) label(value string) string {
+	if pool.labels == nil {
+		pool.labels = make(map[string]string)
+	}
+	if label, exist := pool.labels[value]; exist {
+		return label
+	}
+	label := fmt.Sprintf(".STR%v", len(pool.values))
+	pool.labels[value] = label
+	pool.values = append(pool.values, value)
+	return label
+}
+
+func (pool *stringPool) data() []string {
+	//The constants laid out like the strings built at run time: their length followed by their bytes
+	insList := []string{}
+	for idx, value := range pool.values {
+		insList = append(insList, "\t.p2align 3")
+		insList = append(insList, fmt.Sprintf(".STR%v:", idx))
+		insList = append(insList, fmt.Sprintf("\t.quad %v", len(value)))
+		if len(value) > 0 {
+			insList = append(insList, fmt.Sprintf("\t.ascii \"%v\"", asciiEscape(value)))
+		}
+	}
+	return insList
+}
+
+func asciiEscape(value string) string {
+	//Quote value for the .ascii directive, bytes other than printable ASCII are written in octal
+	out := strings.Builder{}
+	for idx := 0; idx < len(value); idx++ {
+		c := value[idx]
+		if c == '"' || c == '\\' {
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		} else if c >= ' ' && c <= '~' {
+			out.WriteByte(c)
+		} else {
+			out.WriteString(fmt.Sprintf("\\%03o", c))
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,123 @@
+package codegen
+
+import (
+	"proj/ir"
+)
+
+// Liveness holds the virtual registers live before and after each instruction of a function
+type Liveness struct {
+	Instrs  []ir.Instruction // Instructions of the function in layout order
+	Labels  map[string]int   // Index of the first instruction after each label
+	LiveIn  []map[int]bool
+	LiveOut []map[int]bool
+}
+
+func NewLiveness(frame *Frame) *Liveness {
+	live := &Liveness{[]ir.Instruction{}, make(map[string]int), nil, nil}
+	for _, frag := range frame.Frags {
+		live.Labels[frag.Label] = len(live.Instrs)
+		for _, instruction := range frag.Body {
+			if instruction != nil {
+				live.Instrs = append(live.Instrs, instruction)
+			}
+		}
+	}
+	for range live.Instrs {
+		live.LiveIn = append(live.LiveIn, make(map[int]bool))
+		live.LiveOut = append(live.LiveOut, make(map[int]bool))
+	}
+
+	// iterate the backward dataflow equations until they reach a fixed point
+	for changed := true; changed; {
+		changed = false
+		for idx := len(live.Instrs) - 1; idx >= 0; idx-- {
+			instruction := live.Instrs[idx]
+			for _, succ := range live.Successors(idx) {
+				for reg := range live.LiveIn[succ] {
+					if !live.LiveOut[idx][reg] {
+						live.LiveOut[idx][reg] = true
+						changed = true
+					}
+				}
+			}
+			defined := make(map[int]bool)
+			for _, reg := range Defs(instruction) {
+				defined[reg] = true
+			}
+			for reg := range live.LiveOut[idx] {
+				if !defined[reg] && !live.LiveIn[idx][reg] {
+					live.LiveIn[idx][reg] = true
+					changed = true
+				}
+			}
+			for _, reg := range Uses(instruction) {
+				if !live.LiveIn[idx][reg] {
+					live.LiveIn[idx][reg] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return live
+}
+
+func (live *Liveness) Successors(idx int) []int {
+	//Instructions that can execute right after the idx-th one, falling off the function has none
+	succs := []int{}
+	switch instr := live.Instrs[idx].(type) {
+	case *ir.Ret:
+		return succs
+	case *ir.Branch:
+		succs = append(succs, live.Labels[instr.GetLabel()])
+		if instr.GetFlag() != ir.AL {
+			succs = append(succs, idx+1)
+		}
+	case *ir.JumpTable:
+		for _, label := range instr.Successors() {
+			succs = append(succs, live.Labels[label])
+		}
+	default:
+		succs = append(succs, idx+1)
+	}
+	inRange := []int{}
+	for _, succ := range succs {
+		if succ < len(live.Instrs) {
+			inRange = append(inRange, succ)
+		}
+	}
+	return inRange
+}
+
+func Uses(instruction ir.Instruction) []int {
+	switch instr := instruction.(type) {
+	case *ir.Pop:
+		// pop only releases the arguments of the call, it does not read them
+		return []int{}
+	case *ir.Mov:
+		if isConditionalMov(instr) {
+			// the target keeps its value when the condition does not hold
+			return append(instr.GetSources(), instr.GetTargets()...)
+		}
+	}
+	return instruction.GetSources()
+}
+
+func Defs(instruction ir.Instruction) []int {
+	if _, isRet := instruction.(*ir.Ret); isRet {
+		return []int{}
+	}
+	return instruction.GetTargets()
+}
+
+func IsCall(instruction ir.Instruction) bool {
+	//Whether the instruction calls a function, which clobbers the caller-saved registers
+	switch instruction.(type) {
+	case *ir.Bl, *ir.NewStruct, *ir.NewArray, *ir.Append, *ir.Concat, *ir.StrEq, *ir.Delete, *ir.Read, *ir.Print, *ir.Println:
+		return true
+	}
+	return false
+}
+
+func isConditionalMov(instr *ir.Mov) bool {
+	return !instr.GetRetFlag() && instr.GetFlag() != ir.AL && instr.GetFlag() != ir.MARG
+}
@@ -0,0 +1,189 @@
+package codegen
+
+import (
+	"sort"
+)
+
+// Allocation maps the virtual registers of a function to physical registers or stack slots
+type Allocation struct {
+	Regs      map[int]int    // Physical register of each virtual register kept in a register
+	Spilled   []int          // Virtual registers living in a stack slot, in spill order
+	Saved     []int          // Callee-saved registers written by the function, in increasing order
+	CallSaves map[int][]int  // Caller-saved registers holding values live across the call at each instruction index
+	LiveIn    map[int]bool   // Virtual registers live at the entry of the function
+	Intervals map[int][2]int // First and last instruction where each virtual register is live, -1 is the entry
+}
+
+type interval struct {
+	reg        int
+	start, end int
+	crossCall  bool // The value must survive a call
+}
+
+func LinearScan(live *Liveness, params []int, callerSaved []int, calleeSaved []int) *Allocation {
+	/*
+		Linear scan allocation (Poletto and Sarkar). A value live across a call
+		prefers a callee-saved register, which the function saves once in its
+		prologue. It gets a caller-saved register when they are all taken, then
+		every call it lives across saves and restores that register. Other values
+		prefer the caller-saved registers. When no register is free the interval
+		ending last is spilled.
+	*/
+	intervals := make(map[int]*interval)
+	extend := func(reg int, pos int) {
+		if iv, exist := intervals[reg]; exist {
+			if pos < iv.start {
+				iv.start = pos
+			}
+			if pos > iv.end {
+				iv.end = pos
+			}
+		} else {
+			intervals[reg] = &interval{reg, pos, pos, false}
+		}
+	}
+	// parameters are written at the entry of the function, before the first instruction
+	for _, reg := range params {
+		extend(reg, -1)
+	}
+	for idx, instruction := range live.Instrs {
+		for reg := range live.LiveIn[idx] {
+			extend(reg, idx)
+		}
+		for reg := range live.LiveOut[idx] {
+			extend(reg, idx)
+		}
+		for _, reg := range Defs(instruction) {
+			extend(reg, idx)
+		}
+		if IsCall(instruction) {
+			defined := make(map[int]bool)
+			for _, reg := range Defs(instruction) {
+				defined[reg] = true
+			}
+			for reg := range live.LiveOut[idx] {
+				if !defined[reg] {
+					intervals[reg].crossCall = true
+				}
+			}
+		}
+	}
+
+	alloc := &Allocation{make(map[int]int), []int{}, []int{}, make(map[int][]int), make(map[int]bool), make(map[int][2]int)}
+	if len(live.Instrs) > 0 {
+		alloc.LiveIn = live.LiveIn[0]
+	}
+	sorted := []*interval{}
+	for _, iv := range intervals {
+		sorted = append(sorted, iv)
+		alloc.Intervals[iv.reg] = [2]int{iv.start, iv.end}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].start != sorted[j].start {
+			return sorted[i].start < sorted[j].start
+		}
+		return sorted[i].reg < sorted[j].reg
+	})
+
+	free := make(map[int]bool)
+	for _, phys := range callerSaved {
+		free[phys] = true
+	}
+	for _, phys := range calleeSaved {
+		free[phys] = true
+	}
+	saved := make(map[int]bool)
+	active := []*interval{}
+	for _, current := range sorted {
+		// expire the intervals ending before the current one starts
+		stillActive := []*interval{}
+		for _, iv := range active {
+			if iv.end < current.start {
+				free[alloc.Regs[iv.reg]] = true
+			} else {
+				stillActive = append(stillActive, iv)
+			}
+		}
+		active = stillActive
+
+		candidates := append(append([]int{}, callerSaved...), calleeSaved...)
+		if current.crossCall {
+			candidates = append(append([]int{}, calleeSaved...), callerSaved...)
+		}
+		phys := -1
+		for _, reg := range candidates {
+			if free[reg] {
+				phys = reg
+				break
+			}
+		}
+		if phys == -1 {
+			// steal the register of the active interval ending last if it ends after the current one
+			var victim *interval
+			for _, iv := range active {
+				if victim == nil || iv.end > victim.end {
+					victim = iv
+				}
+			}
+			if victim == nil || victim.end <= current.end {
+				alloc.Spilled = append(alloc.Spilled, current.reg)
+				continue
+			}
+			phys = alloc.Regs[victim.reg]
+			delete(alloc.Regs, victim.reg)
+			alloc.Spilled = append(alloc.Spilled, victim.reg)
+			active = remove(active, victim)
+		}
+		free[phys] = false
+		alloc.Regs[current.reg] = phys
+		active = append(active, current)
+		if contains(calleeSaved, phys) {
+			saved[phys] = true
+		}
+	}
+	for _, phys := range calleeSaved {
+		if saved[phys] {
+			alloc.Saved = append(alloc.Saved, phys)
+		}
+	}
+	// the caller-saved registers each call clobbers while they hold a live value
+	for idx, instruction := range live.Instrs {
+		if !IsCall(instruction) {
+			continue
+		}
+		defined := make(map[int]bool)
+		for _, reg := range Defs(instruction) {
+			defined[reg] = true
+		}
+		regs := []int{}
+		for reg := range live.LiveOut[idx] {
+			if phys, exist := alloc.Regs[reg]; exist && !defined[reg] && contains(callerSaved, phys) {
+				regs = append(regs, phys)
+			}
+		}
+		if len(regs) > 0 {
+			sort.Ints(regs)
+			alloc.CallSaves[idx] = regs
+		}
+	}
+	return alloc
+}
+
+func contains(regs []int, reg int) bool {
+	for _, r := range regs {
+		if r == reg {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(intervals []*interval, target *interval) []*interval {
+	kept := []*interval{}
+	for _, iv := range intervals {
+		if iv != target {
+			kept = append(kept, iv)
+		}
+	}
+	return kept
+}
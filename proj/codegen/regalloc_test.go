@@ -0,0 +1,90 @@
+package codegen
+
+import (
+	"proj/internal/golitetest"
+	"proj/ir"
+	"proj/ir/cfg"
+	st "proj/symboltable"
+	"testing"
+)
+
+func CompileSource(t *testing.T, source string) ([]*ir.FuncFrag, *st.SymbolTable) {
+	//Compile the source down to iloc, without the fragment of the global variables
+	program := golitetest.Compile(t, source)
+	frags := ir.ControlFlowFrags
+	if len(frags) > 0 && frags[0].Label == ir.GlobalFragLabel {
+		frags = frags[1:]
+	}
+	return frags, program.GlobalSymbolTable
+}
+
+func TestLinearScan(t *testing.T) {
+	frags, symTable := CompileSource(t, `package main;
+import "fmt";
+var calls int;
+func fact(n int) int {
+	calls = calls + 1;
+	if (n <= 1) {
+		return 1;
+	}
+	return n * fact(n - 1);
+}
+func main() {
+	var a, b, c, d, e, f, g, h, i, j, k, l, m, n, o, p, q, r, s, u int;
+	fmt.Scan(&a);
+	b = a + 1; c = b + 1; d = c + 1; e = d + 1; f = e + 1; g = f + 1;
+	h = g + 1; i = h + 1; j = i + 1; k = j + 1; l = k + 1; m = l + 1; n = m + 1;
+	o = n + 1; p = o + 1; q = p + 1; r = q + 1; s = r + 1; u = s + 1;
+	for (a < 100) {
+		a = a + fact(b);
+		fmt.Println(a);
+	}
+	a = a + b + c + d + e + f + g + h + i + j + k + l + m + n + o + p + q + r + s + u;
+	fmt.Println(a);
+}
+`)
+	spilled, callSaves := 0, 0
+	for _, function := range cfg.GroupByFunction(frags, symTable) {
+		entry, _ := symTable.ContainFunction(function[0].Label)
+		frame := NewFrame(function, entry.GetValue().ParametersRegisterLocList)
+		live := NewLiveness(frame)
+		alloc := LinearScan(live, frame.Params, arm64CallerSaved, arm64CalleeSaved)
+		spilled += len(alloc.Spilled)
+		callSaves += len(alloc.CallSaves)
+		for idx, instruction := range live.Instrs {
+			// the values written or live after an instruction must be in distinct registers
+			holders := make(map[int]int)
+			check := func(reg int) {
+				phys, exist := alloc.Regs[reg]
+				if !exist {
+					return
+				}
+				if other, taken := holders[phys]; taken && other != reg {
+					t.Fatalf("%s: r%v and r%v share x%v after %v", frame.Name, other, reg, phys, instruction)
+				}
+				holders[phys] = reg
+			}
+			for reg := range live.LiveOut[idx] {
+				check(reg)
+			}
+			for _, reg := range Defs(instruction) {
+				check(reg)
+			}
+			if !IsCall(instruction) {
+				continue
+			}
+			// a value in a caller-saved register must be saved around every call it lives across
+			for reg := range live.LiveOut[idx] {
+				if phys, exist := alloc.Regs[reg]; exist && contains(arm64CallerSaved, phys) && !contains(Defs(instruction), reg) && !contains(alloc.CallSaves[idx], phys) {
+					t.Fatalf("%s: r%v lives across %v in caller-saved x%v", frame.Name, reg, instruction, phys)
+				}
+			}
+		}
+	}
+	if spilled == 0 {
+		t.Fatalf("expected main to spill under register pressure")
+	}
+	if callSaves == 0 {
+		t.Fatalf("expected calls to save the caller-saved registers once the callee-saved ones are taken")
+	}
+}
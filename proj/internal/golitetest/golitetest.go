@@ -0,0 +1,42 @@
+// Package golitetest compiles golite sources for the tests of the other packages
+package golitetest
+
+import (
+	"os"
+	"path/filepath"
+	"proj/ast"
+	"proj/context"
+	"proj/ir"
+	"proj/parser"
+	"proj/sa"
+	"proj/scanner"
+	"testing"
+)
+
+func Parse(t *testing.T, source string) (*ast.Program, []*parser.SyntaxError) {
+	//Write the source to a file named prog.golite and parse it, the syntax errors are returned
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prog.golite")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.New(false, path)
+	p := parser.New(ctx, scanner.New(ctx))
+	program := p.Parse()
+	return program, p.Errors()
+}
+
+func Compile(t *testing.T, source string) *ast.Program {
+	//Compile the source down to iloc, the fragments are left in ir.ControlFlowFrags
+	t.Helper()
+	program, syntaxErrors := Parse(t, source)
+	if len(syntaxErrors) > 0 {
+		t.Fatalf("syntax errors: %v", syntaxErrors)
+	}
+	if errors := sa.PerformSA(program); len(errors) > 0 {
+		t.Fatalf("semantic errors: %v", errors)
+	}
+	ir.ControlFlowFrags = make([]*ir.FuncFrag, 0)
+	program.TranslateToILoc(program.GlobalSymbolTable)
+	return program
+}
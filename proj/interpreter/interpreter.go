@@ -0,0 +1,435 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"proj/ir"
+	st "proj/symboltable"
+)
+
+// maxCallDepth bounds the recursion of the interpreted program
+const maxCallDepth = 100000
+
+type frame struct {
+	function  string      // Name of the function executing in this frame
+	registers map[int]int // Virtual registers of the function, zero until written
+	args      []int       // Values pushed for the next call
+	retVals   []int       // Values returned by the last call
+	cmpLeft   int         // Operands of the last cmp, read by conditional instructions
+	cmpRight  int
+}
+
+type Interpreter struct {
+	frags    []*ir.FuncFrag
+	symTable *st.SymbolTable
+	labels   map[string]int // Index of the fragment carrying each label
+	globals  map[string]int
+	heap     map[int][]int  // Struct cells by address, nil is address 0
+	literals map[string]int // Address of the cell of each string constant loaded so far
+	nextAddr int
+	depth    int
+	in       *bufio.Reader
+	out      *bufio.Writer
+}
+
+func New(frags []*ir.FuncFrag, symTable *st.SymbolTable, in io.Reader, out io.Writer) *Interpreter {
+	interp := &Interpreter{frags, symTable, make(map[string]int), make(map[string]int), make(map[int][]int), make(map[string]int), 1, 0, bufio.NewReader(in), bufio.NewWriter(out)}
+	for idx, frag := range frags {
+		interp.labels[frag.Label] = idx
+	}
+	return interp
+}
+
+func Run(frags []*ir.FuncFrag, symTable *st.SymbolTable, in io.Reader, out io.Writer) error {
+	//Execute the ILOC program: initialize the global variables, then call main
+	interp := New(frags, symTable, in, out)
+	defer interp.out.Flush()
+	if _, exist := interp.labels[ir.GlobalFragLabel]; exist {
+		if _, err := interp.execute(&frame{function: ir.GlobalFragLabel, registers: make(map[int]int)}, ir.GlobalFragLabel); err != nil {
+			return err
+		}
+	}
+	_, err := interp.call("main", []int{})
+	return err
+}
+
+func (interp *Interpreter) isFunction(label string) bool {
+	_, exist := interp.symTable.ContainFunction(label)
+	return exist
+}
+
+func (interp *Interpreter) call(function string, args []int) ([]int, error) {
+	/*
+		Call the function in a new frame, binding the pushed values to the
+		registers the callee assigned to its parameters
+	*/
+	entry, exist := interp.symTable.ContainFunction(function)
+	if _, hasBody := interp.labels[function]; !exist || !hasBody {
+		return nil, fmt.Errorf("runtime error: call of undefined function %s", function)
+	}
+	params := entry.GetValue().ParametersRegisterLocList
+	if len(params) != len(args) {
+		return nil, fmt.Errorf("runtime error: %s expects %d arguments, %d were pushed", function, len(params), len(args))
+	}
+	interp.depth += 1
+	defer func() { interp.depth -= 1 }()
+	if interp.depth > maxCallDepth {
+		return nil, fmt.Errorf("runtime error: stack overflow calling %s", function)
+	}
+	fr := &frame{function: function, registers: make(map[int]int)}
+	for idx, reg := range params {
+		fr.registers[reg] = args[idx]
+	}
+	return interp.execute(fr, function)
+}
+
+func (interp *Interpreter) execute(fr *frame, label string) ([]int, error) {
+	/*
+		Run the instructions from the fragment carrying label. A fragment falls
+		through into the next one, the function returns on ret or when it runs
+		into the fragment of another function.
+	*/
+	fragIdx := interp.labels[label]
+	pc := 0
+	for {
+		body := interp.frags[fragIdx].Body
+		if pc >= len(body) {
+			fragIdx += 1
+			pc = 0
+			if fragIdx >= len(interp.frags) || interp.isFunction(interp.frags[fragIdx].Label) {
+				return nil, nil
+			}
+			continue
+		}
+		instruction := body[pc]
+		pc += 1
+		if instruction == nil {
+			continue
+		}
+		switch instr := instruction.(type) {
+		case *ir.Branch:
+			if !fr.holds(instr.GetFlag()) {
+				continue
+			}
+			target, exist := interp.labels[instr.GetLabel()]
+			if !exist {
+				return nil, fmt.Errorf("runtime error: branch to undefined label %s", instr.GetLabel())
+			}
+			fragIdx, pc = target, 0
+		case *ir.JumpTable:
+			label := instr.Target(fr.registers[instr.GetSources()[0]])
+			target, exist := interp.labels[label]
+			if !exist {
+				return nil, fmt.Errorf("runtime error: branch to undefined label %s", label)
+			}
+			fragIdx, pc = target, 0
+		case *ir.Ret:
+			if instr.GetImmediate() != nil {
+				return []int{*instr.GetImmediate()}, nil
+			}
+			values := []int{}
+			for _, reg := range instr.GetSources() {
+				values = append(values, fr.registers[reg])
+			}
+			return values, nil
+		default:
+			if err := interp.step(fr, instruction); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (interp *Interpreter) step(fr *frame, instruction ir.Instruction) error {
+	//Execute an instruction that does not change the control flow of the function
+	regs := fr.registers
+	switch instr := instruction.(type) {
+	case *ir.Add, *ir.Sub, *ir.Mul, *ir.Div, *ir.Mod, *ir.And, *ir.Or, *ir.Xor, *ir.Bic, *ir.Lsl, *ir.Asr:
+		left := regs[instr.GetSources()[0]]
+		right := fr.operand(instr, 1)
+		var result int
+		switch instr.(type) {
+		case *ir.Add:
+			result = left + right
+		case *ir.Sub:
+			result = left - right
+		case *ir.Mul:
+			result = left * right
+		case *ir.Div:
+			if right == 0 {
+				return fr.errorf("integer divide by zero")
+			}
+			result = left / right
+		case *ir.Mod:
+			if right == 0 {
+				return fr.errorf("integer divide by zero")
+			}
+			result = left % right
+		case *ir.And:
+			result = left & right
+		case *ir.Or:
+			result = left | right
+		case *ir.Xor:
+			result = left ^ right
+		case *ir.Bic:
+			result = left &^ right
+		case *ir.Lsl, *ir.Asr:
+			if right < 0 {
+				return fr.errorf("negative shift amount")
+			}
+			if _, isLsl := instr.(*ir.Lsl); isLsl {
+				result = left << uint(right)
+			} else {
+				result = left >> uint(right)
+			}
+		}
+		regs[instr.GetTargets()[0]] = result
+	case *ir.Not:
+		regs[instr.GetTargets()[0]] = boolToInt(fr.operand(instr, 0) == 0)
+	case *ir.Mov:
+		if instr.GetRetFlag() {
+			if instr.GetRetIndex() < len(fr.retVals) {
+				regs[instr.GetTargets()[0]] = fr.retVals[instr.GetRetIndex()]
+			} else {
+				regs[instr.GetTargets()[0]] = 0
+			}
+		} else if fr.holds(instr.GetFlag()) {
+			regs[instr.GetTargets()[0]] = fr.operand(instr, 0)
+		}
+	case *ir.Cmp:
+		fr.cmpLeft = regs[instr.GetSources()[0]]
+		fr.cmpRight = fr.operand(instr, 1)
+	case *ir.Ldr:
+		if instr.GetGlobal() == "" {
+			return fr.errorf("unsupported load %s", instr)
+		}
+		regs[instr.GetTargets()[0]] = interp.globals[instr.GetGlobal()]
+	case *ir.Str:
+		if instr.GetGlobal() == "" {
+			return fr.errorf("unsupported store %s", instr)
+		}
+		interp.globals[instr.GetGlobal()] = regs[instr.GetSources()[0]]
+	case *ir.NewStruct:
+		regs[instr.GetTargets()[0]] = interp.alloc(instr.GetSize())
+	case *ir.Delete:
+		addr := regs[instr.GetSources()[0]]
+		if addr == 0 {
+			return nil
+		}
+		if _, exist := interp.heap[addr]; !exist {
+			return fr.errorf("delete of a freed struct")
+		}
+		delete(interp.heap, addr)
+	case *ir.LoadRef:
+		cell, err := interp.deref(fr, regs[instr.GetSources()[0]], instr.GetFieldIndex())
+		if err != nil {
+			return err
+		}
+		regs[instr.GetTargets()[0]] = cell[instr.GetFieldIndex()]
+	case *ir.StrRef:
+		cell, err := interp.deref(fr, regs[instr.GetSources()[1]], instr.GetFieldIndex())
+		if err != nil {
+			return err
+		}
+		cell[instr.GetFieldIndex()] = regs[instr.GetSources()[0]]
+	case *ir.NewArray:
+		length := fr.operand(instr, 0)
+		if length < 0 {
+			return fr.errorf("makeslice: len out of range")
+		}
+		regs[instr.GetTargets()[0]] = interp.alloc(length)
+	case *ir.LoadIndex:
+		cell, err := interp.deref(fr, regs[instr.GetSources()[0]], regs[instr.GetSources()[1]])
+		if err != nil {
+			return err
+		}
+		regs[instr.GetTargets()[0]] = cell[regs[instr.GetSources()[1]]]
+	case *ir.StrIndex:
+		cell, err := interp.deref(fr, regs[instr.GetSources()[1]], regs[instr.GetSources()[2]])
+		if err != nil {
+			return err
+		}
+		cell[regs[instr.GetSources()[2]]] = regs[instr.GetSources()[0]]
+	case *ir.Len:
+		regs[instr.GetTargets()[0]] = 0
+		if slice := regs[instr.GetSources()[0]]; slice != 0 {
+			regs[instr.GetTargets()[0]] = interp.heap[slice][ir.SliceLen]
+		}
+	case *ir.CheckBounds:
+		index, length := regs[instr.GetSources()[0]], fr.operand(instr, 1)
+		if index < 0 || index >= length {
+			return fr.errorf("index out of range [%d] with length %d", index, length)
+		}
+	case *ir.CheckMake:
+		length, capacity := regs[instr.GetSources()[0]], regs[instr.GetSources()[1]]
+		if length < 0 {
+			return fr.errorf("makeslice: len out of range")
+		}
+		if length > capacity {
+			return fr.errorf("makeslice: cap out of range")
+		}
+	case *ir.Append:
+		regs[instr.GetTargets()[0]] = interp.append(regs[instr.GetSources()[0]], regs[instr.GetSources()[1]])
+	case *ir.LoadStr:
+		addr, exist := interp.literals[instr.GetValue()]
+		if !exist {
+			addr = interp.newString(instr.GetValue())
+			interp.literals[instr.GetValue()] = addr
+		}
+		regs[instr.GetTargets()[0]] = addr
+	case *ir.Concat:
+		left, right := interp.stringAt(regs[instr.GetSources()[0]]), interp.stringAt(regs[instr.GetSources()[1]])
+		regs[instr.GetTargets()[0]] = interp.newString(left + right)
+	case *ir.StrEq:
+		left, right := interp.stringAt(regs[instr.GetSources()[0]]), interp.stringAt(regs[instr.GetSources()[1]])
+		regs[instr.GetTargets()[0]] = boolToInt(left == right)
+	case *ir.Push:
+		fr.args = []int{}
+		for _, src := range instr.GetSources() {
+			fr.args = append(fr.args, regs[src])
+		}
+	case *ir.Bl:
+		retVals, err := interp.call(instr.GetLabel(), fr.args)
+		if err != nil {
+			return err
+		}
+		fr.retVals = retVals
+	case *ir.Pop:
+		fr.args = nil
+	case *ir.Read:
+		var val int
+		interp.out.Flush()
+		if _, err := fmt.Fscan(interp.in, &val); err != nil {
+			return fr.errorf("reading an int: %v", err)
+		}
+		regs[instr.GetTargets()[0]] = val
+	case *ir.Print:
+		if instr.IsString() {
+			fmt.Fprint(interp.out, interp.stringAt(regs[instr.GetSources()[0]]))
+		} else {
+			fmt.Fprintf(interp.out, "%d", regs[instr.GetSources()[0]])
+		}
+	case *ir.Println:
+		if instr.IsString() {
+			fmt.Fprintln(interp.out, interp.stringAt(regs[instr.GetSources()[0]]))
+		} else {
+			fmt.Fprintf(interp.out, "%d\n", regs[instr.GetSources()[0]])
+		}
+	default:
+		return fr.errorf("unsupported instruction %s", instruction)
+	}
+	return nil
+}
+
+func (interp *Interpreter) alloc(size int) int {
+	//Allocate a zeroed cell of size words and return its address
+	addr := interp.nextAddr
+	interp.heap[addr] = make([]int, size)
+	interp.nextAddr += 1
+	return addr
+}
+
+func (interp *Interpreter) newString(value string) int {
+	//Allocate a string cell, its length followed by one byte per word like the elements of a slice
+	addr := interp.alloc(len(value) + 1)
+	interp.heap[addr][0] = len(value)
+	for idx := 0; idx < len(value); idx++ {
+		interp.heap[addr][idx+1] = int(value[idx])
+	}
+	return addr
+}
+
+func (interp *Interpreter) stringAt(addr int) string {
+	//The string held by the cell at addr, nil is the empty string
+	if addr == 0 {
+		return ""
+	}
+	cell := interp.heap[addr]
+	value := make([]byte, cell[0])
+	for idx := range value {
+		value[idx] = byte(cell[idx+1])
+	}
+	return string(value)
+}
+
+func (interp *Interpreter) append(slice int, value int) int {
+	/*
+		Return the header of slice with value added at its end. The elements are
+		shared while the capacity allows, then moved to a cell twice as large.
+	*/
+	length, capacity, elements := 0, 0, 0
+	if slice != 0 {
+		header := interp.heap[slice]
+		length, capacity, elements = header[ir.SliceLen], header[ir.SliceCap], header[ir.SliceData]
+	}
+	if length == capacity {
+		capacity = 2 * capacity
+		if capacity < 4 {
+			capacity = 4
+		}
+		grown := interp.alloc(capacity)
+		if elements != 0 {
+			copy(interp.heap[grown], interp.heap[elements][:length])
+		}
+		elements = grown
+	}
+	interp.heap[elements][length] = value
+	header := interp.alloc(ir.SliceHeaderSize)
+	interp.heap[header][ir.SliceLen] = length + 1
+	interp.heap[header][ir.SliceCap] = capacity
+	interp.heap[header][ir.SliceData] = elements
+	return header
+}
+
+func (interp *Interpreter) deref(fr *frame, addr int, field int) ([]int, error) {
+	if addr == 0 {
+		return nil, fr.errorf("nil pointer dereference")
+	}
+	cell, exist := interp.heap[addr]
+	if !exist {
+		return nil, fr.errorf("use of a freed struct")
+	}
+	if field < 0 || field >= len(cell) {
+		return nil, fr.errorf("field %d out of range", field)
+	}
+	return cell, nil
+}
+
+func (fr *frame) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("runtime error in %s: %s", fr.function, fmt.Sprintf(format, args...))
+}
+
+func (fr *frame) operand(instr ir.Instruction, idx int) int {
+	//The idx-th source operand, which is the immediate if the instruction has one
+	if imm := instr.GetImmediate(); imm != nil {
+		return *imm
+	}
+	return fr.registers[instr.GetSources()[idx]]
+}
+
+func (fr *frame) holds(flag ir.ApsrFlag) bool {
+	//Whether the condition holds for the operands of the last cmp
+	switch flag {
+	case ir.GT:
+		return fr.cmpLeft > fr.cmpRight
+	case ir.LT:
+		return fr.cmpLeft < fr.cmpRight
+	case ir.GE:
+		return fr.cmpLeft >= fr.cmpRight
+	case ir.LE:
+		return fr.cmpLeft <= fr.cmpRight
+	case ir.EQ:
+		return fr.cmpLeft == fr.cmpRight
+	case ir.NE:
+		return fr.cmpLeft != fr.cmpRight
+	}
+	return true
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
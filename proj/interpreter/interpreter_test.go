@@ -0,0 +1,593 @@
+package interpreter
+
+import (
+	"bytes"
+	"proj/internal/golitetest"
+	"proj/ir"
+	"strings"
+	"testing"
+)
+
+func RunSource(t *testing.T, source string, input string) (string, error) {
+	//Compile the source down to iloc and execute it
+	program := golitetest.Compile(t, source)
+	out := bytes.Buffer{}
+	err := Run(ir.ControlFlowFrags, program.GlobalSymbolTable, strings.NewReader(input), &out)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		input  string
+		want   string
+	}{
+		{"arithmetic", `package main;
+import "fmt";
+func main() {
+	var a, b int;
+	fmt.Scan(&a);
+	b = -a * 3 + 10 / 4 - (a - 1);
+	fmt.Println(b);
+	fmt.Print(a);
+}
+`, "7", "-25\n7"},
+		{"recursion and globals", `package main;
+import "fmt";
+var calls int;
+func fact(n int) int {
+	calls = calls + 1;
+	if (n <= 1) {
+		return 1;
+	}
+	return n * fact(n - 1);
+}
+func main() {
+	var r int;
+	r = fact(10);
+	fmt.Println(r);
+	fmt.Println(calls);
+}
+`, "", "3628800\n10\n"},
+		{"structs and loops", `package main;
+import "fmt";
+type Node struct {
+	val int;
+	next *Node;
+};
+func main() {
+	var head, n *Node;
+	var i, s int;
+	i = 0;
+	for (i < 4) {
+		n = new(Node);
+		n.val = i;
+		n.next = head;
+		head = n;
+		i = i + 1;
+	}
+	s = 0;
+	for (head != nil) {
+		if (head.val == 2) {
+			s = s + 100;
+		} else {
+			s = s + head.val;
+		}
+		n = head;
+		head = head.next;
+		delete(n);
+	}
+	fmt.Println(s);
+}
+`, "", "104\n"},
+		{"arrays and slices", `package main;
+import "fmt";
+var g [4]int;
+func sum(s []int) int {
+	var i, t int;
+	i = 0;
+	t = 0;
+	for (i < len(s)) {
+		t = t + s[i];
+		i = i + 1;
+	}
+	return t;
+}
+func main() {
+	var a [5]int;
+	var s, t []int;
+	var i, x int;
+	i = 0;
+	for (i < len(a)) {
+		a[i] = i * i;
+		s = append(s, a[i]);
+		i = i + 1;
+	}
+	x = a[4] + a[2];
+	fmt.Println(x);
+	x = sum(s);
+	fmt.Println(x);
+	t = make([]int, 3);
+	t[1] = 7;
+	t = append(t, 5, 6);
+	x = sum(t) + len(t);
+	fmt.Println(x);
+	g[3] = 9;
+	x = g[3] + len(g);
+	fmt.Println(x);
+}
+`, "", "20\n30\n23\n13\n"},
+		{"strings", `package main;
+import "fmt";
+var greeting string;
+func join(a string, b string) string {
+	return a + ", " + b;
+}
+func main() {
+	var s, t string;
+	greeting = "hello";
+	s = join(greeting, "world");
+	fmt.Println(s);
+	fmt.Println(len(s));
+	fmt.Print("tab\there \"quoted\"\n");
+	if (t == "") {
+		fmt.Println("empty");
+	}
+	t = "hel" + "lo";
+	if (t == greeting) {
+		fmt.Print(t);
+	}
+}
+`, "", "hello, world\n12\ntab\there \"quoted\"\nempty\nhello"},
+		{"nested field chains", `package main;
+import "fmt";
+type Inner struct {
+	a int;
+	b int;
+	c int;
+};
+type Node struct {
+	val int;
+	in *Inner;
+	next *Node;
+};
+var head *Node;
+func main() {
+	var list *Node;
+	var x int;
+	list = new(Node);
+	list.next = new(Node);
+	list.next.next = new(Node);
+	list.next.next.val = 3;
+	list.next.in = new(Inner);
+	list.next.in.c = 7;
+	list.next.in.b = 5;
+	x = list.next.next.val + list.next.in.c * 10 + list.next.in.b * 100;
+	fmt.Println(x);
+	head = list;
+	head.next.next.in = list.next.in;
+	head.next.next.in.a = 9;
+	x = list.next.in.a + head.val;
+	fmt.Println(x);
+}
+`, "", "573\n9\n"},
+		{"0, 8 and 12 parameters", `package main;
+import "fmt";
+func zero() int {
+	return 7;
+}
+func eight(a int, b int, c int, d int, e int, f int, g int, h int) int {
+	return a + 2 * b + 3 * c + 4 * d + 5 * e + 6 * f + 7 * g + 8 * h;
+}
+func twelve(a int, b int, c int, d int, e int, f int, g int, h int, i int, j int, k int, l int) int {
+	return a - b + c - d + e - f + g - h + i * 1000 + j * 100 + k * 10 + l + eight(i, j, k, l, a, b, c, d);
+}
+func main() {
+	var x, y int;
+	x = zero();
+	fmt.Println(x);
+	y = eight(1, 2, 3, 4, 5, 6, 7, x);
+	fmt.Println(y);
+	y = twelve(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, y + x);
+	fmt.Println(y);
+}
+`, "", "7\n196\n11253\n"},
+		{"multiple return values", `package main;
+import "fmt";
+func divmod(a int, b int) (int, int) {
+	return a / b, a - a / b * b;
+}
+func pass(a int, b int) (int, int) {
+	return divmod(a, b);
+}
+func main() {
+	var q, r, a, b int;
+	var arr [2]int;
+	q, r = pass(17, 5);
+	fmt.Println(q * 10 + r);
+	a, b = 1, 2;
+	a, b = b, a;
+	fmt.Println(a * 10 + b);
+	arr[0], arr[1] = divmod(9, 2);
+	fmt.Println(arr[0] * 10 + arr[1]);
+}
+`, "", "32\n21\n41\n"},
+		{"methods", `package main;
+import "fmt";
+type Node struct {
+	val int;
+	next *Node;
+};
+func (n *Node) Sum() int {
+	if (n.next == nil) {
+		return n.val;
+	}
+	return n.val + n.next.Sum();
+}
+func (n *Node) Push(v int) *Node {
+	var m *Node;
+	m = new(Node);
+	m.val = v;
+	m.next = n;
+	return m;
+}
+func (n *Node) Set(v int) {
+	n.val = v;
+}
+func main() {
+	var n *Node;
+	var ns []*Node;
+	n = new(Node);
+	n.val = 3;
+	n = n.Push(9).Push(1);
+	fmt.Println(n.Sum());
+	ns = append(ns, n);
+	ns[0].next.Set(5);
+	fmt.Println(n.next.Sum());
+}
+`, "", "13\n8\n"},
+		{"break and continue", `package main;
+import "fmt";
+func main() {
+	var i, j, s int;
+	i = 0;
+	s = 0;
+	outer: for (true) {
+		i = i + 1;
+		j = 0;
+		for (j < 5) {
+			j = j + 1;
+			if (j == 3) {
+				continue outer;
+			}
+			if (i == 4) {
+				break outer;
+			}
+			if (j == 1) {
+				continue;
+			}
+			s = s + i;
+		}
+	}
+	fmt.Println(s);
+	fmt.Println(i);
+}
+`, "", "6\n4\n"},
+		{"three-clause and bare for", `package main;
+import "fmt";
+func main() {
+	var i, j, s int;
+	s = 0;
+	for i = 0; i < 10; i = i + 1 {
+		if (i == 3) {
+			continue;
+		}
+		if (i == 8) {
+			break;
+		}
+		s = s + i;
+	}
+	fmt.Println(s);
+	for i, j = 0, 10; i < j; i, j = i + 1, j - 1 {
+	}
+	fmt.Println(i);
+	for {
+		i = i + 1;
+		if (i * i > 50) {
+			break;
+		}
+	}
+	fmt.Println(i);
+}
+`, "", "25\n5\n8\n"},
+		{"switch", `package main;
+import "fmt";
+func dense(d int) int {
+	switch d {
+	case 0, 6:
+		return 100;
+	case 1:
+		return 1;
+	case 2:
+		return 2;
+	case 4:
+		return 4;
+	default:
+		return -1;
+	}
+	return 0;
+}
+func main() {
+	var i, s int;
+	s = 0;
+	for i = -1; i < 8; i = i + 1 {
+		s = s * 3 + dense(i);
+	}
+	fmt.Println(s);
+	s = 0;
+	loop: for i = 0; i < 10; i = i + 1 {
+		switch {
+		case i == 2, i == 4:
+			continue;
+		case i == 7:
+			break loop;
+		case i > 3:
+			s = s + 100;
+			break;
+		default:
+			s = s + i;
+		}
+		s = s + 1000;
+	}
+	fmt.Println(s);
+	switch s * 2 {
+	case 8:
+	case 10408:
+		fmt.Println("matched");
+	}
+}
+`, "", "213671\n5204\nmatched\n"},
+		{"variable declarations in statements", `package main;
+import "fmt";
+var x int;
+func pair(a int) (int, bool) {
+	return a * 2, a > 3;
+}
+func main() {
+	x = 5;
+	y := x + 1;
+	x := 10;
+	a, ok := pair(y);
+	{
+		z := x;
+		x := 2;
+		a, b := z + x, 3;
+		fmt.Println(a * b);
+	}
+	var s int = 0;
+	for i := 0; i < 3; i = i + 1 {
+		var t int;
+		t = t + i;
+		s = s + t;
+	}
+	var n, m = 4, ok;
+	if (m) {
+		fmt.Println(x + y + a + s + n);
+	}
+}
+`, "", "36\n35\n"},
+		{"constants", `package main;
+import "fmt";
+const N = 10;
+const Big, Neg = N * N + 1, -(N - 3) / 2;
+const On bool = N > 5 && !(N == 3);
+const Quo, Rem = N / -2, -7 % -2;
+func main() {
+	const K = N / 3;
+	var i, s int;
+	for i = 0; i < N; i = i + 1 {
+		s = s + i - K;
+	}
+	fmt.Println(s + Big + Neg);
+	{
+		const K = 100;
+		fmt.Println(K);
+	}
+	switch i {
+	case Big:
+	case N:
+		if (On) {
+			fmt.Println(K);
+		}
+	}
+	fmt.Println(Quo);
+	fmt.Println(Rem);
+}
+`, "", "113\n100\n3\n-5\n-1\n"},
+		{"bitwise operators", `package main;
+import "fmt";
+const Low = 1 << 4 - 1;
+func main() {
+	var a, b, n int;
+	fmt.Scan(&a);
+	fmt.Scan(&n);
+	b = 6;
+	fmt.Println(a & b | 1 ^ 8);
+	fmt.Println(1 + 2 << 3 * 2);
+	fmt.Println(a % 5 + -a % 5 * 10);
+	fmt.Println(a &^ b + ^a);
+	fmt.Println(a << n + -a >> n);
+	fmt.Println(a << (n + 61) + -a >> (n + 70));
+	if (a | b >= Low) {
+		fmt.Println(a ^ b - 1);
+	}
+}
+`, "13\n3", "13\n33\n-27\n-5\n102\n-1\n10\n"},
+		{"compound assignments", `package main;
+import "fmt";
+type P struct {
+	x int;
+	s []int;
+	next *P;
+};
+var g, calls int;
+func idx() int {
+	calls++;
+	return 1;
+}
+func main() {
+	var s int;
+	var p *P;
+	var str string;
+	for i := 0; i < 5; i++ {
+		s += i;
+	}
+	s *= 3;
+	s -= 2;
+	s /= 4;
+	s %= 5;
+	s <<= 4;
+	s |= 7;
+	s &^= 2;
+	fmt.Println(s);
+	g += 7;
+	g--;
+	p = new(P);
+	p.next = new(P);
+	p.s = make([]int, 2);
+	p.next.x += 5;
+	p.next.x++;
+	p.s[idx()] += 10;
+	p.s[idx()]++;
+	fmt.Println(g * 100 + p.next.x * 10 + p.s[1]);
+	fmt.Println(calls);
+	str += "ab";
+	str += str;
+	fmt.Println(str);
+}
+`, "", "37\n671\n2\nabab\n"},
+		{"short-circuit evaluation", `package main;
+import "fmt";
+type P struct {
+	val int;
+};
+var calls int;
+func check(v int, r bool) bool {
+	calls++;
+	fmt.Println(v);
+	return r;
+}
+func main() {
+	var p *P;
+	var b bool;
+	var i int;
+	if (p != nil && p.val > 0) {
+		fmt.Println(1);
+	}
+	b = p == nil || p.val > 0;
+	p = new(P);
+	p.val = 3;
+	for p != nil && p.val < 20 {
+		p.val *= 2;
+	}
+	b = b && (check(10, false) || check(11, true)) && !check(12, true);
+	b = b || check(13, true);
+	for i < 5 && (i != 2 || check(14, false)) {
+		i++;
+	}
+	switch {
+	case i > 2 || check(15, true):
+		fmt.Println(p.val * 10 + i);
+	}
+	fmt.Println(calls);
+}
+`, "", "10\n11\n12\n13\n14\n15\n242\n6\n"},
+	}
+	for _, tt := range tests {
+		got, err := RunSource(t, tt.source, tt.input)
+		if err != nil {
+			t.Fatalf("FAILED[%s] - %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Fatalf("FAILED[%s] - incorrect output.\nexpected=%q\ngot=%q\n", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	_, err := RunSource(t, `package main;
+import "fmt";
+func main() {
+	var a, b int;
+	a = 1;
+	b = a / b;
+	fmt.Println(b);
+}
+`, "")
+	if err == nil || !strings.Contains(err.Error(), "integer divide by zero") {
+		t.Fatalf("expected a divide by zero error, got %v", err)
+	}
+}
+
+func TestIndexOutOfRange(t *testing.T) {
+	_, err := RunSource(t, `package main;
+import "fmt";
+func main() {
+	var s []int;
+	var x int;
+	s = make([]int, 2);
+	x = s[2];
+	fmt.Println(x);
+}
+`, "")
+	if err == nil || !strings.Contains(err.Error(), "index out of range [2] with length 2") {
+		t.Fatalf("expected an index out of range error, got %v", err)
+	}
+}
+
+func TestMakeOutOfRange(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"5 2", "makeslice: cap out of range"},
+		{"-1 4", "makeslice: len out of range"},
+		{"-3 -1", "makeslice: len out of range"},
+	}
+	for _, tt := range tests {
+		_, err := RunSource(t, `package main;
+import "fmt";
+func main() {
+	var s []int;
+	var n, c int;
+	fmt.Scan(&n);
+	fmt.Scan(&c);
+	s = make([]int, n, c);
+	s[4] = 7;
+}
+`, tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("expected %q for input %q, got %v", tt.want, tt.input, err)
+		}
+	}
+}
+
+func TestNilFieldChain(t *testing.T) {
+	_, err := RunSource(t, `package main;
+import "fmt";
+type Node struct {
+	val int;
+	next *Node;
+};
+func main() {
+	var list *Node;
+	list = new(Node);
+	list.next.next.val = 3;
+	fmt.Println(list.val);
+}
+`, "")
+	if err == nil || !strings.Contains(err.Error(), "nil pointer dereference") {
+		t.Fatalf("expected a nil pointer dereference, got %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 // Add represents a ADD instruction in ILOC
@@ -24,13 +23,17 @@ func NewAdd(target int, sourceReg int, operand int, opty OperandTy) *Add {
 }
 
 func (instr *Add) GetTargets() []int {
-	targets := make([]int, 1)
+	targets := []int{}
 	targets = append(targets, instr.target)
 	return targets
 }
 func (instr *Add) GetSources() []int {
-	sources := make([]int, 1)
-	sources = append(sources, instr.sourceReg)
+	sources := []int{}
+	if instr.opty == REGISTER {
+		sources = append(sources, instr.sourceReg, instr.operand)
+	} else {
+		sources = append(sources, instr.sourceReg)
+	}
 	return sources
 }
 func (instr *Add) GetImmediate() *int {
@@ -72,47 +75,3 @@ func (instr *Add) String() string {
 	return out.String()
 
 }
-
-func (instr *Add) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-	var source1RegId int
-	var source2RegId int
-	var isParam1 bool
-	var isParam2 bool
-
-	// load operand 1
-	if source1RegId, isParam1 = paramRegIds[instr.sourceReg]; !isParam1 {
-		source1Offset := funcVarDict[instr.sourceReg]
-		source1RegId = regDepatcher.NextAvailReg()
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", source1RegId, source1Offset))
-	}
-
-	// load operand 2
-	if source2RegId, isParam2 = paramRegIds[instr.operand]; !isParam2 {
-		source2RegId = regDepatcher.NextAvailReg()
-		if instr.opty == REGISTER {
-			source2Offset := funcVarDict[instr.operand]
-			instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", source2RegId, source2Offset))
-		} else {
-			instruction = append(instruction, fmt.Sprintf("\tmov x%v,#%v", source2RegId, instr.operand))
-		}
-	}
-
-	// add
-	targetRegId := regDepatcher.NextAvailReg()
-	instruction = append(instruction, fmt.Sprintf("\tadd x%v,x%v,x%v", targetRegId, source1RegId, source2RegId))
-
-	// store result
-	targetOffset := funcVarDict[instr.target]
-	instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x29,#%v]", targetRegId, targetOffset))
-
-	regDepatcher.ReleaseReg(targetRegId)
-	if !isParam1 {
-		regDepatcher.ReleaseReg(source1RegId)
-	}
-	if !isParam2 {
-		regDepatcher.ReleaseReg(source2RegId)
-	}
-
-	return instruction
-}
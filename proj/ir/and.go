@@ -70,8 +70,3 @@ func (instr *And) String() string {
 	return out.String()
 
 }
-
-func (instr *And) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	panic("implement me")
-	return nil
-}
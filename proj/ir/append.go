@@ -0,0 +1,48 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Append creates the header of the slice source with value added at its end. The
+// elements are moved to a larger cell when the capacity of source is exhausted.
+type Append struct {
+	target int
+	source int
+	value  int
+}
+
+func NewAppend(target int, source int, value int) *Append {
+	return &Append{target, source, value}
+}
+
+func (instr *Append) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+// GetSources returns the slice followed by the appended value
+func (instr *Append) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.source, instr.value)
+	return sources
+}
+
+func (instr *Append) GetImmediate() *int { return nil }
+
+func (instr *Append) GetGlobal() string { return "" }
+
+func (instr *Append) GetLabel() string { return "" }
+
+func (instr *Append) SetLabel(newLabel string) {}
+
+func (instr *Append) String() string {
+	var out bytes.Buffer
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	sourceReg := fmt.Sprintf("r%v", instr.source)
+	valueReg := fmt.Sprintf("r%v", instr.value)
+	out.WriteString(fmt.Sprintf("append %s,%s,%s", targetReg, sourceReg, valueReg))
+	return out.String()
+}
@@ -32,9 +32,3 @@ func (instr *Bl) String() string {
 
 	return out.String()
 }
-
-func (instr *Bl) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-	instruction = append(instruction, fmt.Sprintf("\tbl %v", instr.label))
-	return instruction
-}
@@ -24,6 +24,8 @@ func (instr *Branch) GetGlobal() string { return "" }
 
 func (instr *Branch) GetLabel() string { return instr.label }
 
+func (instr *Branch) GetFlag() ApsrFlag { return instr.flagVal }
+
 func (instr *Branch) SetLabel(newLabel string) {}
 
 func (instr *Branch) String() string {
@@ -59,15 +61,3 @@ func (instr *Branch) String() string {
 
 	return out.String()
 }
-
-func (instr *Branch) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-	if instr.flagVal == NE {
-		instruction = append(instruction, fmt.Sprintf("\tb.ne %v", instr.label))
-	} else if instr.flagVal == EQ {
-		instruction = append(instruction, fmt.Sprintf("\tb.eq %v", instr.label))
-	} else {
-		instruction = append(instruction, fmt.Sprintf("\tb %v", instr.label))
-	}
-	return instruction
-}
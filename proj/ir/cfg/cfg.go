@@ -0,0 +1,284 @@
+package cfg
+
+import (
+	"proj/ir"
+	st "proj/symboltable"
+	"sort"
+)
+
+// Function is the control flow graph of one function
+type Function struct {
+	Name   string
+	Frags  []*ir.FuncFrag // Fragment of the function followed by its control flow fragments
+	Blocks []*Block       // Basic blocks in layout order, the first one is the entry
+	Loops  []*Loop        // Outermost loops of the function
+}
+
+// Block is a basic block, only its last instruction may branch
+type Block struct {
+	Index  int    // Position of the block in the layout of the function
+	Label  string // Label of the fragment starting at this block, empty when a branch split the fragment
+	Instrs []ir.Instruction
+	Succs  []*Block
+	Preds  []*Block
+	Idom   *Block // Immediate dominator, nil for the entry and for unreachable blocks
+	Loop   *Loop  // Innermost loop containing the block, nil outside of loops
+}
+
+// Loop is a natural loop, the blocks reaching a back edge to its header without going through the header
+type Loop struct {
+	Header   *Block
+	Blocks   []*Block // Blocks of the loop and of its inner loops, in layout order
+	Parent   *Loop
+	Children []*Loop
+	Depth    int // 1 for an outermost loop
+}
+
+func GroupByFunction(funcfrags []*ir.FuncFrag, symTable *st.SymbolTable) [][]*ir.FuncFrag {
+	//A fragment labelled with a function name starts that function, the fragments after it belong to it
+	functions := [][]*ir.FuncFrag{}
+	for _, frag := range funcfrags {
+		if frag.Label == ir.GlobalFragLabel {
+			continue
+		}
+		if _, isFunction := symTable.ContainFunction(frag.Label); isFunction || len(functions) == 0 {
+			functions = append(functions, []*ir.FuncFrag{})
+		}
+		functions[len(functions)-1] = append(functions[len(functions)-1], frag)
+	}
+	return functions
+}
+
+func BuildAll(funcfrags []*ir.FuncFrag, symTable *st.SymbolTable) []*Function {
+	functions := []*Function{}
+	for _, frags := range GroupByFunction(funcfrags, symTable) {
+		functions = append(functions, Build(frags))
+	}
+	return functions
+}
+
+func Build(frags []*ir.FuncFrag) *Function {
+	/*
+		Split the fragments of a function into basic blocks: a block starts at
+		each fragment and after each branch or return. Execution falls through
+		from a block into the next one unless it ends with an unconditional
+		branch or a return.
+	*/
+	function := &Function{frags[0].Label, frags, []*Block{}, []*Loop{}}
+	labels := make(map[string]*Block)
+	newBlock := func(label string) *Block {
+		block := &Block{Index: len(function.Blocks), Label: label, Instrs: []ir.Instruction{}}
+		function.Blocks = append(function.Blocks, block)
+		return block
+	}
+	for _, frag := range frags {
+		block := newBlock(frag.Label)
+		labels[frag.Label] = block
+		for _, instruction := range frag.Body {
+			if instruction == nil {
+				continue
+			}
+			if block == nil {
+				block = newBlock("")
+			}
+			block.Instrs = append(block.Instrs, instruction)
+			if endsBlock(instruction) {
+				block = nil
+			}
+		}
+	}
+
+	for idx, block := range function.Blocks {
+		var next *Block
+		if idx+1 < len(function.Blocks) {
+			next = function.Blocks[idx+1]
+		}
+		if len(block.Instrs) == 0 {
+			addEdge(block, next)
+			continue
+		}
+		switch instr := block.Instrs[len(block.Instrs)-1].(type) {
+		case *ir.Ret:
+		case *ir.Branch:
+			addEdge(block, labels[instr.GetLabel()])
+			if instr.GetFlag() != ir.AL {
+				addEdge(block, next)
+			}
+		case *ir.JumpTable:
+			for _, label := range instr.Successors() {
+				addEdge(block, labels[label])
+			}
+		default:
+			addEdge(block, next)
+		}
+	}
+
+	function.computeDominators()
+	function.findLoops()
+	return function
+}
+
+func endsBlock(instruction ir.Instruction) bool {
+	switch instruction.(type) {
+	case *ir.Branch, *ir.JumpTable, *ir.Ret:
+		return true
+	}
+	return false
+}
+
+func addEdge(from *Block, to *Block) {
+	if to == nil {
+		return
+	}
+	for _, succ := range from.Succs {
+		if succ == to {
+			return
+		}
+	}
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+func (function *Function) Entry() *Block {
+	return function.Blocks[0]
+}
+
+func (function *Function) ReversePostorder() []*Block {
+	//Blocks reachable from the entry, each one before its successors except along back edges
+	visited := make(map[*Block]bool)
+	postorder := []*Block{}
+	var visit func(block *Block)
+	visit = func(block *Block) {
+		visited[block] = true
+		for _, succ := range block.Succs {
+			if !visited[succ] {
+				visit(succ)
+			}
+		}
+		postorder = append(postorder, block)
+	}
+	visit(function.Entry())
+	order := []*Block{}
+	for idx := len(postorder) - 1; idx >= 0; idx-- {
+		order = append(order, postorder[idx])
+	}
+	return order
+}
+
+func (function *Function) computeDominators() {
+	//Iterative algorithm of Cooper, Harvey and Kennedy over the reverse postorder
+	order := function.ReversePostorder()
+	rank := make(map[*Block]int)
+	for idx, block := range order {
+		rank[block] = idx
+	}
+	entry := function.Entry()
+	idom := map[*Block]*Block{entry: entry}
+	intersect := func(a *Block, b *Block) *Block {
+		for a != b {
+			for rank[a] > rank[b] {
+				a = idom[a]
+			}
+			for rank[b] > rank[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, block := range order[1:] {
+			var newIdom *Block
+			for _, pred := range block.Preds {
+				if _, done := idom[pred]; !done {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = pred
+				} else {
+					newIdom = intersect(pred, newIdom)
+				}
+			}
+			if idom[block] != newIdom {
+				idom[block] = newIdom
+				changed = true
+			}
+		}
+	}
+	for _, block := range order[1:] {
+		block.Idom = idom[block]
+	}
+}
+
+func (block *Block) Dominates(other *Block) bool {
+	//Whether every path from the entry to other goes through block
+	for other != nil {
+		if other == block {
+			return true
+		}
+		other = other.Idom
+	}
+	return false
+}
+
+func (function *Function) findLoops() {
+	/*
+		A back edge goes to a block dominating its source. The natural loop of a
+		header gathers the blocks reaching one of its back edges without going
+		through the header. Loops are nested by inclusion.
+	*/
+	loops := make(map[*Block]map[*Block]bool)
+	headers := []*Block{}
+	for _, block := range function.Blocks {
+		for _, succ := range block.Succs {
+			if !succ.Dominates(block) {
+				continue
+			}
+			body, exist := loops[succ]
+			if !exist {
+				body = map[*Block]bool{succ: true}
+				loops[succ] = body
+				headers = append(headers, succ)
+			}
+			worklist := []*Block{block}
+			for len(worklist) > 0 {
+				current := worklist[len(worklist)-1]
+				worklist = worklist[:len(worklist)-1]
+				if body[current] {
+					continue
+				}
+				body[current] = true
+				worklist = append(worklist, current.Preds...)
+			}
+		}
+	}
+
+	// visit the loops from the largest, so a loop is nested in the smallest one found before it
+	sort.SliceStable(headers, func(i, j int) bool {
+		return len(loops[headers[i]]) > len(loops[headers[j]])
+	})
+	all := []*Loop{}
+	for _, header := range headers {
+		loop := &Loop{Header: header, Blocks: []*Block{}, Children: []*Loop{}, Depth: 1}
+		for _, block := range function.Blocks {
+			if loops[header][block] {
+				loop.Blocks = append(loop.Blocks, block)
+			}
+		}
+		for idx := len(all) - 1; idx >= 0; idx-- {
+			if loops[all[idx].Header][header] {
+				loop.Parent = all[idx]
+				loop.Depth = all[idx].Depth + 1
+				all[idx].Children = append(all[idx].Children, loop)
+				break
+			}
+		}
+		if loop.Parent == nil {
+			function.Loops = append(function.Loops, loop)
+		}
+		for _, block := range loop.Blocks {
+			block.Loop = loop
+		}
+		all = append(all, loop)
+	}
+}
@@ -0,0 +1,89 @@
+package cfg
+
+import (
+	"proj/internal/golitetest"
+	"proj/ir"
+	"testing"
+)
+
+func BuildSource(t *testing.T, source string) []*Function {
+	//Compile the source down to iloc and build the graph of every function
+	program := golitetest.Compile(t, source)
+	return BuildAll(ir.ControlFlowFrags, program.GlobalSymbolTable)
+}
+
+func TestBuild(t *testing.T) {
+	functions := BuildSource(t, `package main;
+import "fmt";
+var g int;
+func sign(a int) int {
+	if (a < 0) {
+		return 0 - 1;
+	}
+	return 1;
+}
+func main() {
+	var i, j int;
+	i = 0;
+	for (i < 3) {
+		j = 0;
+		for (j < i) {
+			if (j == 1) {
+				fmt.Println(j);
+			}
+			j = j + 1;
+		}
+		i = i + 1;
+	}
+	g = sign(i);
+}
+`)
+	if len(functions) != 2 || functions[0].Name != "sign" || functions[1].Name != "main" {
+		t.Fatalf("expected the functions sign and main, got %d functions", len(functions))
+	}
+
+	sign := functions[0]
+	if len(sign.Loops) != 0 {
+		t.Fatalf("sign: expected no loop, got %d", len(sign.Loops))
+	}
+	for _, block := range sign.Blocks {
+		for _, pred := range block.Preds {
+			if !contains(pred.Succs, block) {
+				t.Fatalf("sign: edge %d -> %d only recorded as a predecessor", pred.Index, block.Index)
+			}
+		}
+		if block != sign.Entry() && block.Idom != nil && !sign.Entry().Dominates(block) {
+			t.Fatalf("sign: the entry does not dominate block %d", block.Index)
+		}
+	}
+
+	main := functions[1]
+	if len(main.Loops) != 1 {
+		t.Fatalf("main: expected one outermost loop, got %d", len(main.Loops))
+	}
+	outer := main.Loops[0]
+	if len(outer.Children) != 1 || outer.Children[0].Depth != 2 || outer.Children[0].Parent != outer {
+		t.Fatalf("main: expected one loop nested in the outer loop")
+	}
+	inner := outer.Children[0]
+	for _, block := range inner.Blocks {
+		if !contains(outer.Blocks, block) {
+			t.Fatalf("main: block %d of the inner loop is not in the outer loop", block.Index)
+		}
+		if !outer.Header.Dominates(block) || !inner.Header.Dominates(block) {
+			t.Fatalf("main: block %d is not dominated by the headers of its loops", block.Index)
+		}
+	}
+	if main.Entry().Loop != nil || inner.Header.Loop != inner {
+		t.Fatalf("main: wrong innermost loop of the blocks")
+	}
+}
+
+func contains(blocks []*Block, target *Block) bool {
+	for _, block := range blocks {
+		if block == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CheckBounds aborts the program unless 0 <= index < operand
+type CheckBounds struct {
+	index   int
+	operand int       // The length, either register or constant
+	opty    OperandTy // The type for the operand (REGISTER, IMMEDIATE)
+}
+
+func NewCheckBounds(index int, operand int, opty OperandTy) *CheckBounds {
+	return &CheckBounds{index, operand, opty}
+}
+
+func (instr *CheckBounds) GetTargets() []int { return []int{} }
+
+func (instr *CheckBounds) GetSources() []int {
+	sources := []int{}
+	if instr.opty == REGISTER {
+		sources = append(sources, instr.index, instr.operand)
+	} else {
+		sources = append(sources, instr.index)
+	}
+	return sources
+}
+
+func (instr *CheckBounds) GetImmediate() *int {
+	if instr.opty == IMMEDIATE {
+		return &instr.operand
+	}
+	return nil
+}
+
+func (instr *CheckBounds) GetGlobal() string { return "" }
+
+func (instr *CheckBounds) GetLabel() string { return "" }
+
+func (instr *CheckBounds) SetLabel(newLabel string) {}
+
+func (instr *CheckBounds) String() string {
+	var out bytes.Buffer
+	indexReg := fmt.Sprintf("r%v", instr.index)
+	prefix := "r"
+	if instr.opty == IMMEDIATE {
+		prefix = "#"
+	}
+	out.WriteString(fmt.Sprintf("checkBounds %s,%s%v", indexReg, prefix, instr.operand))
+	return out.String()
+}
@@ -0,0 +1,40 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CheckMake aborts the program unless 0 <= length <= capacity
+type CheckMake struct {
+	length   int
+	capacity int
+}
+
+func NewCheckMake(length int, capacity int) *CheckMake {
+	return &CheckMake{length, capacity}
+}
+
+func (instr *CheckMake) GetTargets() []int { return []int{} }
+
+func (instr *CheckMake) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.length, instr.capacity)
+	return sources
+}
+
+func (instr *CheckMake) GetImmediate() *int { return nil }
+
+func (instr *CheckMake) GetGlobal() string { return "" }
+
+func (instr *CheckMake) GetLabel() string { return "" }
+
+func (instr *CheckMake) SetLabel(newLabel string) {}
+
+func (instr *CheckMake) String() string {
+	var out bytes.Buffer
+	lengthReg := fmt.Sprintf("r%v", instr.length)
+	capacityReg := fmt.Sprintf("r%v", instr.capacity)
+	out.WriteString(fmt.Sprintf("checkMake %s,%s", lengthReg, capacityReg))
+	return out.String()
+}
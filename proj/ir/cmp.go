@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Cmp struct {
@@ -63,41 +62,3 @@ func (instr *Cmp) String() string {
 
 	return out.String()
 }
-
-func (instr *Cmp) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	var operand1Reg, operand2Reg int
-	var isOperand1Param, isOperand2Param bool
-
-	// get operand 1
-	if operand1Reg, isOperand1Param = paramRegIds[instr.sourceReg]; !isOperand1Param {
-		operand1Reg = regDepatcher.NextAvailReg()
-		operand1Offset := funcVarDict[instr.sourceReg]
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", operand1Reg, operand1Offset))
-	}
-
-	// get operand 2
-	if operand2Reg, isOperand2Param = paramRegIds[instr.operand]; !isOperand2Param {
-		operand2Reg = regDepatcher.NextAvailReg()
-		if instr.opty == REGISTER {
-			operand2Offset := funcVarDict[instr.operand]
-			instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", operand2Reg, operand2Offset))
-		} else {
-			instruction = append(instruction, fmt.Sprintf("\tmov x%v,#%v", operand2Reg, instr.operand))
-		}
-	}
-
-	// compare
-	instruction = append(instruction, fmt.Sprintf("\tcmp x%v,x%v", operand1Reg, operand2Reg))
-
-	// release registers
-	if !isOperand1Param {
-		regDepatcher.ReleaseReg(operand1Reg)
-	}
-	if !isOperand2Param {
-		regDepatcher.ReleaseReg(operand2Reg)
-	}
-
-	return instruction
-}
@@ -0,0 +1,46 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Concat creates a new string holding the bytes of left followed by the bytes of right
+type Concat struct {
+	target int
+	left   int
+	right  int
+}
+
+func NewConcat(target int, left int, right int) *Concat {
+	return &Concat{target, left, right}
+}
+
+func (instr *Concat) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+func (instr *Concat) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.left, instr.right)
+	return sources
+}
+
+func (instr *Concat) GetImmediate() *int { return nil }
+
+func (instr *Concat) GetGlobal() string { return "" }
+
+func (instr *Concat) GetLabel() string { return "" }
+
+func (instr *Concat) SetLabel(newLabel string) {}
+
+func (instr *Concat) String() string {
+	var out bytes.Buffer
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	leftReg := fmt.Sprintf("r%v", instr.left)
+	rightReg := fmt.Sprintf("r%v", instr.right)
+	out.WriteString(fmt.Sprintf("concat %s,%s,%s", targetReg, leftReg, rightReg))
+	return out.String()
+}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Delete struct {
@@ -36,16 +35,3 @@ func (instr *Delete) String() string {
 	out.WriteString(fmt.Sprintf("delete %s", sourceRegister))
 	return out.String()
 }
-
-func (instr *Delete) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	targetRegId := regDepatcher.NextAvailReg()
-	delOffset := funcVarDict[instr.sourceReg]
-	instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", targetRegId, delOffset))
-	instruction = append(instruction, fmt.Sprintf("\tmov x0,x%v", targetRegId))
-	instruction = append(instruction, fmt.Sprintf("\tbl free"))
-	regDepatcher.ReleaseReg(targetRegId)
-
-	return instruction
-}
@@ -1,7 +1,5 @@
 package ir
 
-// "proj/codegen"
-
 type OperandTy int
 
 const (
@@ -39,12 +37,21 @@ type Instruction interface {
 	SetLabel(newLabel string) //Set the label for this instruction
 
 	String() string // Return a string representation of this instruction
-
-	ToAssembly(map[int]int, map[int]int) []string
 }
 
+// GlobalFragLabel labels the fragment initializing the global variables
+const GlobalFragLabel = "Global Variable"
+
 type FuncFrag struct {
 	Label string        // Function name
 	Body  []Instruction // Function body of ILOC instructions
-	// Frame *codegen.Frame // Activation Records (i.e., stack frame) for this function
 }
+
+// A slice is the address of a header of SliceHeaderSize words: its length, its
+// capacity and the address of the cell holding its elements. The nil slice is 0.
+const (
+	SliceLen = iota
+	SliceCap
+	SliceData
+	SliceHeaderSize
+)
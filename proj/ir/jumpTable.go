@@ -0,0 +1,63 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// JumpTable branches to the label of the value of the operand, or to the default label when it has none
+type JumpTable struct {
+	operand      int
+	low          int      // The value of the first label
+	labels       []string // The label of each value from low on
+	defaultLabel string
+}
+
+func NewJumpTable(operand int, low int, labels []string, defaultLabel string) *JumpTable {
+	return &JumpTable{operand, low, labels, defaultLabel}
+}
+
+func (instr *JumpTable) GetTargets() []int { return []int{} }
+
+func (instr *JumpTable) GetSources() []int { return []int{instr.operand} }
+
+func (instr *JumpTable) GetImmediate() *int { return nil }
+
+func (instr *JumpTable) GetGlobal() string { return "" }
+
+func (instr *JumpTable) GetLabel() string { return instr.defaultLabel }
+
+func (instr *JumpTable) GetLow() int { return instr.low }
+
+func (instr *JumpTable) GetLabels() []string { return instr.labels }
+
+func (instr *JumpTable) Target(value int) string {
+	//Label the table branches to for the given value
+	if value < instr.low || value-instr.low >= len(instr.labels) {
+		return instr.defaultLabel
+	}
+	return instr.labels[value-instr.low]
+}
+
+func (instr *JumpTable) Successors() []string {
+	//Every label the table may branch to, without repetitions
+	seen := map[string]bool{instr.defaultLabel: true}
+	succs := []string{instr.defaultLabel}
+	for _, label := range instr.labels {
+		if !seen[label] {
+			seen[label] = true
+			succs = append(succs, label)
+		}
+	}
+	return succs
+}
+
+func (instr *JumpTable) SetLabel(newLabel string) {}
+
+func (instr *JumpTable) String() string {
+	var out bytes.Buffer
+	operand := fmt.Sprintf("r%v", instr.operand)
+	out.WriteString(fmt.Sprintf("jumpTable %s,#%v,[%s],%s", operand, instr.low, strings.Join(instr.labels, ","), instr.defaultLabel))
+	return out.String()
+}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Ldr struct {
@@ -77,18 +76,3 @@ func (instr *Ldr) String() string {
 
 	return out.String()
 }
-
-func (instr *Ldr) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-	if instr.opty == GLOBALVAR {
-		addrRegId := regDepatcher.NextAvailReg()
-		instruction = append(instruction, fmt.Sprintf("\tadrp x%v,%v", addrRegId, instr.globalVar))
-		instruction = append(instruction, fmt.Sprintf("\tadd x%v,x%v, :lo12:%v", addrRegId, addrRegId, instr.globalVar))
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x%v]", addrRegId, addrRegId))
-		targetOffset := funcVarDict[instr.target]
-		instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x29,#%v]", addrRegId, targetOffset))
-		regDepatcher.ReleaseReg(addrRegId)
-	}
-
-	return instruction
-}
@@ -0,0 +1,44 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Len loads the length of the slice at source, which is 0 for the nil slice
+type Len struct {
+	target int
+	source int
+}
+
+func NewLen(target int, source int) *Len {
+	return &Len{target, source}
+}
+
+func (instr *Len) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+func (instr *Len) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.source)
+	return sources
+}
+
+func (instr *Len) GetImmediate() *int { return nil }
+
+func (instr *Len) GetGlobal() string { return "" }
+
+func (instr *Len) GetLabel() string { return "" }
+
+func (instr *Len) SetLabel(newLabel string) {}
+
+func (instr *Len) String() string {
+	var out bytes.Buffer
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	sourceReg := fmt.Sprintf("r%v", instr.source)
+	out.WriteString(fmt.Sprintf("len %s,%s", targetReg, sourceReg))
+	return out.String()
+}
@@ -0,0 +1,50 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// LoadIndex loads the index-th word of the heap cell at source
+type LoadIndex struct {
+	target int
+	source int
+	index  int
+}
+
+func NewLoadIndex(target int, source int, index int) *LoadIndex {
+	return &LoadIndex{target, source, index}
+}
+
+func (instr *LoadIndex) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+// GetSources returns the address of the cell followed by the index
+func (instr *LoadIndex) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.source, instr.index)
+	return sources
+}
+
+func (instr *LoadIndex) GetImmediate() *int { return nil }
+
+func (instr *LoadIndex) GetGlobal() string { return "" }
+
+func (instr *LoadIndex) GetLabel() string { return "" }
+
+func (instr *LoadIndex) SetLabel(newLabel string) {}
+
+func (instr *LoadIndex) String() string {
+	var out bytes.Buffer
+
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	sourceReg := fmt.Sprintf("r%v", instr.source)
+	indexReg := fmt.Sprintf("r%v", instr.index)
+
+	out.WriteString(fmt.Sprintf("loadIdx %s,%s[%s]", targetReg, sourceReg, indexReg))
+
+	return out.String()
+}
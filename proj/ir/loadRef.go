@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 // to access fields of a struct
@@ -39,6 +38,8 @@ func (instr *LoadRef) GetGlobal() string {
 
 func (instr *LoadRef) GetLabel() string { return "" }
 
+func (instr *LoadRef) GetFieldIndex() int { return instr.offset }
+
 func (instr *LoadRef) SetLabel(newLabel string) {}
 
 func (instr *LoadRef) String() string {
@@ -52,22 +53,3 @@ func (instr *LoadRef) String() string {
 
 	return out.String()
 }
-
-func (instr *LoadRef) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	loadToRegId := regDepatcher.NextAvailReg()
-	loadToOffset := funcVarDict[instr.target]
-	structRegId := regDepatcher.NextAvailReg()
-	structOffset := funcVarDict[instr.source]
-	fieldOffset := instr.offset * 8
-
-	instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", structRegId, structOffset))
-	instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x%v,#%v]", loadToRegId, structRegId, fieldOffset))
-	instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x29,#%v]", loadToRegId, loadToOffset))
-
-	regDepatcher.ReleaseReg(loadToRegId)
-	regDepatcher.ReleaseReg(structRegId)
-
-	return instruction
-}
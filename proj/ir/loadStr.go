@@ -0,0 +1,42 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// LoadStr loads the address of a string constant, the backends place its bytes in read-only data
+type LoadStr struct {
+	target int
+	value  string
+}
+
+func NewLoadStr(target int, value string) *LoadStr {
+	return &LoadStr{target, value}
+}
+
+func (instr *LoadStr) GetValue() string { return instr.value }
+
+func (instr *LoadStr) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+func (instr *LoadStr) GetSources() []int { return []int{} }
+
+func (instr *LoadStr) GetImmediate() *int { return nil }
+
+func (instr *LoadStr) GetGlobal() string { return "" }
+
+func (instr *LoadStr) GetLabel() string { return "" }
+
+func (instr *LoadStr) SetLabel(newLabel string) {}
+
+func (instr *LoadStr) String() string {
+	var out bytes.Buffer
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	out.WriteString(fmt.Sprintf("loadStr %s,%s", targetReg, strconv.Quote(instr.value)))
+	return out.String()
+}
@@ -0,0 +1,53 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type Mod struct {
+	target     int // The target register for the instruction
+	sourceReg1 int // The first source register of the instruction
+	sourceReg2 int // The second source register of the instruction
+}
+
+func NewMod(target int, sourceReg1 int, sourceReg2 int) *Mod {
+	return &Mod{target, sourceReg1, sourceReg2}
+}
+
+func (instr *Mod) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+func (instr *Mod) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.sourceReg1, instr.sourceReg2)
+	return sources
+}
+func (instr *Mod) GetImmediate() *int {
+
+	//Return nil if this instruction does not have an immediate
+	return nil
+}
+func (instr *Mod) GetGlobal() string {
+	return ""
+}
+func (instr *Mod) GetLabel() string {
+	return ""
+}
+func (instr *Mod) SetLabel(newLabel string) {}
+
+func (instr *Mod) String() string {
+
+	var out bytes.Buffer
+
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	sourceReg1 := fmt.Sprintf("r%v", instr.sourceReg1)
+	sourceReg2 := fmt.Sprintf("r%v", instr.sourceReg2)
+
+	out.WriteString(fmt.Sprintf("mod %s,%s,%s", targetReg, sourceReg1, sourceReg2))
+
+	return out.String()
+
+}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Mul struct {
@@ -52,42 +51,3 @@ func (instr *Mul) String() string {
 	return out.String()
 
 }
-
-func (instr *Mul) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	var source1RedId, source2RedId int
-	var isParam1, isParam2 bool
-
-	// load operand 1
-	if source1RedId, isParam1 = paramRegIds[instr.sourceReg1]; !isParam1 {
-		source1Offset := funcVarDict[instr.sourceReg1]
-		source1RedId = regDepatcher.NextAvailReg()
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", source1RedId, source1Offset))
-	}
-
-	// load operand 2
-	if source2RedId, isParam2 = paramRegIds[instr.sourceReg2]; !isParam2 {
-		source2Offset := funcVarDict[instr.sourceReg2]
-		source2RedId = regDepatcher.NextAvailReg()
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", source2RedId, source2Offset))
-	}
-
-	// multiply
-	targetRegId := regDepatcher.NextAvailReg()
-	instruction = append(instruction, fmt.Sprintf("\tmul x%v,x%v,x%v", targetRegId, source1RedId, source2RedId))
-
-	// store result
-	targetOffset := funcVarDict[instr.target]
-	instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x29,#%v]", targetRegId, targetOffset))
-
-	if !isParam1 {
-		regDepatcher.ReleaseReg(source1RedId)
-	}
-	if !isParam2 {
-		regDepatcher.ReleaseReg(source2RedId)
-	}
-	regDepatcher.ReleaseReg(targetRegId)
-
-	return instruction
-}
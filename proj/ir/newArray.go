@@ -0,0 +1,54 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// NewArray allocates a zeroed heap cell of operand words, the elements of an array or a slice
+type NewArray struct {
+	target  int
+	operand int       // The number of elements, either register or constant
+	opty    OperandTy // The type for the operand (REGISTER, IMMEDIATE)
+}
+
+func GetNewArrayInst(target int, operand int, opty OperandTy) *NewArray {
+	return &NewArray{target, operand, opty}
+}
+
+func (instr *NewArray) GetTargets() []int {
+	target := []int{}
+	target = append(target, instr.target)
+	return target
+}
+
+func (instr *NewArray) GetSources() []int {
+	if instr.opty == IMMEDIATE {
+		return []int{}
+	}
+	return []int{instr.operand}
+}
+
+func (instr *NewArray) GetImmediate() *int {
+	if instr.opty == IMMEDIATE {
+		return &instr.operand
+	}
+	return nil
+}
+
+func (instr *NewArray) GetGlobal() string { return "" }
+
+func (instr *NewArray) GetLabel() string { return "" }
+
+func (instr *NewArray) SetLabel(newLabel string) {}
+
+func (instr *NewArray) String() string {
+	var out bytes.Buffer
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	prefix := "r"
+	if instr.opty == IMMEDIATE {
+		prefix = "#"
+	}
+	out.WriteString(fmt.Sprintf("newArray %s,%s%v", targetReg, prefix, instr.operand))
+	return out.String()
+}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Not struct {
@@ -71,32 +70,3 @@ func (instr *Not) String() string {
 	return out.String()
 
 }
-
-func (instr *Not) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	// load operand
-	sourceRegId := regDepatcher.NextAvailReg()
-	if instr.opty == REGISTER {
-		source2Offset := funcVarDict[instr.operand]
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", sourceRegId, source2Offset))
-	} else {
-		instruction = append(instruction, fmt.Sprintf("\tmov x%v,#%v", sourceRegId, instr.operand))
-	}
-
-	targetRegId := regDepatcher.NextAvailReg()
-	//instruction = append(instruction, fmt.Sprintf("neg x%v, x%v", targetRegId, sourceRegId))
-	tempRedId := regDepatcher.NextAvailReg()
-	instruction = append(instruction, fmt.Sprintf("\tmov x%v,#1", tempRedId))
-	instruction = append(instruction, fmt.Sprintf("\tsubs x%v,x%v,x%v", targetRegId, tempRedId, targetRegId))
-	regDepatcher.ReleaseReg(tempRedId)
-
-	// store result
-	targetOffset := funcVarDict[instr.target]
-	instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x29,#%v]", targetRegId, targetOffset))
-
-	regDepatcher.ReleaseReg(sourceRegId)
-	regDepatcher.ReleaseReg(targetRegId)
-
-	return instruction
-}
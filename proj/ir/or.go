@@ -71,9 +71,3 @@ func (instr *Or) String() string {
 	return out.String()
 
 }
-
-func (instr *Or) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	panic("implement me")
-	instruction := []string{}
-	return instruction
-}
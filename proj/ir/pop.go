@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 	"strconv"
 )
 
@@ -49,25 +48,3 @@ func (instr *Pop) String() string {
 
 	return out.String()
 }
-
-func (instr *Pop) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	instruction = append(instruction, fmt.Sprintf("\tldr x0,[x29,#24]"))
-	offset := len(instr.sourceReg) * 8
-	if offset%16 != 0 {
-		offset += 8
-	}
-
-	instruction = append(instruction, fmt.Sprintf("\tadd sp,sp,#%v", offset))
-
-	iteration := 8
-	if len(instr.sourceReg) <= 8 {
-		iteration = len(instr.sourceReg)
-	}
-
-	for i := 1; i < iteration; i++ {
-		regDepatcher.ReleaseReg(i)
-	}
-	return instruction
-}
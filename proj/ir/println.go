@@ -3,17 +3,19 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Println struct {
 	sourceReg int
+	isString  bool // Whether sourceReg holds a string rather than an int
 }
 
-func NewPrintln(sourceReg int) *Println {
-	return &Println{sourceReg}
+func NewPrintln(sourceReg int, isString bool) *Println {
+	return &Println{sourceReg, isString}
 }
 
+func (instr *Println) IsString() bool { return instr.isString }
+
 func (instr *Println) GetTargets() []int { return []int{} }
 
 func (instr *Println) GetSources() []int {
@@ -33,32 +35,10 @@ func (instr *Println) SetLabel(newLabel string) {}
 func (instr *Println) String() string {
 	var out bytes.Buffer
 	sourceRegister := fmt.Sprintf("r%v", instr.sourceReg)
-	out.WriteString(fmt.Sprintf("println %s", sourceRegister))
-	return out.String()
-}
-
-func (instr *Println) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	regDepatcher.SetPrintln()
-	instruction := []string{}
-
-	var targetRegId int
-	var isTargetParam bool
-	if targetRegId, isTargetParam = paramRegIds[instr.sourceReg]; !isTargetParam {
-		targetRegId = regDepatcher.NextAvailReg()
-		targetOffset := funcVarDict[instr.sourceReg]
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", targetRegId, targetOffset))
+	if instr.isString {
+		out.WriteString(fmt.Sprintf("printlnStr %s", sourceRegister))
+	} else {
+		out.WriteString(fmt.Sprintf("println %s", sourceRegister))
 	}
-
-	sourceRegId := regDepatcher.NextAvailReg()
-	instruction = append(instruction, fmt.Sprintf("\tadrp x%v, .PRINT_LN", sourceRegId))
-	instruction = append(instruction, fmt.Sprintf("\tadd x%v,x%v, :lo12:.PRINT_LN", sourceRegId, sourceRegId))
-	instruction = append(instruction, fmt.Sprintf("\tmov x1,x%v", targetRegId))
-	instruction = append(instruction, fmt.Sprintf("\tmov x0,x%v", sourceRegId))
-	instruction = append(instruction, "\tbl printf")
-
-	if !isTargetParam {
-		regDepatcher.ReleaseReg(targetRegId)
-	}
-	regDepatcher.ReleaseReg(sourceRegId)
-	return instruction
+	return out.String()
 }
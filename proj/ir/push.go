@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 	"strconv"
 )
 
@@ -49,29 +48,3 @@ func (instr *Push) String() string {
 
 	return out.String()
 }
-
-func (instr *Push) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	instruction = append(instruction, fmt.Sprintf("\tstr x0,[x29,#24]"))
-	offset := len(instr.sourceReg) * 8
-	if offset%16 != 0 {
-		offset += 8
-	}
-	instruction = append(instruction, fmt.Sprintf("\tsub sp,sp,#%v", offset))
-
-	iteration := 8
-	if len(instr.sourceReg) <= 8 {
-		iteration = len(instr.sourceReg)
-	}
-
-	for i := 0; i < iteration; i++ {
-		aggRegId := regDepatcher.NextAvailReg()
-		argOffset := funcVarDict[instr.sourceReg[i]]
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", aggRegId, argOffset))
-		instruction = append(instruction, fmt.Sprintf("\tmov x%v,x%v", i, aggRegId))
-		regDepatcher.ReleaseReg(aggRegId)
-		regDepatcher.OccupyReg(i)
-	}
-	return instruction
-}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Read struct {
@@ -38,25 +37,3 @@ func (instr *Read) String() string {
 	out.WriteString(fmt.Sprintf("read %s", targetRegister))
 	return out.String()
 }
-
-func (instr *Read) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	regDepatcher.SetScan()
-	instruction := []string{}
-
-	varTargetRegId := regDepatcher.NextAvailReg()
-	varTargetOffset := funcVarDict[instr.targetReg]
-	instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", varTargetRegId, varTargetOffset))
-
-	sourceReg := regDepatcher.NextAvailReg()
-	instruction = append(instruction, fmt.Sprintf("\tadrp x%v, .READ", sourceReg))
-	instruction = append(instruction, fmt.Sprintf("\tadd x%v,x%v,:lo12:.READ", sourceReg, sourceReg))
-
-	instruction = append(instruction, fmt.Sprintf("\tadd x%v,x29,#%v", varTargetRegId, varTargetOffset))
-	instruction = append(instruction, fmt.Sprintf("\tmov x0,x%v", sourceReg))
-	instruction = append(instruction, "\tbl scanf")
-
-	regDepatcher.ReleaseReg(varTargetRegId)
-	regDepatcher.ReleaseReg(sourceReg)
-
-	return instruction
-}
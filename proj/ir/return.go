@@ -3,16 +3,21 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Ret struct {
 	operand int
 	opty    OperandTy
+	rest    []int // Registers of the results after the first one
 }
 
 func NewRet(operand int, opty OperandTy) *Ret {
-	return &Ret{operand, opty}
+	return &Ret{operand, opty, nil}
+}
+
+// NewRetValues returns the values of the registers in order, in x0, x1...
+func NewRetValues(sources []int) *Ret {
+	return &Ret{sources[0], REGISTER, sources[1:]}
 }
 
 func (instr *Ret) GetTargets() []int {
@@ -26,6 +31,7 @@ func (instr *Ret) GetSources() []int {
 	sources := []int{}
 	if instr.opty == REGISTER {
 		sources = append(sources, instr.operand)
+		sources = append(sources, instr.rest...)
 	}
 	return sources
 }
@@ -60,6 +66,9 @@ func (instr *Ret) String() string {
 			sourceReg = fmt.Sprintf("#%v", instr.operand)
 		} else { // REGISTER
 			sourceReg = fmt.Sprintf("r%v", instr.operand)
+			for _, reg := range instr.rest {
+				sourceReg += fmt.Sprintf(",r%v", reg)
+			}
 		}
 
 		out.WriteString(fmt.Sprintf("ret %v", sourceReg))
@@ -68,27 +77,3 @@ func (instr *Ret) String() string {
 	return out.String()
 
 }
-
-func (instr *Ret) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	var retRegId int
-	var isParam bool
-
-	if instr.opty == REGISTER {
-		if retRegId, isParam = paramRegIds[instr.operand]; !isParam {
-			operandOffset := funcVarDict[instr.operand]
-			retRegId = regDepatcher.NextAvailReg()
-			instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", retRegId, operandOffset))
-		}
-		instruction = append(instruction, fmt.Sprintf("\tmov x0,x%v", retRegId))
-	} else if instr.opty == IMMEDIATE {
-		instruction = append(instruction, fmt.Sprintf("\tmov x0,%v", instr.operand))
-	}
-
-	if !isParam {
-		regDepatcher.ReleaseReg(retRegId)
-	}
-
-	return instruction
-}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 type Str struct {
@@ -19,13 +18,13 @@ func NewStr(target int, sourceReg int, operand int, globalVar string, opty Opera
 }
 
 func (instr *Str) GetTargets() []int {
-	targets := []int{}
-	targets = append(targets, instr.target)
-	return targets
+	// The "target" of a store is the register holding the stored value
+	return []int{}
 }
 
 func (instr *Str) GetSources() []int {
 	sources := []int{}
+	sources = append(sources, instr.target)
 	if instr.opty == REGISTER {
 		sources = append(sources, instr.sourceReg, instr.operand)
 	} else if instr.opty == IMMEDIATE || instr.opty == ONEOPERAND {
@@ -77,21 +76,3 @@ func (instr *Str) String() string {
 
 	return out.String()
 }
-
-func (instr *Str) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	if instr.opty == GLOBALVAR {
-		addrRegId := regDepatcher.NextAvailReg()
-		sourceRegId := regDepatcher.NextAvailReg()
-		sourceOffset := funcVarDict[instr.target]
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", sourceRegId, sourceOffset))
-		instruction = append(instruction, fmt.Sprintf("\tadrp x%v,%v", addrRegId, instr.globalVar))
-		instruction = append(instruction, fmt.Sprintf("\tadd x%v,x%v, :lo12:%v", addrRegId, addrRegId, instr.globalVar))
-		instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x%v]", sourceRegId, addrRegId))
-		regDepatcher.ReleaseReg(sourceRegId)
-		regDepatcher.ReleaseReg(addrRegId)
-	}
-
-	return instruction
-}
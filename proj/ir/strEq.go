@@ -0,0 +1,46 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// StrEq sets target to 1 when the strings left and right hold the same bytes and to 0 otherwise
+type StrEq struct {
+	target int
+	left   int
+	right  int
+}
+
+func NewStrEq(target int, left int, right int) *StrEq {
+	return &StrEq{target, left, right}
+}
+
+func (instr *StrEq) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+func (instr *StrEq) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.left, instr.right)
+	return sources
+}
+
+func (instr *StrEq) GetImmediate() *int { return nil }
+
+func (instr *StrEq) GetGlobal() string { return "" }
+
+func (instr *StrEq) GetLabel() string { return "" }
+
+func (instr *StrEq) SetLabel(newLabel string) {}
+
+func (instr *StrEq) String() string {
+	var out bytes.Buffer
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	leftReg := fmt.Sprintf("r%v", instr.left)
+	rightReg := fmt.Sprintf("r%v", instr.right)
+	out.WriteString(fmt.Sprintf("strEq %s,%s,%s", targetReg, leftReg, rightReg))
+	return out.String()
+}
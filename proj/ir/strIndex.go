@@ -0,0 +1,49 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// StrIndex stores target into the index-th word of the heap cell at source
+type StrIndex struct {
+	target int
+	source int
+	index  int
+}
+
+func NewStrIndex(target int, source int, index int) *StrIndex {
+	return &StrIndex{target, source, index}
+}
+
+func (instr *StrIndex) GetTargets() []int {
+	// The "target" of a store is the register holding the stored value
+	return []int{}
+}
+
+// GetSources returns the stored value, the address of the cell and the index
+func (instr *StrIndex) GetSources() []int {
+	sources := []int{}
+	sources = append(sources, instr.target, instr.source, instr.index)
+	return sources
+}
+
+func (instr *StrIndex) GetImmediate() *int { return nil }
+
+func (instr *StrIndex) GetGlobal() string { return "" }
+
+func (instr *StrIndex) GetLabel() string { return "" }
+
+func (instr *StrIndex) SetLabel(newLabel string) {}
+
+func (instr *StrIndex) String() string {
+	var out bytes.Buffer
+
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	sourceReg := fmt.Sprintf("r%v", instr.source)
+	indexReg := fmt.Sprintf("r%v", instr.index)
+
+	out.WriteString(fmt.Sprintf("strIdx %s,%s[%s]", targetReg, sourceReg, indexReg))
+
+	return out.String()
+}
@@ -3,7 +3,6 @@ package ir
 import (
 	"bytes"
 	"fmt"
-	"proj/regDepatcher"
 )
 
 // to access fields of a struct
@@ -20,14 +19,14 @@ func NewStrRef(target int, source int, field string, structName string, fieldIdx
 }
 
 func (instr *StrRef) GetTargets() []int {
-	targets := []int{}
-	targets = append(targets, instr.target)
-	return targets
+	// The "target" of a store is the register holding the stored value
+	return []int{}
 }
 
+// GetSources returns the stored value followed by the struct pointer
 func (instr *StrRef) GetSources() []int {
 	sources := []int{}
-	sources = append(sources, instr.source)
+	sources = append(sources, instr.target, instr.source)
 	return sources
 }
 
@@ -39,6 +38,8 @@ func (instr *StrRef) GetGlobal() string {
 
 func (instr *StrRef) GetLabel() string { return "" }
 
+func (instr *StrRef) GetFieldIndex() int { return instr.fieldIdx }
+
 func (instr *StrRef) SetLabel(newLabel string) {}
 
 func (instr *StrRef) String() string {
@@ -52,28 +53,3 @@ func (instr *StrRef) String() string {
 
 	return out.String()
 }
-
-func (instr *StrRef) ToAssembly(funcVarDict map[int]int, paramRegIds map[int]int) []string {
-	instruction := []string{}
-
-	var targetRegId int
-	var istargetParam bool
-	if targetRegId, istargetParam = paramRegIds[instr.target]; !istargetParam {
-		targetRegId = regDepatcher.NextAvailReg()
-		targetOffSet := funcVarDict[instr.target]
-		instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", targetRegId, targetOffSet))
-	}
-
-	sourceRegId := regDepatcher.NextAvailReg()
-	sourceOffSet := funcVarDict[instr.source]
-	instruction = append(instruction, fmt.Sprintf("\tldr x%v,[x29,#%v]", sourceRegId, sourceOffSet))
-
-	fieldOffset := instr.fieldIdx * 8
-	instruction = append(instruction, fmt.Sprintf("\tstr x%v,[x%v,#%v]", targetRegId, sourceRegId, fieldOffset))
-
-	if !istargetParam {
-		regDepatcher.ReleaseReg(targetRegId)
-	}
-	regDepatcher.ReleaseReg(sourceRegId)
-	return instruction
-}
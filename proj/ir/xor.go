@@ -0,0 +1,72 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type Xor struct {
+	target    int       // The target register for the instruction
+	sourceReg int       // The first source register of the instruction
+	operand   int       // The operand either register or constant
+	opty      OperandTy // The type for the operand (REGISTER, IMMEDIATE)
+}
+
+func NewXor(target int, sourceReg int, operand int, opty OperandTy) *Xor {
+	return &Xor{target, sourceReg, operand, opty}
+}
+
+func (instr *Xor) GetTargets() []int {
+	targets := []int{}
+	targets = append(targets, instr.target)
+	return targets
+}
+
+func (instr *Xor) GetSources() []int {
+	sources := []int{}
+	if instr.opty == REGISTER {
+		sources = append(sources, instr.sourceReg, instr.operand)
+	} else if instr.opty == IMMEDIATE {
+		sources = append(sources, instr.sourceReg)
+	}
+	return sources
+}
+
+func (instr *Xor) GetImmediate() *int {
+
+	if instr.opty == IMMEDIATE {
+		return &instr.operand
+	}
+	return nil
+}
+
+func (instr *Xor) GetGlobal() string {
+	return ""
+}
+func (instr *Xor) GetLabel() string {
+	return ""
+}
+
+func (instr *Xor) SetLabel(newLabel string) {}
+
+func (instr *Xor) String() string {
+
+	var out bytes.Buffer
+
+	targetReg := fmt.Sprintf("r%v", instr.target)
+	sourceReg := fmt.Sprintf("r%v", instr.sourceReg)
+
+	var prefix string
+
+	if instr.opty == IMMEDIATE {
+		prefix = "#"
+	} else {
+		prefix = "r"
+	}
+	operand2 := fmt.Sprintf("%v%v", prefix, instr.operand)
+
+	out.WriteString(fmt.Sprintf("xor %s,%s,%s", targetReg, sourceReg, operand2))
+
+	return out.String()
+
+}
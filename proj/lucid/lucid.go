@@ -7,8 +7,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"proj/assembly"
+	"proj/ast"
+	"proj/codegen"
 	cc "proj/context"
+	"proj/interpreter"
 	"proj/ir"
 	"proj/parser"
 	"proj/sa"
@@ -17,25 +19,22 @@ import (
 )
 
 func StartCompiling(ctx *cc.CompilerContext) {
-	scanner := scanner.New(ctx)
-	parser := parser.New(ctx, scanner)
 	fmt.Println("Start parsing")
-	ast := parser.Parse()
+	ast := parseProgram(ctx)
 	fmt.Println("Parse successful")
 	fmt.Println("Printing AST:")
 	fmt.Println(ast)
 	fmt.Println("Start perform SA")
-	sa.PerformSA(ast)
+	checkSemantics(ast)
+	fmt.Println("SA successful")
 }
 
 func GenerateIlocInstructions(ctx *cc.CompilerContext) {
-	scanner := scanner.New(ctx)
-	parser := parser.New(ctx, scanner)
 	fmt.Println("Start parsing")
-	programAst := parser.Parse()
+	programAst := parseProgram(ctx)
 	fmt.Println("Parse successful")
 	fmt.Println("Start perform SA")
-	sa.PerformSA(programAst)
+	checkSemantics(programAst)
 	fmt.Println("Start Translatating ast into iloc")
 	ir.ControlFlowFrags = make([]*ir.FuncFrag, 0)
 	programAst.TranslateToILoc(programAst.GlobalSymbolTable)
@@ -43,6 +42,47 @@ func GenerateIlocInstructions(ctx *cc.CompilerContext) {
 	PrintIlocInstructions(ir.ControlFlowFrags)
 }
 
+func RunIlocInstructions(ctx *cc.CompilerContext) {
+	//Execute the iloc instructions of the program on stdin and stdout
+	programAst := parseProgram(ctx)
+	checkSemantics(programAst)
+	ir.ControlFlowFrags = make([]*ir.FuncFrag, 0)
+	programAst.TranslateToILoc(programAst.GlobalSymbolTable)
+	if err := interpreter.Run(ir.ControlFlowFrags, programAst.GlobalSymbolTable, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		os.Exit(1)
+	}
+}
+
+func parseProgram(ctx *cc.CompilerContext) *ast.Program {
+	//Report every syntax error of the program and stop compiling if there is any
+	parser := parser.New(ctx, scanner.New(ctx))
+	program := parser.Parse()
+	errors := parser.Errors()
+	if len(errors) == 0 {
+		return program
+	}
+	out := flag.CommandLine.Output()
+	for _, err := range errors {
+		fmt.Fprintln(out, err)
+	}
+	os.Exit(1)
+	return nil
+}
+
+func checkSemantics(program *ast.Program) {
+	//Report the semantic errors of the program and stop compiling if there is any
+	errors := sa.PerformSA(program)
+	if len(errors) == 0 {
+		return
+	}
+	out := flag.CommandLine.Output()
+	for _, err := range errors {
+		fmt.Fprintln(out, err)
+	}
+	os.Exit(1)
+}
+
 func PrintIlocInstructions(FuncFrags []*ir.FuncFrag) {
 	//Find the longest label
 	for _, funcFrag := range FuncFrags {
@@ -60,16 +100,13 @@ func PrintIlocInstructions(FuncFrags []*ir.FuncFrag) {
 	}
 }
 
-func getAssembly(ctx *cc.CompilerContext) []string {
-	scanner := scanner.New(ctx)
-	parser := parser.New(ctx, scanner)
-	ast := parser.Parse()
+func getAssembly(ctx *cc.CompilerContext, target codegen.Target) []string {
+	ast := parseProgram(ctx)
 
-	sa.PerformSA(ast)
+	checkSemantics(ast)
 	ir.ControlFlowFrags = make([]*ir.FuncFrag, 0)
 	ast.TranslateToILoc(ast.GlobalSymbolTable)
-	armInstructString := assembly.ToAssembly(ir.ControlFlowFrags, ast.GlobalSymbolTable)
-	return armInstructString
+	return codegen.Generate(target, ir.ControlFlowFrags, ast.GlobalSymbolTable)
 }
 
 func main() {
@@ -77,7 +114,9 @@ func main() {
 	lexPtr := flag.Bool("lex", false, "Use -lex fileName to print the scanned tokens in the specified file")
 	astPtr := flag.Bool("ast", false, "Use -ast fileName to print the ast for the specified file")
 	ilocPtr := flag.Bool("iloc", false, "Use -iloc fileName to print the iloc instructions for the specified file")
+	runIlocPtr := flag.Bool("run-iloc", false, "Use -run-iloc fileName to execute the iloc instructions for the specified file")
 	armPtr := flag.Bool("S", false, "Use -s to print out arm code")
+	targetPtr := flag.String("target", "arm64", "Use -target=amd64 with -S to generate x86-64 code instead of arm code")
 	flag.Parse()
 	argsWithoutProg := flag.Args()
 	inputFileName := argsWithoutProg[0]
@@ -90,12 +129,18 @@ func main() {
 		StartCompiling(ctx)
 	} else if *ilocPtr {
 		GenerateIlocInstructions(ctx)
+	} else if *runIlocPtr {
+		RunIlocInstructions(ctx)
 	} else if *armPtr {
 		fileName := filepath.Base(ctx.SourcePath())
 		fileType := filepath.Ext(ctx.SourcePath())
 		fileName = strings.TrimSuffix(fileName, fileType) + ".s"
 
-		armInstList := getAssembly(ctx)
+		target, err := codegen.NewTarget(*targetPtr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		armInstList := getAssembly(ctx, target)
 		outStr := "" // dump arm code into a string
 		for _, line := range armInstList {
 			outStr = outStr + line + "\n"
@@ -16,6 +16,24 @@ type Parser struct {
 	compilerContext *cc.CompilerContext
 	scanner         *cs.Scanner
 	successfulBuild bool
+	errors          []*SyntaxError
+	targets         []branchTarget  // Loops and switches around the statement being parsed, innermost last
+	labels          map[string]bool // Labels declared in the function being parsed
+}
+
+// branchTarget is a statement a break, or for loops a continue, can leave
+type branchTarget struct {
+	label string // Empty for an unlabeled statement
+	loop  bool
+}
+
+type SyntaxError struct {
+	Pos ct.Position
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: syntax error: %s", e.Pos, e.Msg)
 }
 
 func New(compilerContext *cc.CompilerContext, scanner *cs.Scanner) *Parser {
@@ -35,6 +53,11 @@ func New(compilerContext *cc.CompilerContext, scanner *cs.Scanner) *Parser {
 			continue
 		}
 	}
+	//A malformed literal is still scanned into a token, its error is reported with the syntax errors
+	for _, err := range p.scanner.Errors() {
+		p.errors = append(p.errors, &SyntaxError{Pos: err.Pos, Msg: err.Msg})
+		p.successfulBuild = false
+	}
 	p.currIdx = 0
 	p.psuedoIdx = 0
 	return &p
@@ -58,9 +81,110 @@ func (p *Parser) nextToken() {
 }
 
 func (p *Parser) parseError(msg string) {
-	// out := flag.CommandLine.Output()
-	panic(fmt.Sprintf("syntax error: %s\n", msg))
+	//Abort the construct being parsed, the nearest recovery point records the error and resynchronizes
+	panic(p.syntaxError(msg))
+}
+
+func (p *Parser) parseErrorAt(tok ct.Token, msg string) {
+	//Abort like parseError, the error is reported at tok instead of the current token
 	p.successfulBuild = false
+	panic(&SyntaxError{Pos: tok.Pos, Msg: msg})
+}
+
+func (p *Parser) syntaxError(msg string) *SyntaxError {
+	p.successfulBuild = false
+	return &SyntaxError{Pos: p.currToken().Pos, Msg: msg}
+}
+
+func (p *Parser) Errors() []*SyntaxError {
+	return p.errors
+}
+
+func (p *Parser) expect(tokenType ct.TokenType, expected string) ct.Token {
+	//Match a token the grammar requires at this point, it is a syntax error if it is missing
+	tok, match := p.match(tokenType)
+	if !match {
+		p.parseError(p.expectedTypeErrorMessage(tok, expected))
+	}
+	return tok
+}
+
+func (p *Parser) recoverable(topLevel bool, parse func()) (parsed bool) {
+	/*
+		Run parse as a recovery point. A syntax error raised inside is recorded and
+		the tokens of the broken construct are skipped, so parsing can go on with
+		the next statement, declaration or function. topLevel is set for the
+		constructs outside of functions.
+	*/
+	start := p.currIdx
+	defer func() {
+		if r := recover(); r != nil {
+			synErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			p.errors = append(p.errors, synErr)
+			p.synchronize(start, topLevel)
+			parsed = false
+		}
+	}()
+	parse()
+	return true
+}
+
+func (p *Parser) synchronize(start int, topLevel bool) {
+	/*
+		Skip to the end of the construct that started at start: the next ';' or the
+		'}' closing a block opened inside it. A '}' closing an enclosing block is
+		left for the enclosing construct. At top level the keyword starting the next
+		function, type or global declaration ends the construct too, 'var' and
+		'const' only outside of braces as function bodies declare variables.
+	*/
+	p.psuedoIdx = p.currIdx
+	depth := 0
+	for _, tok := range p.tokens[start:p.currIdx] {
+		if tok.Type == ct.LEFTBRAC {
+			depth++
+		} else if tok.Type == ct.RIGHTBRAC && depth > 0 {
+			depth--
+		}
+	}
+	for {
+		switch p.currToken().Type {
+		case ct.EOF:
+			return
+		case ct.FUNC, ct.TYPE:
+			if topLevel && p.currIdx != start {
+				return
+			}
+		case ct.VAR, ct.CONST:
+			if topLevel && depth == 0 && p.currIdx != start {
+				return
+			}
+		case ct.SEMICOLON:
+			if depth == 0 {
+				p.nextToken()
+				return
+			}
+		case ct.LEFTBRAC:
+			depth++
+		case ct.RIGHTBRAC:
+			if depth == 0 {
+				if p.currIdx == start {
+					//Nothing was consumed, skip the token so the parser makes progress
+					p.nextToken()
+				}
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.nextToken()
+				p.match(ct.SEMICOLON)
+				return
+			}
+		}
+		p.nextToken()
+	}
 }
 
 func (p *Parser) match(tokenType ct.TokenType) (ct.Token, bool) {
@@ -73,6 +197,16 @@ func (p *Parser) match(tokenType ct.TokenType) (ct.Token, bool) {
 	return p.currToken(), false
 }
 
+func (p *Parser) matchAny(tokenTypes ...ct.TokenType) (ct.Token, bool) {
+	//Check whether the current token matches one of the given types
+	for _, tokenType := range tokenTypes {
+		if curToken, match := p.match(tokenType); match {
+			return curToken, true
+		}
+	}
+	return p.currToken(), false
+}
+
 func (p *Parser) PseudoMatch(tokenType ct.TokenType, rollback bool) (ct.Token, bool) {
 	//Check whether the current token matches the given type
 	if tokenType == p.currPsuedoToken().Type {
@@ -91,157 +225,148 @@ func (p *Parser) RollForward() {
 	p.currIdx = p.psuedoIdx
 }
 
-func (p *Parser) expectedTypeErrorMessage(curToken ct.TokenType, expectToken ct.TokenType) string {
-	return "unexpected token type error. Found: #{curToken.Type}, Expected: #{expectToken.Type}"
+func (p *Parser) spanFrom(startIdx int) ast.Span {
+	//Span from the token at startIdx to the last token consumed, including the pseudo matched ones
+	endIdx := p.currIdx
+	if p.psuedoIdx > endIdx {
+		endIdx = p.psuedoIdx
+	}
+	if endIdx <= startIdx {
+		return ast.Span{Start: p.tokens[startIdx].Pos, End: p.tokens[startIdx].Pos}
+	}
+	return ast.Span{Start: p.tokens[startIdx].Pos, End: p.tokens[endIdx-1].End()}
 }
 
-func (p *Parser) Parse() *ast.Program {
-	prog := program(p)
-	if prog == nil {
-		p.parseError("Nil program error")
+func tokenSpan(tok ct.Token) ast.Span {
+	return ast.Span{Start: tok.Pos, End: tok.End()}
+}
+
+func newIdent(tok ct.Token) ast.IdentLiteral {
+	return ast.IdentLiteral{Token: &tok, Span: tokenSpan(tok), Id: tok.Literal, RegisterLoc: -1}
+}
+
+func newStatement(p *Parser, start int, stmt ast.Stat) *ast.Statement {
+	node := ast.NewStatement(stmt)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
+	return node
+}
+
+func (p *Parser) expectedTypeErrorMessage(curToken ct.Token, expected string) string {
+	found := strconv.Quote(curToken.Literal)
+	if curToken.Type == ct.EOF {
+		found = "end of file"
 	}
-	return prog
+	return fmt.Sprintf("unexpected %s, expected %s", found, expected)
+}
+
+func (p *Parser) Parse() *ast.Program {
+	/*
+		Parse the whole token list. Syntax errors do not stop the parsing: they are
+		collected in Errors() and the returned program only holds the constructs
+		that were parsed successfully.
+	*/
+	return program(p)
 }
 
 func program(p *Parser) *ast.Program {
-	pac := packageStmt(p)
-	if pac == nil {
-		return nil
-	}
-	imp := importStmt(p)
-	if imp == nil {
-		return nil
-	}
+	start := p.currIdx
+	var pac *ast.Package
+	var imp *ast.Import
+	p.recoverable(true, func() { pac = packageStmt(p) })
+	p.recoverable(true, func() { imp = importStmt(p) })
 	tps := typesStmt(p)
-	if tps == nil {
-		return nil
-	}
-	decs := declarations(p)
-	if decs == nil {
-		return nil
-	}
+	decs := declarations(p, false)
 	funcs := functions(p)
-	if funcs == nil {
-		p.parseError(fmt.Sprintf("Nill calls"))
-		return nil
-	}
-	if p.currToken().Type != ct.EOF {
-		p.parseError(fmt.Sprintf(" Expected end of file but found:%s on line num: %d", p.currToken().Literal, p.currToken().Rows))
-	}
-	if p.successfulBuild {
-		return ast.NewProgram(pac, imp, tps, decs, funcs)
-	}
-	return nil
+	node := ast.NewProgram(pac, imp, tps, decs, funcs)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
+	return node
 }
 
 func packageStmt(p *Parser) *ast.Package {
-	var pac, id ct.Token
-	var pacMatch, idMatch bool
-
-	if pac, pacMatch = p.match(ct.PACKAGE); !pacMatch {
-		return nil
-	}
-	if id, idMatch = p.match(ct.IDENT); !idMatch {
-		return nil
-	}
-	if _, scMatch := p.match(ct.SEMICOLON); !scMatch {
-		return nil
-	}
-	node := ast.NewPackage(ast.IdentLiteral{Token: &id, Id: id.Literal})
+	start := p.currIdx
+	pac := p.expect(ct.PACKAGE, "package clause")
+	id := p.expect(ct.IDENT, "package name")
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	node := ast.NewPackage(newIdent(id))
+	node.Span = p.spanFrom(start)
 	node.Token = &pac
 	return node
 }
 
 func importStmt(p *Parser) *ast.Import {
-	var imp, imppck ct.Token
-	var impMatch, imppckMatch bool
-
-	if imp, impMatch = p.match(ct.IMPORT); !impMatch {
-		return nil
-	}
-	if _, lQtdMatch := p.match(ct.DOUQUAT); !lQtdMatch {
-		return nil
-	}
-	if imppck, imppckMatch = p.match(ct.FMT); !imppckMatch { //Currently only support fmt for the import package
-		return nil
-	}
-	if _, rQtdMatch := p.match(ct.DOUQUAT); !rQtdMatch {
-		return nil
-	}
-	if _, scMatch := p.match(ct.SEMICOLON); !scMatch {
-		return nil
-	}
-
-	node := ast.NewImport(ast.IdentLiteral{&imppck, imppck.Literal, -1})
+	start := p.currIdx
+	imp := p.expect(ct.IMPORT, "import declaration")
+	pathTok := p.expect(ct.STRLIT, "\"fmt\"")
+	if pathTok.Literal != "\"fmt\"" { //Currently only support fmt for the import package
+		p.parseError(p.expectedTypeErrorMessage(pathTok, "\"fmt\""))
+	}
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	pathTok.Pos.Column += 1
+	pathTok.Pos.Offset += 1
+	imppck := *ct.New(ct.FMT, "fmt", pathTok.Pos)
+
+	node := ast.NewImport(newIdent(imppck))
+	node.Span = p.spanFrom(start)
 	node.Token = &imp
 	return node
 }
 
 func typesStmt(p *Parser) *ast.Types {
+	start := p.currIdx
 	var typeDeclarations []ast.TypeDeclaration
-	for {
-		if typeDeclaration := typeDeclaration(p); typeDeclaration != nil {
-			typeDeclarations = append(typeDeclarations, *typeDeclaration)
-		} else {
-			break
+	for p.currToken().Type == ct.TYPE {
+		var typeDecl *ast.TypeDeclaration
+		if p.recoverable(true, func() { typeDecl = typeDeclaration(p) }) {
+			typeDeclarations = append(typeDeclarations, *typeDecl)
 		}
 	}
-	return ast.NewTypes(typeDeclarations)
+	node := ast.NewTypes(typeDeclarations)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
+	return node
 }
 
 func typeDeclaration(p *Parser) *ast.TypeDeclaration {
-	var typeToken, idToken ct.Token
-	var typeMatch, idMactch, structMatch, lbrMactch, rightBracketMactch, semicolonMatch bool
-	if typeToken, typeMatch = p.match(ct.TYPE); !typeMatch {
-		return nil
-	}
-	if idToken, idMactch = p.match(ct.IDENT); !idMactch {
-		return nil
-	}
-	if _, structMatch = p.match(ct.STRUCT); !structMatch {
-		return nil
-	}
-	if _, lbrMactch = p.match(ct.LEFTBRAC); !lbrMactch {
-		return nil
-	}
+	start := p.currIdx
+	typeToken := p.expect(ct.TYPE, "type declaration")
+	idToken := p.expect(ct.IDENT, "type name")
+	p.expect(ct.STRUCT, ct.STRUCT)
+	p.expect(ct.LEFTBRAC, ct.LEFTBRAC)
 	astFields := fields(p)
-	if astFields == nil {
-		return nil
-	}
-	if _, rightBracketMactch = p.match(ct.RIGHTBRAC); !rightBracketMactch {
-		return nil
-	}
-	if _, semicolonMatch = p.match(ct.SEMICOLON); !semicolonMatch {
-		return nil
-	}
+	p.expect(ct.RIGHTBRAC, ct.RIGHTBRAC)
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
 
-	node := ast.NewTypeDeclaration(ast.IdentLiteral{&idToken, idToken.Literal, -1}, astFields)
+	node := ast.NewTypeDeclaration(newIdent(idToken), astFields)
+	node.Span = p.spanFrom(start)
 	node.Token = &typeToken
 	return node
 }
 
 func fields(p *Parser) *ast.Fields {
+	start := p.currIdx
 	var fieldsDeclarationList = make([]ast.Decl, 0)
 	for {
 		fieldsDeclaration := decl(p)
-		//fmt.Println(fieldsDeclaration)
 		if fieldsDeclaration != nil {
 			fieldsDeclarationList = append(fieldsDeclarationList, *fieldsDeclaration)
 		} else {
 			break
 		}
-		if _, match := p.match(ct.SEMICOLON); !match {
-			return nil
-		}
+		p.expect(ct.SEMICOLON, ct.SEMICOLON)
 	}
 	if len(fieldsDeclarationList) < 1 {
-		return nil
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "field declaration"))
 	}
 	node := ast.NewFields(fieldsDeclarationList)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
 	return node
 }
 
 func decl(p *Parser) *ast.Decl {
+	start := p.currIdx
 	var IDToken ct.Token
 	var match bool
 	if IDToken, match = p.match(ct.IDENT); !match {
@@ -249,144 +374,217 @@ func decl(p *Parser) *ast.Decl {
 	}
 	astType := typeExpression(p)
 	if astType == nil {
-		return nil
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "type"))
 	}
-	node := ast.NewDecl(ast.IdentLiteral{Token: &IDToken, Id: IDToken.Literal}, astType)
+	node := ast.NewDecl(newIdent(IDToken), astType)
+	node.Span = p.spanFrom(start)
 	node.Token = &IDToken
 	return node
 }
 
 func typeExpression(p *Parser) *ast.Type {
+	start := p.currIdx
 	if typeTok, match := p.match(ct.INT); match {
 		node := ast.NewType("int")
+		node.Span = p.spanFrom(start)
 		node.Token = &typeTok
 		return node
 	}
 	if typeTok, match := p.match(ct.BOOL); match {
 		node := ast.NewType("bool")
+		node.Span = p.spanFrom(start)
+		node.Token = &typeTok
+		return node
+	}
+	if typeTok, match := p.match(ct.STRING); match {
+		node := ast.NewType("string")
+		node.Span = p.spanFrom(start)
 		node.Token = &typeTok
 		return node
 	}
 	if typeTok, match := p.match(ct.ASTERISK); match {
-		if idToken, idMatch := p.match(ct.IDENT); idMatch {
-			node := ast.NewType(typeTok.Literal + idToken.Literal)
-			node.Token = &idToken
-			return node
+		idToken := p.expect(ct.IDENT, "struct name")
+		node := ast.NewType(typeTok.Literal + idToken.Literal)
+		node.Span = p.spanFrom(start)
+		node.Token = &idToken
+		return node
+	}
+	if typeTok, match := p.match(ct.LEFTSQUARE); match {
+		//"'[' [number] ']' Type", an array has a length and a slice does not
+		length := ""
+		if numTok, match := p.match(ct.NUMBER); match {
+			length = numTok.Literal
 		}
-		return nil
+		p.expect(ct.RIGHTSQUARE, ct.RIGHTSQUARE)
+		elemType := typeExpression(p)
+		if elemType == nil {
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "element type"))
+		}
+		node := ast.NewType("[" + length + "]" + elemType.TypeString)
+		node.Span = p.spanFrom(start)
+		node.Token = &typeTok
+		return node
 	}
 	return nil
 }
 
-func declarations(p *Parser) *ast.Declarations {
+func declarations(p *Parser, local bool) *ast.Declarations {
+	//The declarations of a function end at the first one with values, which is parsed as a statement, constants are global ones
+	start := p.currIdx
 	var declarationList []ast.Declaration
+	var constList []ast.ConstDecl
 	for {
-		if dec := declaration(p); dec != nil {
-			declarationList = append(declarationList, *dec)
+		if p.currToken().Type == ct.CONST && !local {
+			var dec *ast.ConstDecl
+			if p.recoverable(true, func() { dec = constDecl(p) }) {
+				constList = append(constList, *dec)
+			}
+		} else if p.currToken().Type == ct.VAR && !(local && p.declarationHasValues()) {
+			var dec *ast.Declaration
+			if p.recoverable(!local, func() { dec = declaration(p) }) {
+				declarationList = append(declarationList, *dec)
+			}
 		} else {
 			break
 		}
 	}
 
-	return ast.NewDeclarations(declarationList)
+	node := ast.NewDeclarations(declarationList, constList)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
+	return node
 }
 
 func declaration(p *Parser) *ast.Declaration {
-	var varMatch, semicolonMatch bool
-	var varToken ct.Token
-	if varToken, varMatch = p.match(ct.VAR); !varMatch {
-		return nil
-	}
+	start := p.currIdx
+	varToken := p.expect(ct.VAR, "variable declaration")
 	idToken := ids(p)
 	if idToken == nil {
-		return nil
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "variable name"))
 	}
 	typeToken := typeExpression(p)
 	if typeToken == nil {
-		return nil
-	}
-	if _, semicolonMatch = p.match(ct.SEMICOLON); !semicolonMatch {
-		return nil
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "type"))
 	}
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
 	node := ast.NewDeclaration(idToken, typeToken)
+	node.Span = p.spanFrom(start)
 	node.Token = &varToken
 	return node
 }
 
+func constDecl(p *Parser) *ast.ConstDecl {
+	//"'const' Ids [Type] '=' Expression {',' Expression} ';'"
+	start := p.currIdx
+	constTok, match := p.match(ct.CONST)
+	if !match {
+		return nil
+	}
+	idList := ids(p)
+	if idList == nil {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "constant name"))
+	}
+	typ := typeExpression(p)
+	p.expect(ct.ASSIGN, ct.ASSIGN)
+	exprs := expressionList(p)
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	node := ast.NewConstDecl(idList.Idents, typ, exprs)
+	node.Span = p.spanFrom(start)
+	node.Token = &constTok
+	return node
+}
+
+func (p *Parser) declarationHasValues() bool {
+	//Whether the var declaration starting at the current token assigns values to its variables
+	for idx := p.currIdx; idx < len(p.tokens); idx++ {
+		switch p.tokens[idx].Type {
+		case ct.ASSIGN:
+			return true
+		case ct.SEMICOLON, ct.EOF:
+			return false
+		}
+	}
+	return false
+}
+
 func ids(p *Parser) *ast.Ids {
+	start := p.currIdx
 	var ids []ast.IdentLiteral
 	if idToken, idMatch := p.match(ct.IDENT); idMatch {
-		ids = append(ids, ast.IdentLiteral{Token: &idToken, Id: idToken.Literal})
+		ids = append(ids, newIdent(idToken))
+	} else {
+		return nil
 	}
 	for {
 		if _, semiMatch := p.match(ct.PUNCTUATOR); semiMatch {
-			if idToken, idMatch := p.match(ct.IDENT); idMatch {
-				ids = append(ids, ast.IdentLiteral{Token: &idToken, Id: idToken.Literal})
-			} else {
-				return nil
-			}
+			idToken := p.expect(ct.IDENT, "variable name")
+			ids = append(ids, newIdent(idToken))
 		} else {
 			break
 		}
 	}
-	if len(ids) < 1 {
-		return nil
-	}
 	node := ast.NewIds(ids)
+	node.Token = ids[0].Token
+	node.Span = p.spanFrom(start)
 	return node
 }
 
 func functions(p *Parser) *ast.Functions {
+	start := p.currIdx
 	var functionList []ast.Function
 
-	for {
-		if function := function(p); function != nil {
-			functionList = append(functionList, *function)
-		} else {
-			break
+	for p.currToken().Type != ct.EOF {
+		if p.currToken().Type != ct.FUNC {
+			//Report the stray tokens once and skip to the next function
+			p.errors = append(p.errors, p.syntaxError(p.expectedTypeErrorMessage(p.currToken(), "function declaration")))
+			for p.currToken().Type != ct.FUNC && p.currToken().Type != ct.EOF {
+				p.nextToken()
+			}
+			continue
+		}
+		var fn *ast.Function
+		if p.recoverable(true, func() { fn = function(p) }) {
+			functionList = append(functionList, *fn)
 		}
 	}
 
-	return ast.NewFunctions(functionList)
+	node := ast.NewFunctions(functionList)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
+	return node
 }
 
 func function(p *Parser) *ast.Function {
-	var functionToken, idToken ct.Token
-	var funcMatch, idMatch bool
-	if functionToken, funcMatch = p.match(ct.FUNC); !funcMatch {
-		return nil
-	}
-	if idToken, idMatch = p.match(ct.IDENT); !idMatch {
-		return nil
+	start := p.currIdx
+	functionToken := p.expect(ct.FUNC, "function declaration")
+	p.labels = make(map[string]bool)
+	var receiver *ast.Decl
+	if _, match := p.match(ct.LEFTPAR); match {
+		//"'(' id Type ')'" before the name declares a method
+		if receiver = decl(p); receiver == nil {
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "receiver"))
+		}
+		p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
 	}
+	idToken := p.expect(ct.IDENT, "function name")
 	paras := parameters(p)
 	if paras == nil {
-		return nil
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), ct.LEFTPAR))
 	}
 	retTyp := returnType(p)
-	if retTyp == nil {
-		return nil
-	}
-	if _, lbraceMatch := p.match(ct.LEFTBRAC); !lbraceMatch {
-		return nil
-	}
-	decls := declarations(p)
-	if decls == nil {
-		return nil
-	}
+	p.expect(ct.LEFTBRAC, ct.LEFTBRAC)
+	decls := declarations(p, true)
 	stmts := statements(p)
-	if stmts == nil {
-		return nil
-	}
-	if _, rbraceMatch := p.match(ct.RIGHTBRAC); !rbraceMatch {
-		return nil
-	}
-	node := ast.NewFunction(ast.IdentLiteral{Token: &idToken, Id: idToken.Literal}, paras, retTyp, decls, stmts)
+	closing := p.expect(ct.RIGHTBRAC, ct.RIGHTBRAC)
+	node := ast.NewFunction(receiver, newIdent(idToken), paras, retTyp, decls, stmts)
+	node.Closing = &closing
+	node.Span = p.spanFrom(start)
 	node.Token = &functionToken
 	return node
 }
 
 func parameters(p *Parser) *ast.Parameters {
+	start := p.currIdx
 	var declarationList []ast.Decl
 	var leftParenToken ct.Token
 	var leftParenMatch bool
@@ -405,155 +603,339 @@ func parameters(p *Parser) *ast.Parameters {
 			if decl := decl(p); decl != nil {
 				declarationList = append(declarationList, *decl)
 			} else {
-				return nil
+				p.parseError(p.expectedTypeErrorMessage(p.currToken(), "parameter"))
 			}
 		}
 	}
 
-	if _, rParenMatch := p.match(ct.RIGHTPAR); !rParenMatch {
-		return nil
-	}
+	p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
 
 	node := ast.NewParameters(declarationList)
+	node.Span = p.spanFrom(start)
 	node.Token = &leftParenToken
 	return node
 }
 
 func returnType(p *Parser) *ast.ReturnType {
+	//"[Type | '(' Type {',' Type} ')']"
+	start := p.currIdx
+	if leftParenToken, match := p.match(ct.LEFTPAR); match {
+		var typeList []ast.Type
+		for {
+			typTok := typeExpression(p)
+			if typTok == nil {
+				p.parseError(p.expectedTypeErrorMessage(p.currToken(), "result type"))
+			}
+			typeList = append(typeList, *typTok)
+			if _, commaMatch := p.match(ct.PUNCTUATOR); !commaMatch {
+				break
+			}
+		}
+		p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
+		node := ast.NewReturnType(typeList)
+		node.Span = p.spanFrom(start)
+		node.Token = &leftParenToken
+		return node
+	}
 	typTok := typeExpression(p)
 	if typTok != nil {
-		node := ast.NewReturnType(typTok)
+		node := ast.NewReturnType([]ast.Type{*typTok})
+		node.Span = p.spanFrom(start)
 		node.Token = typTok.Token
 		return node
 	} else {
-		return ast.NewReturnType(ast.NewType(""))
+		node := ast.NewReturnType(nil)
+		node.Span = p.spanFrom(start)
+		return node
 	}
 }
 
 func statements(p *Parser) *ast.Statements {
+	start := p.currIdx
 	var statementsList []ast.Statement
 
-	for {
-		if stmt := statement(p); stmt != nil {
+	for !p.endsStatements(p.currToken().Type) {
+		var stmt *ast.Statement
+		if p.recoverable(false, func() { stmt = statement(p) }) {
 			statementsList = append(statementsList, *stmt)
-		} else {
-			break
 		}
 	}
-	return ast.NewStatements(statementsList)
+	node := ast.NewStatements(statementsList)
+	node.Token = &p.tokens[start]
+	node.Span = p.spanFrom(start)
+	return node
+}
+
+func (p *Parser) endsStatements(tokenType ct.TokenType) bool {
+	//Whether the token closes a list of statements, a block or the body of a case
+	switch tokenType {
+	case ct.RIGHTBRAC, ct.CASE, ct.DEFAULT, ct.EOF:
+		return true
+	}
+	return false
 }
 
 func statement(p *Parser) *ast.Statement {
+	start := p.currIdx
 	blck := block(p)
 	if blck != nil {
-		return ast.NewStatement(blck)
+		return newStatement(p, start, blck)
+	}
+	if decl := varStatement(p); decl != nil {
+		return newStatement(p, start, decl)
+	}
+	if decl := constDecl(p); decl != nil {
+		return newStatement(p, start, decl)
 	}
-	assi := assignment(p)
-	if assi != nil {
-		return ast.NewStatement(assi)
+	if labeled := labeledStatement(p); labeled != nil {
+		return newStatement(p, start, labeled)
+	}
+	if simple := simpleStatement(p); simple != nil {
+		p.expect(ct.SEMICOLON, ct.SEMICOLON)
+		return newStatement(p, start, simple)
 	}
 	prin := print(p)
 	if prin != nil {
-		return ast.NewStatement(prin)
+		return newStatement(p, start, prin)
 	}
 	cond := conditional(p)
 	if cond != nil {
-		return ast.NewStatement(cond)
+		return newStatement(p, start, cond)
 	}
-	loopAst := loop(p)
+	loopAst := loop(p, "")
 	if loopAst != nil {
-		return ast.NewStatement(loopAst)
+		return newStatement(p, start, loopAst)
+	}
+	if switchAst := switchStmt(p, ""); switchAst != nil {
+		return newStatement(p, start, switchAst)
 	}
 	ret := returnStmt(p)
 	if ret != nil {
-		return ast.NewStatement(ret)
+		return newStatement(p, start, ret)
+	}
+	if branch := branchStmt(p); branch != nil {
+		return newStatement(p, start, branch)
 	}
 	readAst := read(p)
 	if readAst != nil {
-		return ast.NewStatement(readAst)
+		return newStatement(p, start, readAst)
 	}
 	invoc := invocation(p)
 	if invoc != nil {
-		return ast.NewStatement(invoc)
+		return newStatement(p, start, invoc)
 	}
+	p.parseError(p.expectedTypeErrorMessage(p.currToken(), "statement"))
 	return nil
 }
 
 func block(p *Parser) *ast.Block {
+	start := p.currIdx
 	var leftBraceketToken ct.Token
 	var leftBraceketMatch bool
 	if leftBraceketToken, leftBraceketMatch = p.match(ct.LEFTBRAC); !leftBraceketMatch {
 		return nil
 	}
 	stmts := statements(p)
-	if stmts == nil {
-		return nil
-	}
-	if _, rightBraceketMatch := p.match(ct.RIGHTBRAC); !rightBraceketMatch {
-		return nil
-	}
+	p.expect(ct.RIGHTBRAC, ct.RIGHTBRAC)
 	blockExpr := ast.NewBlock(stmts)
+	blockExpr.Span = p.spanFrom(start)
 	blockExpr.Token = &leftBraceketToken
 	return blockExpr
 }
 
-func assignment(p *Parser) *ast.Assignment {
+// Tokens of the assignments that apply an operator to the lvalue
+var compoundOperators = map[ct.TokenType]bool{
+	ct.PLUSASSIGN: true, ct.MINUSASSIGN: true, ct.MULASSIGN: true, ct.DIVASSIGN: true, ct.MODASSIGN: true,
+	ct.ANDASSIGN: true, ct.ORASSIGN: true, ct.XORASSIGN: true, ct.ANDNOTASSIGN: true,
+	ct.LSHIFTASSIGN: true, ct.RSHIFTASSIGN: true, ct.INC: true, ct.DEC: true,
+}
+
+func simpleStatement(p *Parser) ast.Stat {
+	//An assignment, a compound assignment, a short variable declaration or a method call, the statements that can also be the clauses of a for loop
+	start := p.currIdx
+	if decl := shortVarDecl(p); decl != nil {
+		return decl
+	}
 	leftVal := lvalue(p)
 	if leftVal == nil {
 		return nil
 	}
-	if _, match := p.PseudoMatch(ct.ASSIGN, true); !match {
-		return nil
+	if _, match := p.PseudoMatch(ct.PUNCTUATOR, false); match {
+		//No other statement starts with "lvalue ,", the statement is a tuple assignment
+		p.RollForward()
+		return tupleAssignment(p, start, leftVal)
 	}
-	p.RollForward()
-	expr := expression(p)
-	if expr == nil {
-		return nil
+	if last := len(leftVal.Selectors) - 1; last >= 0 && leftVal.Selectors[last].Field != nil && p.currPsuedoToken().Type == ct.LEFTPAR {
+		//"lvalue '(' ..." calls the method named by the last field selector
+		p.RollForward()
+		return methodInvocation(p, start, leftVal)
 	}
-	if _, match := p.match(ct.SEMICOLON); !match {
+	if opTok := p.currPsuedoToken(); compoundOperators[opTok.Type] {
+		//"lvalue op= Expression" or "lvalue ++", the operator is the only token that tells them from an assignment
+		p.RollForward()
+		p.nextToken()
+		var expr *ast.Expression
+		if opTok.Type != ct.INC && opTok.Type != ct.DEC {
+			expr = expectExpression(p)
+		}
+		node := ast.NewCompoundAssignment(leftVal, opTok.Literal, expr)
+		node.Span = p.spanFrom(start)
+		node.Token = leftVal.Token
+		return node
+	}
+	if p.currIdx > start {
+		//An index was parsed, the statement can only be an assignment
+		p.expect(ct.ASSIGN, ct.ASSIGN)
+	} else if _, match := p.PseudoMatch(ct.ASSIGN, true); !match {
 		return nil
 	}
+	p.RollForward()
+	expr := expectExpression(p)
 	node := ast.NewAssignment(leftVal, expr)
+	node.Span = p.spanFrom(start)
+	node.Token = leftVal.Token
 	return node
 }
 
-func read(p *Parser) *ast.Read {
-	var fmtTok, idToken ct.Token
-	var fmtMatch, idMatch bool
-
-	if fmtTok, fmtMatch = p.PseudoMatch(ct.FMT, true); !fmtMatch {
-		return nil
+func shortVarDecl(p *Parser) *ast.VarDecl {
+	//"id {',' id} ':=' Expression {',' Expression}"
+	start := p.currIdx
+	var idents []ast.IdentLiteral
+	for {
+		idTok, match := p.PseudoMatch(ct.IDENT, true)
+		if !match {
+			return nil
+		}
+		idents = append(idents, newIdent(idTok))
+		if _, match := p.PseudoMatch(ct.PUNCTUATOR, false); !match {
+			break
+		}
 	}
-	if _, match := p.PseudoMatch(ct.DOT, true); !match {
+	if _, match := p.PseudoMatch(ct.DEFINE, true); !match {
 		return nil
 	}
-	if _, match := p.PseudoMatch(ct.SCAN, true); !match {
+	p.RollForward()
+	exprs := expressionList(p)
+	node := ast.NewVarDecl(true, idents, nil, exprs)
+	node.Span = p.spanFrom(start)
+	node.Token = idents[0].Token
+	return node
+}
+
+func varStatement(p *Parser) *ast.VarDecl {
+	//"'var' Ids [Type] ['=' Expression {',' Expression}] ';'" among the statements, it has a type or values
+	start := p.currIdx
+	varTok, match := p.match(ct.VAR)
+	if !match {
 		return nil
 	}
-	if _, match := p.PseudoMatch(ct.LEFTPAR, true); !match {
-		return nil
+	idList := ids(p)
+	if idList == nil {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "variable name"))
 	}
-	if _, match := p.PseudoMatch(ct.AMPERSAND, true); !match {
-		return nil
+	typ := typeExpression(p)
+	var exprs []ast.Expression
+	if _, match := p.match(ct.ASSIGN); match {
+		exprs = expressionList(p)
+	} else if typ == nil {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "type"))
+	}
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	node := ast.NewVarDecl(false, idList.Idents, typ, exprs)
+	node.Span = p.spanFrom(start)
+	node.Token = &varTok
+	return node
+}
+
+func expectSimpleStatement(p *Parser) ast.Stat {
+	if simple := simpleStatement(p); simple != nil {
+		return simple
+	}
+	p.parseError(p.expectedTypeErrorMessage(p.currToken(), "simple statement"))
+	return nil
+}
+
+func tupleAssignment(p *Parser, start int, first *ast.LValue) *ast.TupleAssignment {
+	//"LValue {',' LValue} '=' Expression {',' Expression}" once the first ',' has been matched
+	leftVals := []ast.LValue{*first}
+	for {
+		leftVal := lvalue(p)
+		if leftVal == nil {
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "variable name"))
+		}
+		p.RollForward()
+		leftVals = append(leftVals, *leftVal)
+		if _, match := p.match(ct.PUNCTUATOR); !match {
+			break
+		}
+	}
+	p.expect(ct.ASSIGN, ct.ASSIGN)
+	exprs := expressionList(p)
+	node := ast.NewTupleAssignment(leftVals, exprs)
+	node.Span = p.spanFrom(start)
+	node.Token = first.Token
+	return node
+}
+
+func methodInvocation(p *Parser, start int, leftVal *ast.LValue) *ast.MethodInvocation {
+	//"id {Selector} '.' id Arguments" once the receiver and the method name have been matched
+	args := arguments(p)
+	var receiver ast.Expr = &leftVal.Ident
+	fact := ast.NewFactor(&receiver)
+	fact.Span = leftVal.Ident.Span
+	fact.Token = leftVal.Ident.Token
+	last := len(leftVal.Selectors) - 1
+	selectors := append(leftVal.Selectors[:last:last], *ast.NewMethodSelector(*leftVal.Selectors[last].Field, args))
+	call := ast.NewSelectorTerm(fact, selectors)
+	call.Span = p.spanFrom(start)
+	call.Token = leftVal.Token
+	node := ast.NewMethodInvocation(call)
+	node.Span = p.spanFrom(start)
+	node.Token = leftVal.Token
+	return node
+}
+
+func expressionList(p *Parser) []ast.Expression {
+	//"Expression {',' Expression}"
+	exprs := []ast.Expression{*expectExpression(p)}
+	for {
+		if _, match := p.match(ct.PUNCTUATOR); !match {
+			return exprs
+		}
+		exprs = append(exprs, *expectExpression(p))
 	}
-	if idToken, idMatch = p.PseudoMatch(ct.IDENT, true); !idMatch {
+}
+
+func read(p *Parser) *ast.Read {
+	start := p.currIdx
+	var fmtTok ct.Token
+	var fmtMatch bool
+
+	if fmtTok, fmtMatch = p.PseudoMatch(ct.FMT, true); !fmtMatch {
 		return nil
 	}
-	if _, match := p.PseudoMatch(ct.RIGHTPAR, true); !match {
+	if _, match := p.PseudoMatch(ct.DOT, true); !match {
 		return nil
 	}
-	if _, match := p.PseudoMatch(ct.SEMICOLON, true); !match {
+	if _, match := p.PseudoMatch(ct.SCAN, true); !match {
 		return nil
 	}
 	p.RollForward()
-	node := ast.NewRead(ast.IdentLiteral{Token: &idToken, Id: idToken.Literal})
+	p.expect(ct.LEFTPAR, ct.LEFTPAR)
+	p.expect(ct.AMPERSAND, ct.AMPERSAND)
+	idToken := p.expect(ct.IDENT, "variable name")
+	p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	node := ast.NewRead(newIdent(idToken))
+	node.Span = p.spanFrom(start)
 	node.Token = &fmtTok
 	return node
 }
 
 func print(p *Parser) *ast.Print {
-	var fmtToken, printToken, idToken ct.Token
-	var fmtMatch, printMatch, idMatch bool
+	start := p.currIdx
+	var fmtToken, printToken ct.Token
+	var fmtMatch, printMatch bool
 
 	if fmtToken, fmtMatch = p.PseudoMatch(ct.FMT, true); !fmtMatch {
 		return nil
@@ -568,110 +950,256 @@ func print(p *Parser) *ast.Print {
 	if !printMatch {
 		return nil
 	}
-	if _, match := p.PseudoMatch(ct.LEFTPAR, true); !match {
-		return nil
-	}
-	if idToken, idMatch = p.PseudoMatch(ct.IDENT, true); !idMatch {
-		return nil
-	}
-	if _, match := p.PseudoMatch(ct.RIGHTPAR, true); !match {
-		return nil
-	}
-	if _, match := p.PseudoMatch(ct.SEMICOLON, true); !match {
-		return nil
-	}
-
 	p.RollForward()
-	node := ast.NewPrint(printToken.Literal, ast.IdentLiteral{Token: &idToken, Id: idToken.Literal, RegisterLoc: -1})
+	p.expect(ct.LEFTPAR, ct.LEFTPAR)
+	expr := expectExpression(p)
+	p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+
+	node := ast.NewPrint(printToken.Literal, expr)
+	node.Span = p.spanFrom(start)
 	node.Token = &fmtToken
 	return node
 }
 
 func conditional(p *Parser) *ast.Conditional {
+	start := p.currIdx
 	var ifToken ct.Token
 	var ifMatch bool
 
 	if ifToken, ifMatch = p.match(ct.IF); !ifMatch {
 		return nil
 	}
-	if _, match := p.match(ct.LEFTPAR); !match {
-		return nil
-	}
-	expr := expression(p)
-	if expr == nil {
-		return nil
-	}
-	if _, match := p.match(ct.RIGHTPAR); !match {
-		return nil
-	}
-	bloc := block(p)
-	if bloc == nil {
-		return nil
-	}
+	p.expect(ct.LEFTPAR, ct.LEFTPAR)
+	expr := expectExpression(p)
+	p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
+	bloc := expectBlock(p)
 
 	var node *ast.Conditional
 	_, match := p.match(ct.ELSE)
 	if match {
-		elsBloc := block(p)
+		elsBloc := expectBlock(p)
 		node = ast.NewConditional(expr, bloc, true, elsBloc)
 	} else {
 		node = ast.NewConditional(expr, bloc, false, nil)
 	}
 	node.Token = &ifToken
+	node.Span = p.spanFrom(start)
 
 	return node
 }
 
-func loop(p *Parser) *ast.Loop {
-	var forToken ct.Token
-	var forMatch bool
-
-	if forToken, forMatch = p.match(ct.FOR); !forMatch {
+func labeledStatement(p *Parser) ast.Stat {
+	//"id ':' (Loop | Switch)", the label names the statement for the break and continue statements inside it
+	start := p.currIdx
+	labelTok, match := p.PseudoMatch(ct.IDENT, true)
+	if !match {
 		return nil
 	}
-	if _, match := p.match(ct.LEFTPAR); !match {
+	if _, match := p.PseudoMatch(ct.COLON, true); !match {
 		return nil
 	}
-	expr := expression(p)
-	if expr == nil {
-		return nil
+	p.RollForward()
+	if p.labels[labelTok.Literal] {
+		p.parseError(fmt.Sprintf("label %s already defined", labelTok.Literal))
 	}
-	if _, match := p.match(ct.RIGHTPAR); !match {
-		return nil
+	p.labels[labelTok.Literal] = true
+	if node := loop(p, labelTok.Literal); node != nil {
+		node.Span = p.spanFrom(start)
+		node.Token = &labelTok
+		return node
 	}
-	bloc := block(p)
-	if bloc == nil {
+	if node := switchStmt(p, labelTok.Literal); node != nil {
+		node.Span = p.spanFrom(start)
+		node.Token = &labelTok
+		return node
+	}
+	p.parseError(p.expectedTypeErrorMessage(p.currToken(), "loop or switch after the label"))
+	return nil
+}
+
+func loop(p *Parser, label string) *ast.Loop {
+	//"'for' [Expression | [SimpleStmt] ';' [Expression] ';' [SimpleStmt]] Block"
+	start := p.currIdx
+	var forToken ct.Token
+	var forMatch bool
+
+	if forToken, forMatch = p.match(ct.FOR); !forMatch {
 		return nil
 	}
+	var init, post ast.Stat
+	var expr *ast.Expression
+	if p.headerHasClauses() {
+		if p.currToken().Type != ct.SEMICOLON {
+			init = expectSimpleStatement(p)
+		}
+		p.expect(ct.SEMICOLON, ct.SEMICOLON)
+		if p.currToken().Type != ct.SEMICOLON {
+			expr = expectExpression(p)
+		}
+		p.expect(ct.SEMICOLON, ct.SEMICOLON)
+		if p.currToken().Type != ct.LEFTBRAC {
+			post = expectSimpleStatement(p)
+			if _, isDecl := post.(*ast.VarDecl); isDecl {
+				p.parseError("cannot declare in the post statement of for loop")
+			}
+		}
+	} else if p.currToken().Type != ct.LEFTBRAC {
+		expr = expectExpression(p)
+	}
+	p.targets = append(p.targets, branchTarget{label, true})
+	defer func() { p.targets = p.targets[:len(p.targets)-1] }()
+	bloc := expectBlock(p)
 
-	node := ast.NewLoop(expr, bloc)
+	node := ast.NewLoop(label, init, expr, post, bloc)
+	node.Span = p.spanFrom(start)
 	node.Token = &forToken
 	return node
 }
 
+func switchStmt(p *Parser, label string) *ast.Switch {
+	//"'switch' [Expression] '{' {CaseClause} '}'", without an expression the cases are conditions
+	start := p.currIdx
+	switchTok, match := p.match(ct.SWITCH)
+	if !match {
+		return nil
+	}
+	var tag *ast.Expression
+	if p.currToken().Type != ct.LEFTBRAC {
+		tag = expectExpression(p)
+	}
+	p.expect(ct.LEFTBRAC, ct.LEFTBRAC)
+	p.targets = append(p.targets, branchTarget{label, false})
+	defer func() { p.targets = p.targets[:len(p.targets)-1] }()
+	clauses := []ast.CaseClause{}
+	hasDefault := false
+	for p.currToken().Type != ct.RIGHTBRAC && p.currToken().Type != ct.EOF {
+		clause := caseClause(p)
+		if clause.Exprs == nil {
+			if hasDefault {
+				p.parseError("multiple defaults in switch")
+			}
+			hasDefault = true
+		}
+		clauses = append(clauses, *clause)
+	}
+	p.expect(ct.RIGHTBRAC, ct.RIGHTBRAC)
+
+	node := ast.NewSwitch(label, tag, clauses)
+	node.Span = p.spanFrom(start)
+	node.Token = &switchTok
+	return node
+}
+
+func caseClause(p *Parser) *ast.CaseClause {
+	//"('case' Expression {',' Expression} | 'default') ':' Statements", the statements end at the next clause
+	start := p.currIdx
+	var exprs []ast.Expression
+	caseTok, match := p.match(ct.CASE)
+	if match {
+		exprs = expressionList(p)
+	} else if caseTok, match = p.match(ct.DEFAULT); !match {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "case or default"))
+	}
+	colonTok := p.expect(ct.COLON, ct.COLON)
+	body := ast.NewBlock(statements(p))
+	body.Span = p.spanFrom(start)
+	body.Token = &colonTok
+
+	node := ast.NewCaseClause(exprs, body)
+	node.Span = p.spanFrom(start)
+	node.Token = &caseTok
+	return node
+}
+
 func returnStmt(p *Parser) *ast.Return {
+	start := p.currIdx
 	var retTok ct.Token
 	var retMatch bool
 	if retTok, retMatch = p.match(ct.RETURN); !retMatch {
 		return nil
 	}
 
-	var node *ast.Return
-	expr := expression(p)
-	if _, match := p.match(ct.SEMICOLON); !match {
-		return nil
-	}
-	if expr == nil {
-		node = ast.NewReturn(false, nil)
-	} else {
-		node = ast.NewReturn(true, expr)
+	var exprs []ast.Expression
+	if p.currToken().Type != ct.SEMICOLON {
+		exprs = expressionList(p)
 	}
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	node := ast.NewReturn(exprs)
 	node.Token = &retTok
+	node.Span = p.spanFrom(start)
 
 	return node
 }
 
+func (p *Parser) headerHasClauses() bool {
+	/*
+		Whether the header of the loop starting at the current token has an
+		init and a post clause. Expressions cannot contain a '{', so it has
+		them when a ';' comes before the '{' of the body.
+	*/
+	depth := 0
+	for idx := p.currIdx; idx < len(p.tokens); idx++ {
+		switch p.tokens[idx].Type {
+		case ct.LEFTPAR, ct.LEFTSQUARE:
+			depth += 1
+		case ct.RIGHTPAR, ct.RIGHTSQUARE:
+			depth -= 1
+		case ct.SEMICOLON:
+			return depth == 0
+		case ct.LEFTBRAC, ct.EOF:
+			return false
+		}
+	}
+	return false
+}
+
+func branchStmt(p *Parser) *ast.BranchStmt {
+	//"('break' | 'continue') [id] ';'" inside a loop, or a switch for break, the label must name one of them
+	start := p.currIdx
+	keywordTok, match := p.match(ct.BREAK)
+	if !match {
+		if keywordTok, match = p.match(ct.CONTINUE); !match {
+			return nil
+		}
+	}
+	label := ""
+	labelTok, hasLabel := p.match(ct.IDENT)
+	if hasLabel {
+		label = labelTok.Literal
+	}
+	continues := keywordTok.Type == ct.CONTINUE
+	if !p.inTarget("", continues) {
+		if continues {
+			p.parseErrorAt(keywordTok, "continue is not in a loop")
+		}
+		p.parseErrorAt(keywordTok, "break is not in a loop or switch")
+	}
+	if hasLabel && !p.inTarget(label, continues) {
+		p.parseErrorAt(labelTok, fmt.Sprintf("invalid %s label %s", keywordTok.Literal, label))
+	}
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
+	node := ast.NewBranchStmt(keywordTok.Literal, label)
+	node.Span = p.spanFrom(start)
+	node.Token = &keywordTok
+	return node
+}
+
+func (p *Parser) inTarget(label string, loopOnly bool) bool {
+	//Whether the statement being parsed is inside a loop or switch with the label, any of them for an empty label
+	for _, target := range p.targets {
+		if loopOnly && !target.loop {
+			continue
+		}
+		if label == "" || target.label == label {
+			return true
+		}
+	}
+	return false
+}
+
 func invocation(p *Parser) *ast.Invocation {
+	start := p.currIdx
 	var idToken ct.Token
 	var idMatch bool
 	if idToken, idMatch = p.match(ct.IDENT); !idMatch {
@@ -679,18 +1207,18 @@ func invocation(p *Parser) *ast.Invocation {
 	}
 	arg := arguments(p)
 	if arg == nil {
-		return nil
-	}
-	if _, match := p.match(ct.SEMICOLON); !match {
-		return nil
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "= or argument list"))
 	}
+	p.expect(ct.SEMICOLON, ct.SEMICOLON)
 
-	node := ast.NewInvocation(ast.IdentLiteral{Token: &idToken, Id: idToken.Literal}, arg)
+	node := ast.NewInvocation(newIdent(idToken), arg)
+	node.Span = p.spanFrom(start)
 	node.Token = &idToken
 	return node
 }
 
 func arguments(p *Parser) *ast.Arguments {
+	start := p.currIdx
 	var lParentoken ct.Token
 	var lParenMatch bool
 	var exprs []ast.Expression
@@ -701,52 +1229,77 @@ func arguments(p *Parser) *ast.Arguments {
 	expr := expression(p)
 	if expr != nil {
 		exprs = append(exprs, *expr)
-	}
-	for {
-		if _, match := p.match(ct.PUNCTUATOR); !match {
-			break
-		}
-		expr := expression(p)
-		if expr != nil {
-			exprs = append(exprs, *expr)
-		} else {
-			return nil
+		for {
+			if _, match := p.match(ct.PUNCTUATOR); !match {
+				break
+			}
+			exprs = append(exprs, *expectExpression(p))
 		}
 	}
-
-	if _, match := p.match(ct.RIGHTPAR); !match {
-		return nil
-	}
+	p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
 
 	node := ast.NewArguments(exprs)
+	node.Span = p.spanFrom(start)
 	node.Token = &lParentoken
 	return node
 }
 
 func lvalue(p *Parser) *ast.LValue {
-	var idToken ct.Token
-	var idList []ast.IdentLiteral
+	/*
+		"id {'.' id | '[' Expression ']'}". The tokens are pseudo matched until
+		the first index, no other statement starts with "id [" so the lvalue is
+		consumed from there on.
+	*/
+	start := p.currIdx
+	var ident ast.IdentLiteral
+	var selectors []ast.Selector
 	if id, match := p.PseudoMatch(ct.IDENT, true); match {
-		idList = append(idList, ast.IdentLiteral{Token: &id, Id: id.Literal})
+		ident = newIdent(id)
 	} else {
 		return nil
 	}
-	for {
-		if _, match := p.PseudoMatch(ct.DOT, false); !match {
+	for p.currIdx == start {
+		if _, match := p.PseudoMatch(ct.DOT, false); match {
+			id, match := p.PseudoMatch(ct.IDENT, true)
+			if !match {
+				return nil
+			}
+			selectors = append(selectors, *ast.NewFieldSelector(newIdent(id)))
+		} else if _, match := p.PseudoMatch(ct.LEFTSQUARE, false); match {
+			p.RollForward()
+			selectors = append(selectors, *indexSelector(p, p.currIdx-1))
+		} else {
 			break
 		}
-		if id, match := p.PseudoMatch(ct.IDENT, true); match {
-			idList = append(idList, ast.IdentLiteral{Token: &id, Id: id.Literal})
+	}
+	for p.currIdx > start {
+		if _, match := p.match(ct.DOT); match {
+			idToken := p.expect(ct.IDENT, "field name")
+			selectors = append(selectors, *ast.NewFieldSelector(newIdent(idToken)))
+		} else if _, match := p.match(ct.LEFTSQUARE); match {
+			selectors = append(selectors, *indexSelector(p, p.currIdx-1))
 		} else {
-			return nil
+			break
 		}
 	}
-	node := ast.NewLvalue(idList)
-	node.Token = &idToken
+	node := ast.NewLvalue(ident, selectors)
+	node.Span = p.spanFrom(start)
+	node.Token = ident.Token
+	return node
+}
+
+func indexSelector(p *Parser, start int) *ast.Selector {
+	//"'[' Expression ']'" once the '[' at start has been matched
+	index := expectExpression(p)
+	p.expect(ct.RIGHTSQUARE, ct.RIGHTSQUARE)
+	node := ast.NewIndexSelector(index)
+	node.Span = p.spanFrom(start)
+	node.Token = &p.tokens[start]
 	return node
 }
 
 func expression(p *Parser) *ast.Expression {
+	start := p.currIdx
 	var bts []ast.BoolTerm
 	btLeft := boolTerm(p)
 	if btLeft == nil {
@@ -760,16 +1313,18 @@ func expression(p *Parser) *ast.Expression {
 		if btRight != nil {
 			bts = append(bts, *btRight)
 		} else {
-			return nil
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
 		}
 	}
 
 	node := ast.NewExpression(btLeft, bts)
+	node.Span = p.spanFrom(start)
 	node.Token = btLeft.Token
 	return node
 }
 
 func boolTerm(p *Parser) *ast.BoolTerm {
+	start := p.currIdx
 	var ets []ast.EqualTerm
 	feq := equalTerm(p)
 	if feq == nil {
@@ -782,19 +1337,21 @@ func boolTerm(p *Parser) *ast.BoolTerm {
 			break
 		}
 		eqt := equalTerm(p)
-		if equalTerm != nil {
+		if eqt != nil {
 			ets = append(ets, *eqt)
 		} else {
-			return nil
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
 		}
 	}
 
 	node := ast.NewBoolTerm(ets)
+	node.Span = p.spanFrom(start)
 	node.Token = feq.Token
 	return node
 }
 
 func equalTerm(p *Parser) *ast.EqualTerm {
+	start := p.currIdx
 	var eqOps []string
 	var relationTermList []ast.RelationTerm
 	frt := relationTerm(p)
@@ -816,17 +1373,19 @@ func equalTerm(p *Parser) *ast.EqualTerm {
 		if rt != nil {
 			relationTermList = append(relationTermList, *rt)
 		} else {
-			return nil
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
 		}
 	}
 
 	node := ast.NewEqualTerm(eqOps, relationTermList)
+	node.Span = p.spanFrom(start)
 	node.Token = frt.Token
 	return node
 
 }
 
 func relationTerm(p *Parser) *ast.RelationTerm {
+	start := p.currIdx
 	var rlOps []string
 	var sts []ast.SimpleTerm
 	var rlTok ct.Token
@@ -838,8 +1397,8 @@ func relationTerm(p *Parser) *ast.RelationTerm {
 	for {
 		if rlTok, match = p.match(ct.GREATER); !match {
 			if rlTok, match = p.match(ct.LESS); !match {
-				if rlTok, match = p.match(ct.GREATER); !match {
-					if rlTok, match = p.match(ct.LESS); !match {
+				if rlTok, match = p.match(ct.GREATEQU); !match {
+					if rlTok, match = p.match(ct.LESSEQU); !match {
 						break
 					}
 				}
@@ -850,16 +1409,19 @@ func relationTerm(p *Parser) *ast.RelationTerm {
 		if stRight != nil {
 			sts = append(sts, *stRight)
 		} else {
-			return nil
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
 		}
 	}
 	node := ast.NewRelationTerm(stLeft, rlOps, sts)
+	node.Span = p.spanFrom(start)
 	node.Token = stLeft.Token
 	return node
 }
 
 func simpleTerm(p *Parser) *ast.SimpleTerm {
+	start := p.currIdx
 	var stOps []string
+	var opToks []*ct.Token
 	var tms []ast.Term
 	var stTok ct.Token
 	var match bool
@@ -868,27 +1430,31 @@ func simpleTerm(p *Parser) *ast.SimpleTerm {
 		return nil
 	}
 	for {
-		if stTok, match = p.match(ct.PLUS); !match {
-			if stTok, match = p.match(ct.MINUS); !match {
-				break
-			}
+		if stTok, match = p.matchAny(ct.PLUS, ct.MINUS, ct.PIPE, ct.CARET); !match {
+			break
 		}
 		stOps = append(stOps, stTok.Literal)
+		opTok := stTok
+		opToks = append(opToks, &opTok)
 		tmRight := term(p)
 		if tmRight != nil {
 			tms = append(tms, *tmRight)
 		} else {
-			return nil
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
 		}
 	}
 
 	node := ast.NewSimpleTerm(termLeft, stOps, tms)
+	node.OperatorTokens = opToks
+	node.Span = p.spanFrom(start)
 	node.Token = termLeft.Token
 	return node
 }
 
 func term(p *Parser) *ast.Term {
+	start := p.currIdx
 	var tmOps []string
+	var opToks []*ct.Token
 	var uts []ast.UnaryTerm
 	var tmTok ct.Token
 	var match bool
@@ -897,99 +1463,156 @@ func term(p *Parser) *ast.Term {
 		return nil
 	}
 	for {
-		if tmTok, match = p.match(ct.ASTERISK); !match {
-			if tmTok, match = p.match(ct.DEVIDE); !match {
-				break
-			}
+		if tmTok, match = p.matchAny(ct.ASTERISK, ct.DEVIDE, ct.MODULUS, ct.LSHIFT, ct.RSHIFT, ct.AMPERSAND, ct.ANDNOT); !match {
+			break
 		}
 		tmOps = append(tmOps, tmTok.Literal)
+		opTok := tmTok
+		opToks = append(opToks, &opTok)
 		utRight := unaryTerm(p)
 		if utRight != nil {
 			uts = append(uts, *utRight)
 		} else {
-			return nil
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
 		}
 	}
 
 	node := ast.NewTerm(utLeft, tmOps, uts)
+	node.OperatorTokens = opToks
+	node.Span = p.spanFrom(start)
 	node.Token = utLeft.Token
 	return node
 }
 
 func unaryTerm(p *Parser) *ast.UnaryTerm {
+	start := p.currIdx
 	op := ""
 	var uniOp ct.Token
 	var match bool
-	if uniOp, match = p.match(ct.NOT); match {
-		op = uniOp.Literal
-	} else if uniOp, match = p.match(ct.MINUS); match {
+	if uniOp, match = p.matchAny(ct.NOT, ct.MINUS, ct.CARET); match {
 		op = uniOp.Literal
 	}
 	selTok := selectorTerm(p)
 	if selTok == nil {
+		if op != "" {
+			p.parseError(p.expectedTypeErrorMessage(p.currToken(), "operand"))
+		}
 		return nil
 	}
 	node := ast.NewUnaryTerm(op, selTok)
-	node.Token = &uniOp
+	node.Span = p.spanFrom(start)
+	if op != "" {
+		node.Token = &uniOp
+	} else {
+		node.Token = selTok.Token
+	}
 	return node
 }
 
 func selectorTerm(p *Parser) *ast.SelectorTerm {
-	var ids []ast.IdentLiteral
-	var idToken ct.Token
-	var match bool
+	start := p.currIdx
+	var selectors []ast.Selector
 	facTok := factor(p)
 	if facTok == nil {
 		return nil
 	}
 	for {
-		if _, match := p.match(ct.DOT); !match {
+		if _, match := p.match(ct.DOT); match {
+			idToken := p.expect(ct.IDENT, "field name")
+			if args := arguments(p); args != nil {
+				selectors = append(selectors, *ast.NewMethodSelector(newIdent(idToken), args))
+			} else {
+				selectors = append(selectors, *ast.NewFieldSelector(newIdent(idToken)))
+			}
+		} else if _, match := p.match(ct.LEFTSQUARE); match {
+			selectors = append(selectors, *indexSelector(p, p.currIdx-1))
+		} else {
 			break
 		}
-		if idToken, match = p.match(ct.IDENT); !match {
-			return nil
-		}
-		ids = append(ids, ast.IdentLiteral{Token: &idToken, Id: idToken.Literal})
 	}
 
-	node := ast.NewSelectorTerm(facTok, ids)
+	node := ast.NewSelectorTerm(facTok, selectors)
+	node.Span = p.spanFrom(start)
 	node.Token = facTok.Token
 	return node
 }
 
 func factor(p *Parser) *ast.Factor {
+	start := p.currIdx
 	var node ast.Expr
 
 	if numTok, match := p.match(ct.NUMBER); match {
 		val, _ := strconv.ParseInt(numTok.Literal, 10, 64)
-		node = &ast.IntLiteral{Token: &numTok, Value: val, RegisterLoc: -1}
+		node = &ast.IntLiteral{Token: &numTok, Span: tokenSpan(numTok), Value: val, RegisterLoc: -1}
 	} else if truTok, match := p.match(ct.TRUE); match {
-		node = &ast.BoolLiteral{Token: &truTok, BoolValue: true}
+		node = &ast.BoolLiteral{Token: &truTok, Span: tokenSpan(truTok), BoolValue: true, RegisterLoc: -1}
 	} else if flsTok, match := p.match(ct.FALSE); match {
-		node = &ast.BoolLiteral{Token: &flsTok, BoolValue: false}
+		node = &ast.BoolLiteral{Token: &flsTok, Span: tokenSpan(flsTok), BoolValue: false, RegisterLoc: -1}
+	} else if strTok, match := p.match(ct.STRLIT); match {
+		value, err := strconv.Unquote(strTok.Literal)
+		if err != nil {
+			p.parseErrorAt(strTok, fmt.Sprintf("invalid escape sequence in string literal %s", strTok.Literal))
+		}
+		node = &ast.StringLiteral{Token: &strTok, Span: tokenSpan(strTok), Value: value, RegisterLoc: -1}
 	} else if nilTok, match := p.match(ct.NIL); match {
-		node = &ast.NilLiteral{Token: &nilTok}
-	} else if identTok, match := p.match(ct.IDENT); match {
+		node = &ast.NilLiteral{Token: &nilTok, Span: tokenSpan(nilTok), RegisterLoc: -1}
+	} else if identTok, match := p.match(ct.IDENT); match && identTok.Literal == "make" && p.currToken().Type == ct.LEFTPAR {
+		node = makeExpr(p, identTok)
+	} else if match {
 		//" 'id' [Arguments] "
 		argu := arguments(p)
-		idl := &ast.IdentLiteral{Token: &identTok, Id: identTok.Literal}
+		idl := newIdent(identTok)
 		if argu == nil {
-			node = idl
+			node = &idl
 		} else {
-			node = &ast.InvocExpr{Token: &identTok, Ident: *idl, InnerArgs: argu}
+			node = &ast.InvocExpr{Token: &identTok, Span: p.spanFrom(start), Ident: idl, InnerArgs: argu, RegisterLoc: -1}
 		}
 	} else if lpTok, match := p.match(ct.LEFTPAR); match {
 		//"'(' Expression ')'"
-		expr := expression(p)
-		if expr != nil {
-			if _, match := p.match(ct.RIGHTPAR); match {
-				node = &ast.PriorityExpression{Token: &lpTok, InnerExpression: expr}
-			}
-		}
+		expr := expectExpression(p)
+		p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
+		node = &ast.PriorityExpression{Token: &lpTok, Span: p.spanFrom(start), InnerExpression: expr, RegisterLoc: -1}
 	}
 	if node != nil {
-		return ast.NewFactor(&node)
+		fac := ast.NewFactor(&node)
+		fac.Span = p.spanFrom(start)
+		fac.Token = &p.tokens[start]
+		return fac
 	} else {
 		return nil
 	}
 }
+
+func makeExpr(p *Parser, makeTok ct.Token) *ast.MakeExpr {
+	//"'make' '(' Type ',' Expression [',' Expression] ')'", the builtin taking a type
+	start := p.currIdx - 1
+	p.expect(ct.LEFTPAR, ct.LEFTPAR)
+	typ := typeExpression(p)
+	if typ == nil {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "type"))
+	}
+	p.expect(ct.PUNCTUATOR, ct.PUNCTUATOR)
+	length := expectExpression(p)
+	var capacity *ast.Expression
+	if _, match := p.match(ct.PUNCTUATOR); match {
+		capacity = expectExpression(p)
+	}
+	p.expect(ct.RIGHTPAR, ct.RIGHTPAR)
+	return &ast.MakeExpr{Token: &makeTok, Span: p.spanFrom(start), Type: typ, Length: length, Capacity: capacity, RegisterLoc: -1}
+}
+
+func expectExpression(p *Parser) *ast.Expression {
+	expr := expression(p)
+	if expr == nil {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), "expression"))
+	}
+	return expr
+}
+
+func expectBlock(p *Parser) *ast.Block {
+	bloc := block(p)
+	if bloc == nil {
+		p.parseError(p.expectedTypeErrorMessage(p.currToken(), ct.LEFTBRAC))
+	}
+	return bloc
+}
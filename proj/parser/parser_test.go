@@ -0,0 +1,134 @@
+package parser_test
+
+import (
+	"proj/internal/golitetest"
+	"strings"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	// Every syntax error is expected in order, the partial program keeps the constructs parsed around them
+	tests := []struct {
+		name   string
+		source string
+		errors []string
+		kept   []string
+		lost   []string
+	}{
+		{"missing semicolons at top level", `package main;
+import "fmt";
+type Point struct {
+	x int;
+	y int
+type Pair struct {
+	a int;
+};
+var a int
+func first() int {
+	return 1;
+}
+func main() {
+	fmt.Println(first());
+}
+`, []string{
+			`prog.golite:6:1: syntax error: unexpected "type", expected semicolon`,
+			`prog.golite:10:1: syntax error: unexpected "func", expected semicolon`,
+		}, []string{"type Pair struct", "func first ()", "func main ()"}, []string{"Point", "var a"}},
+		{"global declaration", `package main;
+import "fmt";
+var a int
+var b int;
+func main() {
+	fmt.Println(b);
+}
+`, []string{
+			`prog.golite:4:1: syntax error: unexpected "var", expected semicolon`,
+		}, []string{"var b int", "func main ()"}, []string{"var a"}},
+		{"statements", `package main;
+import "fmt";
+func main() {
+	var a int;
+	a = ;
+	fmt.Println(a);
+	a = (1;
+}
+func second() {
+}
+`, []string{
+			`prog.golite:5:6: syntax error: unexpected ";", expected expression`,
+			`prog.golite:7:8: syntax error: unexpected ";", expected right parenthesis`,
+		}, []string{"fmt.Println(a);", "func second ()"}, []string{"a = "}},
+	}
+	for _, tt := range tests {
+		program, errors := golitetest.Parse(t, tt.source)
+		if len(errors) != len(tt.errors) {
+			t.Fatalf("FAILED[%s] - expected %d errors, got %d: %v", tt.name, len(tt.errors), len(errors), errors)
+		}
+		for idx, want := range tt.errors {
+			if got := errors[idx].Error(); !strings.HasSuffix(got, "/"+want) {
+				t.Fatalf("FAILED[%s] - incorrect error.\nexpected=%q\ngot=%q\n", tt.name, want, got)
+			}
+		}
+		parsed := program.String()
+		for _, want := range tt.kept {
+			if !strings.Contains(parsed, want) {
+				t.Fatalf("FAILED[%s] - expected %q in the partial program:\n%s", tt.name, want, parsed)
+			}
+		}
+		for _, want := range tt.lost {
+			if strings.Contains(parsed, want) {
+				t.Fatalf("FAILED[%s] - unexpected %q in the partial program:\n%s", tt.name, want, parsed)
+			}
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	// Every syntax error is expected in order, after the directory of the source file
+	tests := []struct {
+		name   string
+		source string
+		errors []string
+	}{
+		{"branch statements", `package main;
+import "fmt";
+func main() {
+	var n int;
+	break;
+	switch n {
+	case 1:
+		continue;
+	}
+	for n < 3 {
+		break outer;
+	}
+	fmt.Println(n);
+}
+`, []string{
+			"prog.golite:5:2: syntax error: break is not in a loop or switch",
+			"prog.golite:8:3: syntax error: continue is not in a loop",
+			"prog.golite:11:9: syntax error: invalid break label outer",
+		}},
+		{"string literals", `package main;
+import "fmt";
+func main() {
+	var s string;
+	s = "tab\q";
+	fmt.Println(s);
+}
+`, []string{
+			`prog.golite:5:6: syntax error: invalid escape sequence in string literal "tab\q"`,
+		}},
+	}
+	for _, tt := range tests {
+		_, errors := golitetest.Parse(t, tt.source)
+		if len(errors) != len(tt.errors) {
+			t.Fatalf("FAILED[%s] - expected %d errors, got %d: %v", tt.name, len(tt.errors), len(errors), errors)
+		}
+		for idx, want := range tt.errors {
+			if got := errors[idx].Error(); !strings.HasSuffix(got, "/"+want) {
+				t.Fatalf("FAILED[%s] - incorrect error.\nexpected=%q\ngot=%q\n", tt.name, want, got)
+			}
+		}
+	}
+}
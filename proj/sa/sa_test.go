@@ -0,0 +1,280 @@
+package sa_test
+
+import (
+	"proj/internal/golitetest"
+	"proj/sa"
+	"strings"
+	"testing"
+)
+
+func TestSemanticErrors(t *testing.T) {
+	// Every error is expected in order, after the directory of the source file
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"calls and assignments", `package main;
+import "fmt";
+type Point struct {
+	x int;
+};
+func add(a int, b int) int {
+	return true;
+}
+func main() {
+	var p *Point;
+	var n int;
+	var b bool;
+	n = add(1);
+	n = add(1, b);
+	p.z = 3;
+	n = b;
+	n = p;
+	fmt.Println(n);
+}
+`, []string{
+			"prog.golite:7:9: semantic error: Function named add unmatched, expected: int, got: bool",
+			"prog.golite:13:6: semantic error: Function named: add expects 2 arguments, got 1",
+			"prog.golite:14:13: semantic error: Unmatched funcion parameter type, expected int, got bool",
+			"prog.golite:15:4: semantic error: Point has no field named z",
+			"prog.golite:16:2: semantic error: Assignment type error: Expected: int, Actual: bool",
+			"prog.golite:17:2: semantic error: Assignment type error: Expected: int, Actual: *Point",
+		}},
+		{"functions used as values", `package main;
+import "fmt";
+func two() (int, int) {
+	return 1, 2;
+}
+func one(a int) int {
+	return a;
+}
+func main() {
+	var x int;
+	x = two;
+	two = 3;
+	x = one + 1;
+	fmt.Println(x);
+	fmt.Println(one);
+}
+`, []string{
+			"prog.golite:11:6: semantic error: two (func) used as value",
+			"prog.golite:12:2: semantic error: Cannot assign to the function two",
+			"prog.golite:13:6: semantic error: one (func) used as value",
+			"prog.golite:15:14: semantic error: one (func) used as value",
+		}},
+		{"return values", `package main;
+import "fmt";
+func one() int {
+	return;
+}
+func two() (int, int) {
+	return 1;
+}
+func main() {
+	fmt.Println(one());
+}
+`, []string{
+			"prog.golite:4:2: semantic error: Function named one must return a value of type int",
+			"prog.golite:7:2: semantic error: Function named two returns 2 values, got 1",
+		}},
+		{"assignment mismatch", `package main;
+import "fmt";
+func two() (int, int) {
+	return 1, 2;
+}
+func main() {
+	var a, b, c int;
+	var d int = two();
+	e := two();
+	a = two();
+	a, b, c = two();
+	a, b = 1;
+	fmt.Println(a + b + c + d + e);
+}
+`, []string{
+			"prog.golite:8:2: semantic error: Assignment mismatch: 1 variable but two returns 2 values",
+			"prog.golite:9:2: semantic error: Assignment mismatch: 1 variable but two returns 2 values",
+			"prog.golite:10:2: semantic error: Assignment mismatch: 1 variable but two returns 2 values",
+			"prog.golite:11:2: semantic error: Assignment mismatch: 3 variables but two returns 2 values",
+			"prog.golite:12:2: semantic error: Assignment mismatch: 2 variables but 1 values",
+		}},
+		{"missing return", `package main;
+import "fmt";
+func sign(a int) int {
+	if (a > 0) {
+		return 1;
+	}
+}
+func both(a int) int {
+	if (a > 0) {
+		return 1;
+	} else {
+		return -1;
+	}
+}
+func forever(a int) int {
+	for {
+		a = a + 1;
+	}
+}
+func leaves(a int) int {
+	for {
+		if (a > 3) {
+			break;
+		}
+		a = a + 1;
+	}
+}
+func nested(a int) int {
+outer:
+	for {
+		for {
+			break outer;
+		}
+	}
+}
+func cases(a int) int {
+	switch a {
+	case 1:
+		return 1;
+	default:
+		for {
+			break;
+		}
+		return 0;
+	}
+}
+func partial(a int) int {
+	switch a {
+	case 1:
+		return 1;
+	}
+}
+func main() {
+	fmt.Println(sign(1) + both(2) + forever(3) + leaves(4) + nested(5) + cases(6) + partial(7));
+}
+`, []string{
+			"prog.golite:7:1: semantic error: missing return",
+			"prog.golite:27:1: semantic error: missing return",
+			"prog.golite:35:1: semantic error: missing return",
+			"prog.golite:52:1: semantic error: missing return",
+		}},
+		{"methods and short declarations", `package main;
+import "fmt";
+type Point struct {
+	x int;
+};
+func (p *Point) get() int {
+	return p.x;
+}
+func (p *Point) get() int {
+	return 1;
+}
+func (n int) bad() int {
+	return n;
+}
+func main() {
+	var n int;
+	n := 1;
+	fmt.Println(n);
+}
+`, []string{
+			"prog.golite:9:17: semantic error: Method get already defined for Point",
+			"prog.golite:12:9: semantic error: Receiver type int is not a struct pointer",
+			"prog.golite:17:2: semantic error: No new variables on the left side of :=",
+		}},
+		{"constants", `package main;
+import "fmt";
+const limit = 10;
+func main() {
+	var n int;
+	limit = 3;
+	n = limit;
+	fmt.Println(n);
+}
+`, []string{
+			"prog.golite:6:2: semantic error: Cannot assign to the constant limit",
+		}},
+		{"constant arithmetic", `package main;
+import "fmt";
+const Y = 9223372036854775807 + 1;
+const Z = 1 << 70;
+const X = 10 / 0;
+const M = (-9223372036854775807 - 1) * -1;
+const W = -(-9223372036854775807 - 1);
+const Q, R = 10 / -2, -7 % -2;
+func main() {
+	fmt.Println(Q + R);
+}
+`, []string{
+			"prog.golite:3:11: semantic error: constant overflows int",
+			"prog.golite:4:11: semantic error: constant overflows int",
+			"prog.golite:5:14: semantic error: division by zero",
+			"prog.golite:6:11: semantic error: constant overflows int",
+			"prog.golite:7:11: semantic error: constant overflows int",
+		}},
+		{"division by a constant zero", `package main;
+import "fmt";
+func main() {
+	var a int;
+	a = 7 + a % (2 - 2);
+	fmt.Println(a / 1);
+}
+`, []string{
+			"prog.golite:5:12: semantic error: division by zero",
+		}},
+		{"make sizes", `package main;
+import "fmt";
+func main() {
+	var s []int;
+	var n int;
+	s = make([]int, 5, 2);
+	s = make([]int, -1);
+	s = make([]int, 2, 3 - 4);
+	s = make([]int, 0, 0);
+	s = make([]int, n, 2);
+	fmt.Println(len(s));
+}
+`, []string{
+			"prog.golite:6:18: semantic error: make expects a length no larger than the capacity, found: 5 > 2",
+			"prog.golite:7:18: semantic error: make expects a non-negative size, found: -1",
+			"prog.golite:8:21: semantic error: make expects a non-negative size, found: -1",
+		}},
+		{"constant indexes", `package main;
+import "fmt";
+const N = 3;
+func main() {
+	var a [3]int;
+	var s []int;
+	var i int;
+	a[5] = 1;
+	i = a[N];
+	i = a[N - 1] + a[0];
+	s = make([]int, 2);
+	s[-1] = 2;
+	i = s[5];
+	fmt.Println(a[i] + i);
+}
+`, []string{
+			"prog.golite:8:4: semantic error: index 5 out of bounds [0:3]",
+			"prog.golite:9:8: semantic error: index 3 out of bounds [0:3]",
+			"prog.golite:12:4: semantic error: index -1 must not be negative",
+		}},
+	}
+	for _, tt := range tests {
+		program, syntaxErrors := golitetest.Parse(t, tt.source)
+		if len(syntaxErrors) > 0 {
+			t.Fatalf("FAILED[%s] - syntax errors: %v", tt.name, syntaxErrors)
+		}
+		errors := sa.PerformSA(program)
+		if len(errors) != len(tt.want) {
+			t.Fatalf("FAILED[%s] - expected %d errors, got %d:\n%s", tt.name, len(tt.want), len(errors), strings.Join(errors, "\n"))
+		}
+		for idx, want := range tt.want {
+			if !strings.HasSuffix(errors[idx], "/"+want) {
+				t.Fatalf("FAILED[%s] - incorrect error.\nexpected=%q\ngot=%q\n", tt.name, want, errors[idx])
+			}
+		}
+	}
+}
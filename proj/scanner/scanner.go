@@ -7,29 +7,37 @@ import (
 	"os"
 	"proj/context"
 	"proj/token"
+	"strconv"
 )
 
 var keywordsMap map[string]token.TokenType = map[string]token.TokenType{
-	"var":     token.VAR,
-	"return":  token.RETURN,
-	"for":     token.FOR,
-	"if":      token.IF,
-	"else":    token.ELSE,
-	"true":    token.TRUE,
-	"false":   token.FALSE,
-	"id":      token.IDENT,
-	"Print":   token.PRINT,
-	"nil":     token.NIL,
-	"fmt":     token.FMT,
-	"Scan":    token.SCAN,
-	"package": token.PACKAGE,
-	"type":    token.TYPE,
-	"import":  token.IMPORT,
-	"struct":  token.STRUCT,
-	"Println": token.PRINTLN,
-	"int":     token.INT,
-	"bool":    token.BOOL,
-	"func":    token.FUNC,
+	"var":      token.VAR,
+	"return":   token.RETURN,
+	"for":      token.FOR,
+	"if":       token.IF,
+	"else":     token.ELSE,
+	"true":     token.TRUE,
+	"false":    token.FALSE,
+	"id":       token.IDENT,
+	"Print":    token.PRINT,
+	"nil":      token.NIL,
+	"fmt":      token.FMT,
+	"Scan":     token.SCAN,
+	"package":  token.PACKAGE,
+	"type":     token.TYPE,
+	"import":   token.IMPORT,
+	"struct":   token.STRUCT,
+	"Println":  token.PRINTLN,
+	"int":      token.INT,
+	"bool":     token.BOOL,
+	"string":   token.STRING,
+	"func":     token.FUNC,
+	"break":    token.BREAK,
+	"continue": token.CONTINUE,
+	"switch":   token.SWITCH,
+	"case":     token.CASE,
+	"default":  token.DEFAULT,
+	"const":    token.CONST,
 }
 
 func calTokenList(l *Scanner, input string) []token.Token {
@@ -40,11 +48,13 @@ func calTokenList(l *Scanner, input string) []token.Token {
 
 	for ; idx <= size; idx++ {
 		c := input[idx]
+		start := idx
 
 		//skip space, tab and newline
 		if c == ' ' || c == '\n' || c == '\t' || c == '\r' {
 			if c == '\n' {
 				l.curRow += 1
+				l.lineStart = l.offset + idx + 1
 				l.commentLine = false
 			}
 			continue
@@ -52,111 +62,198 @@ func calTokenList(l *Scanner, input string) []token.Token {
 		//fmt.Println(strconv.Itoa(int(c)))
 		switch c {
 		case '+':
-			curToken = token.New(token.PLUS, "+", l.curRow)
+			if nextChar(input, idx, size) == '+' {
+				curToken = token.New(token.INC, "++", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.PLUSASSIGN, "+=", l.position(start))
+				idx += 1
+			} else {
+				curToken = token.New(token.PLUS, "+", l.position(start))
+			}
 		case '-':
-			curToken = token.New(token.MINUS, "-", l.curRow)
+			if nextChar(input, idx, size) == '-' {
+				curToken = token.New(token.DEC, "--", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.MINUSASSIGN, "-=", l.position(start))
+				idx += 1
+			} else {
+				curToken = token.New(token.MINUS, "-", l.position(start))
+			}
 		case '*':
-			curToken = token.New(token.ASTERISK, "*", l.curRow)
+			if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.MULASSIGN, "*=", l.position(start))
+				idx += 1
+			} else {
+				curToken = token.New(token.ASTERISK, "*", l.position(start))
+			}
 
 		case '/':
 			if nextChar(input, idx, size) == '/' {
 				l.commentLine = true
-				curToken = token.New(token.COMMENT, "//", l.curRow)
+				curToken = token.New(token.COMMENT, "//", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.DIVASSIGN, "/=", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.DEVIDE, "/", l.curRow)
+				curToken = token.New(token.DEVIDE, "/", l.position(start))
 			}
 		case '&':
 			if nextChar(input, idx, size) == '&' {
-				curToken = token.New(token.AND, "&&", l.curRow)
+				curToken = token.New(token.AND, "&&", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '^' && nextChar(input, idx+1, size) == '=' {
+				curToken = token.New(token.ANDNOTASSIGN, "&^=", l.position(start))
+				idx += 2
+			} else if nextChar(input, idx, size) == '^' {
+				curToken = token.New(token.ANDNOT, "&^", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.ANDASSIGN, "&=", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.AMPERSAND, "&", l.curRow)
+				curToken = token.New(token.AMPERSAND, "&", l.position(start))
 			}
 		case '|':
 			if nextChar(input, idx, size) == '|' {
-				curToken = token.New(token.OR, "||", l.curRow)
+				curToken = token.New(token.OR, "||", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.ORASSIGN, "|=", l.position(start))
+				idx += 1
+			} else {
+				curToken = token.New(token.PIPE, "|", l.position(start))
+			}
+		case '^':
+			if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.XORASSIGN, "^=", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.INVALID, "|", l.curRow)
+				curToken = token.New(token.CARET, "^", l.position(start))
 			}
 		case '%':
-			curToken = token.New(token.MODULUS, "%", l.curRow)
+			if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.MODASSIGN, "%=", l.position(start))
+				idx += 1
+			} else {
+				curToken = token.New(token.MODULUS, "%", l.position(start))
+			}
 		case '<':
 			if nextChar(input, idx, size) == '=' {
-				curToken = token.New(token.LESSEQU, "<=", l.curRow)
+				curToken = token.New(token.LESSEQU, "<=", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '<' && nextChar(input, idx+1, size) == '=' {
+				curToken = token.New(token.LSHIFTASSIGN, "<<=", l.position(start))
+				idx += 2
+			} else if nextChar(input, idx, size) == '<' {
+				curToken = token.New(token.LSHIFT, "<<", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.LESS, "<", l.curRow)
+				curToken = token.New(token.LESS, "<", l.position(start))
 			}
 		case '>':
 			if nextChar(input, idx, size) == '=' {
-				curToken = token.New(token.GREATEQU, ">=", l.curRow)
+				curToken = token.New(token.GREATEQU, ">=", l.position(start))
+				idx += 1
+			} else if nextChar(input, idx, size) == '>' && nextChar(input, idx+1, size) == '=' {
+				curToken = token.New(token.RSHIFTASSIGN, ">>=", l.position(start))
+				idx += 2
+			} else if nextChar(input, idx, size) == '>' {
+				curToken = token.New(token.RSHIFT, ">>", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.GREATER, ">", l.curRow)
+				curToken = token.New(token.GREATER, ">", l.position(start))
 			}
 		case '=':
 			if nextChar(input, idx, size) == '=' {
-				curToken = token.New(token.EQUA, "==", l.curRow)
+				curToken = token.New(token.EQUA, "==", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.ASSIGN, "=", l.curRow)
+				curToken = token.New(token.ASSIGN, "=", l.position(start))
 			}
 		case '!':
 			if nextChar(input, idx, size) == '=' {
-				curToken = token.New(token.NOTEQU, "!=", l.curRow)
+				curToken = token.New(token.NOTEQU, "!=", l.position(start))
 				idx += 1
 			} else {
-				curToken = token.New(token.GREATER, ">", l.curRow)
+				curToken = token.New(token.NOT, "!", l.position(start))
 			}
 		case '(':
-			curToken = token.New(token.LEFTPAR, "(", l.curRow)
+			curToken = token.New(token.LEFTPAR, "(", l.position(start))
 		case ')':
-			curToken = token.New(token.RIGHTPAR, ")", l.curRow)
+			curToken = token.New(token.RIGHTPAR, ")", l.position(start))
 		case '{':
-			curToken = token.New(token.LEFTBRAC, "{", l.curRow)
+			curToken = token.New(token.LEFTBRAC, "{", l.position(start))
 		case '}':
-			curToken = token.New(token.RIGHTBRAC, "}", l.curRow)
+			curToken = token.New(token.RIGHTBRAC, "}", l.position(start))
+		case '[':
+			curToken = token.New(token.LEFTSQUARE, "[", l.position(start))
+		case ']':
+			curToken = token.New(token.RIGHTSQUARE, "]", l.position(start))
 		case ';':
-			curToken = token.New(token.SEMICOLON, ";", l.curRow)
+			curToken = token.New(token.SEMICOLON, ";", l.position(start))
 		case ',':
-			curToken = token.New(token.PUNCTUATOR, ",", l.curRow)
+			curToken = token.New(token.PUNCTUATOR, ",", l.position(start))
 		case '.':
-			curToken = token.New(token.DOT, ".", l.curRow)
+			curToken = token.New(token.DOT, ".", l.position(start))
 		case ':':
-			curToken = token.New(token.COLON, ":", l.curRow)
+			if nextChar(input, idx, size) == '=' {
+				curToken = token.New(token.DEFINE, ":=", l.position(start))
+				idx += 1
+			} else {
+				curToken = token.New(token.COLON, ":", l.position(start))
+			}
 		case '"':
-			curToken = token.New(token.DOUQUAT, "\"", l.curRow)
+			// the literal keeps its quotes and escapes, the parser decodes it
+			str, step, closed := getString(input, idx, size)
+			if closed {
+				curToken = token.New(token.STRLIT, str, l.position(start))
+			} else {
+				curToken = token.New(token.INVALID, str, l.position(start))
+			}
+			idx += step - 1
 		case '\'':
-			curToken = token.New(token.SIGQUAT, "'", l.curRow)
+			curToken = token.New(token.SIGQUAT, "'", l.position(start))
 		default:
 			if isDigit(input[idx]) {
 				num, step := getNum(input, idx, size)
 				idx += step - 1
-				curToken = token.New(token.NUMBER, num, l.curRow)
+				curToken = token.New(token.NUMBER, num, l.position(start))
+				if _, err := strconv.ParseInt(num, 10, 64); err != nil {
+					// the literal is kept so parsing can go on, the error stops the compilation
+					l.errors = append(l.errors, &Error{Pos: curToken.Pos, Msg: fmt.Sprintf("integer literal %s overflows int64", num)})
+				}
 			} else if isChar(input[idx]) {
 				word, step := getWord(input, idx, size)
 				idx += step - 1
 				tokenType, ok := keywordsMap[word]
 				if ok {
 					// keyword
-					curToken = token.New(tokenType, word, l.curRow)
+					curToken = token.New(tokenType, word, l.position(start))
 				} else {
 					//identifier
-					curToken = token.New(token.IDENT, word, l.curRow)
+					curToken = token.New(token.IDENT, word, l.position(start))
 				}
 			} else {
-				curToken = token.New(token.INVALID, string(c), l.curRow)
+				curToken = token.New(token.INVALID, string(c), l.position(start))
 			}
 		}
 		if !l.commentLine {
 			tokenList = append(tokenList, *curToken)
 		}
 	}
+	l.offset += len(input)
 	return tokenList
 }
 
+func (l *Scanner) position(idx int) token.Position {
+	//Convert an index into the chunk being scanned into a position in the source file
+	offset := l.offset + idx
+	return token.Position{File: l.file, Line: l.curRow, Column: offset - l.lineStart + 1, Offset: offset}
+}
+
 func isChar(c byte) bool {
 	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
 }
@@ -171,6 +268,23 @@ func getWord(input string, idx int, size int) (string, int) {
 	return input[idx : idx+i], i
 }
 
+func getString(input string, idx int, size int) (string, int, bool) {
+	//Read the string literal starting at the opening quote, it must be closed on the same line
+	for i := 1; idx+i <= size; i++ {
+		switch input[idx+i] {
+		case '"':
+			return input[idx : idx+i+1], i + 1, true
+		case '\n':
+			return input[idx : idx+i], i, false
+		case '\\':
+			if idx+i < size && input[idx+i+1] != '\n' {
+				i++
+			}
+		}
+	}
+	return input[idx:], size + 1 - idx, false
+}
+
 func isDigit(c byte) bool {
 	if c >= '0' && c <= '9' {
 		return true
@@ -201,13 +315,26 @@ func check(e error) {
 	}
 }
 
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
 type Scanner struct {
 	finalTokenList []token.Token
 	curTokenliST   []token.Token
 	reader         *bufio.Reader
+	file           string
 	idx            int
 	curRow         int
+	offset         int // Byte offset of the chunk being scanned
+	lineStart      int // Byte offset of the first character of the current row
 	commentLine    bool
+	errors         []*Error // Lexical errors found so far
 }
 
 func New(inputContext *context.CompilerContext) *Scanner {
@@ -219,22 +346,27 @@ func New(inputContext *context.CompilerContext) *Scanner {
 	return &Scanner{finalTokenList: make([]token.Token, 0),
 		curTokenliST: make([]token.Token, 0),
 		reader:       reader,
+		file:         inputContext.SourcePath(),
 		idx:          0,
 		curRow:       1,
+		offset:       0,
+		lineStart:    0,
 		commentLine:  false,
 	}
 }
 
 func (l *Scanner) NextToken() (*token.Token, bool) {
-	if l.idx+1 > len(l.finalTokenList) { //Check whether the pointer has exceeded the end of the finalTokenList
-		inputString, err := l.reader.ReadString(' ')
+	for l.idx+1 > len(l.finalTokenList) { //Check whether the pointer has exceeded the end of the finalTokenList
+		// scan line by line, a line without tokens is skipped
+		inputString, err := l.reader.ReadString('\n')
 		l.finalTokenList = append(l.finalTokenList, calTokenList(l, inputString)...)
 		if err != nil {
 			if err == io.EOF {
-				l.finalTokenList = append(l.finalTokenList, *token.New(token.EOF, "eof", l.curRow))
+				l.finalTokenList = append(l.finalTokenList, *token.New(token.EOF, "eof", l.position(0)))
 			} else {
 				check(err)
 			}
+			break
 		}
 	}
 	if l.idx+1 <= len(l.finalTokenList) {
@@ -245,23 +377,30 @@ func (l *Scanner) NextToken() (*token.Token, bool) {
 	}
 }
 
+func (l *Scanner) Errors() []*Error {
+	return l.errors
+}
+
 func PrintToken(t token.Token) {
-	fmt.Printf("|%-20v|%-20v|%-20v|\n", t.Type, t.Literal, t.Rows)
+	fmt.Printf("|%-20v|%-20v|%-20v|\n", t.Type, t.Literal, t.Pos)
 }
 
 func (l *Scanner) PrintAllTokens() {
 	fmt.Println("Start printing tokens")
-	fmt.Printf("|%-20v|%-20v|%-20v|\n", "Token Type", "Token Literal", "Rows")
+	fmt.Printf("|%-20v|%-20v|%-20v|\n", "Token Type", "Token Literal", "Position")
 	for {
 		if nextToken, readSuccess := l.NextToken(); readSuccess {
 			PrintToken(*nextToken)
 			if nextToken.Type == token.EOF {
-				return
+				break
 			}
 		} else {
 			continue
 		}
 
 	}
+	for _, err := range l.errors {
+		fmt.Println(err)
+	}
 	fmt.Println("Finish printing tokens")
 }
@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"os"
+	"path/filepath"
 	"proj/context"
 	"proj/token"
 	"testing"
@@ -14,7 +16,7 @@ type ExpectedResult struct {
 func VerifyTest(t *testing.T, tts []ExpectedResult, scanner *Scanner) {
 
 	for i, tt := range tts {
-		tok := scanner.NextToken()
+		tok, _ := scanner.NextToken()
 
 		if tok.Type != tt.expectedType {
 			t.Fatalf("FAILED[%d] - incorrect token.\nexpected=%v\ngot=%v\n",
@@ -34,16 +36,14 @@ func VerifyTest(t *testing.T, tts []ExpectedResult, scanner *Scanner) {
 func Test1(t *testing.T) {
 
 	// This is a raw string in Go (aka its a multiline string). This will be easy to
-	ctx := context.New(false, "../golite/test1.golite")
+	ctx := context.New(false, "../lucid/test1.golite")
 	// The expected result struct represents the token stream for the input source
 	expected := []ExpectedResult{
 		{token.PACKAGE, "package"},
 		{token.IDENT, "main"},
 		{token.SEMICOLON, ";"},
 		{token.IMPORT, "import"},
-		{token.DOUQUAT, "\""},
-		{token.FMT, "fmt"},
-		{token.DOUQUAT, "\""},
+		{token.STRLIT, "\"fmt\""},
 		{token.SEMICOLON, ";"},
 		{token.FUNC, "func"},
 		{token.IDENT, "main"},
@@ -78,3 +78,165 @@ func Test1(t *testing.T) {
 	// Verify that the scanner produces the tokens in the order that you expect.
 	VerifyTest(t, expected, scanner)
 }
+
+func TestPositions(t *testing.T) {
+
+	ctx := context.New(false, "../lucid/test1.golite")
+	// Positions of the tokens on the assignment line "a = 3 + 4 + 5;"
+	expected := []token.Position{
+		{File: "../lucid/test1.golite", Line: 11, Column: 1, Offset: 59},
+		{File: "../lucid/test1.golite", Line: 11, Column: 3, Offset: 61},
+		{File: "../lucid/test1.golite", Line: 11, Column: 5, Offset: 63},
+	}
+
+	scanner := New(ctx)
+	for {
+		tok, _ := scanner.NextToken()
+		if tok.Type == token.EOF {
+			t.Fatalf("FAILED - assignment line not found")
+		}
+		if tok.Type == token.IDENT && tok.Literal == "a" && tok.Pos.Line == 11 {
+			for i, pos := range expected {
+				if tok.Pos != pos {
+					t.Fatalf("FAILED[%d] - incorrect position.\nexpected=%v\ngot=%v\n", i, pos, tok.Pos)
+				}
+				tok, _ = scanner.NextToken()
+			}
+			return
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "strings.golite")
+	if err := os.WriteFile(path, []byte("s = \"a b\\\"c\" + \"\";\nt = \"open;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Literals keep their quotes and escapes, spaces do not split them
+	expected := []ExpectedResult{
+		{token.IDENT, "s"},
+		{token.ASSIGN, "="},
+		{token.STRLIT, "\"a b\\\"c\""},
+		{token.PLUS, "+"},
+		{token.STRLIT, "\"\""},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "t"},
+		{token.ASSIGN, "="},
+		{token.INVALID, "\"open;"},
+		{token.EOF, "eof"},
+	}
+	VerifyTest(t, expected, New(context.New(false, path)))
+}
+
+func TestBitwiseOperators(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "bits.golite")
+	if err := os.WriteFile(path, []byte("x = a&^b | c&&d ^ e<<1 >> 2 <= f & ^g;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The longest operator is taken, "&^" is not "&" followed by "^"
+	expected := []ExpectedResult{
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "a"},
+		{token.ANDNOT, "&^"},
+		{token.IDENT, "b"},
+		{token.PIPE, "|"},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.CARET, "^"},
+		{token.IDENT, "e"},
+		{token.LSHIFT, "<<"},
+		{token.NUMBER, "1"},
+		{token.RSHIFT, ">>"},
+		{token.NUMBER, "2"},
+		{token.LESSEQU, "<="},
+		{token.IDENT, "f"},
+		{token.AMPERSAND, "&"},
+		{token.CARET, "^"},
+		{token.IDENT, "g"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, "eof"},
+	}
+	VerifyTest(t, expected, New(context.New(false, path)))
+}
+
+func TestAssignmentOperators(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "assign.golite")
+	if err := os.WriteFile(path, []byte("i++; j--; a+=1; b-=-1; c*=d/=e%=f&=g|=h^=k&^=l<<=m>>=n<<o;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expected := []ExpectedResult{
+		{token.IDENT, "i"},
+		{token.INC, "++"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "j"},
+		{token.DEC, "--"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.PLUSASSIGN, "+="},
+		{token.NUMBER, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "b"},
+		{token.MINUSASSIGN, "-="},
+		{token.MINUS, "-"},
+		{token.NUMBER, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "c"},
+		{token.MULASSIGN, "*="},
+		{token.IDENT, "d"},
+		{token.DIVASSIGN, "/="},
+		{token.IDENT, "e"},
+		{token.MODASSIGN, "%="},
+		{token.IDENT, "f"},
+		{token.ANDASSIGN, "&="},
+		{token.IDENT, "g"},
+		{token.ORASSIGN, "|="},
+		{token.IDENT, "h"},
+		{token.XORASSIGN, "^="},
+		{token.IDENT, "k"},
+		{token.ANDNOTASSIGN, "&^="},
+		{token.IDENT, "l"},
+		{token.LSHIFTASSIGN, "<<="},
+		{token.IDENT, "m"},
+		{token.RSHIFTASSIGN, ">>="},
+		{token.IDENT, "n"},
+		{token.LSHIFT, "<<"},
+		{token.IDENT, "o"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, "eof"},
+	}
+	VerifyTest(t, expected, New(context.New(false, path)))
+}
+
+func TestIntegerOverflow(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "overflow.golite")
+	if err := os.WriteFile(path, []byte("a = 9223372036854775807;\nb = 9223372036854775808;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The largest int64 is accepted, the literal after it is scanned and reported
+	expected := []ExpectedResult{
+		{token.IDENT, "a"},
+		{token.ASSIGN, "="},
+		{token.NUMBER, "9223372036854775807"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "b"},
+		{token.ASSIGN, "="},
+		{token.NUMBER, "9223372036854775808"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, "eof"},
+	}
+	scanner := New(context.New(false, path))
+	VerifyTest(t, expected, scanner)
+	errors := scanner.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", errors)
+	}
+	if want := path + ":2:5: integer literal 9223372036854775808 overflows int64"; errors[0].Error() != want {
+		t.Fatalf("expected error %q, got %q", want, errors[0].Error())
+	}
+}
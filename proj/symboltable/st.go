@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"proj/ir"
 	"proj/types"
+	"strings"
 )
 
 type EntryValue struct {
@@ -50,10 +51,11 @@ type IntLiteralEntry struct {
 type structDefinitionEntry struct {
 	//Entry type for struct definition
 	entryValue *EntryValue
+	methods    map[string]*functionEntry // Method table of the struct
 }
 
 func NewStructDefinition(t types.Type, st SymbolTable) *structDefinitionEntry {
-	return &structDefinitionEntry{&EntryValue{EntryType: t, LocalSymbolTable: &st}}
+	return &structDefinitionEntry{&EntryValue{EntryType: t, LocalSymbolTable: &st}, make(map[string]*functionEntry)}
 }
 
 func (h *structDefinitionEntry) GetValue() *EntryValue {
@@ -73,6 +75,19 @@ func (f *functionEntry) GetValue() *EntryValue {
 	return f.entryValue
 }
 
+type constantEntry struct {
+	//Entry type for constant, its IntValue or BoolValue is known at compile time
+	entryValue *EntryValue
+}
+
+func NewConstantEntry(t types.Type, intValue int, boolValue bool) *constantEntry {
+	return &constantEntry{&EntryValue{EntryType: t, IntValue: intValue, BoolValue: boolValue}}
+}
+
+func (c *constantEntry) GetValue() *EntryValue {
+	return c.entryValue
+}
+
 type lowLevelEntry struct {
 	//Entry type for int, bool and Unknown
 
@@ -91,6 +106,7 @@ type SymbolTable struct {
 	tableName         string
 	typeMap           map[string]Entry
 	fatherSymbolTable *SymbolTable
+	extends           bool // Whether the table continues the scope of its father
 }
 
 func (st *SymbolTable) String() string {
@@ -99,12 +115,17 @@ func (st *SymbolTable) String() string {
 
 func NewSymbolTable(tableName string) *SymbolTable {
 	//Create a symbol table without father
-	return &SymbolTable{tableName, make(map[string]Entry), nil}
+	return &SymbolTable{tableName, make(map[string]Entry), nil, false}
 }
 
 func NewWithFather(father *SymbolTable, tableName string) *SymbolTable {
 	//Create a symbol table with father
-	return &SymbolTable{tableName, map[string]Entry{}, father}
+	return &SymbolTable{tableName, map[string]Entry{}, father, false}
+}
+
+func Extend(scope *SymbolTable) *SymbolTable {
+	//Create a table for variables declared in the middle of a scope, only the code after them sees it
+	return &SymbolTable{scope.tableName, map[string]Entry{}, scope, true}
 }
 
 func (st *SymbolTable) GetRegisterLoc(id string) int {
@@ -127,6 +148,10 @@ func (st *SymbolTable) InsertWithNewReg(input string, t types.Type) Entry {
 	return st.typeMap[input]
 }
 
+func (st *SymbolTable) InsertConstant(input string, t types.Type, intValue int, boolValue bool) {
+	st.typeMap[input] = NewConstantEntry(t, intValue, boolValue)
+}
+
 func (st *SymbolTable) InsertStructDefinition(structName string, t types.Type, localST SymbolTable) {
 	st.typeMap[structName] = NewStructDefinition(t, localST)
 }
@@ -135,6 +160,20 @@ func (st *SymbolTable) InsertFunctionEntry(input string, t types.Type, localST *
 	st.typeMap[input] = NewFunctionEntry(t, localST, para, returnType)
 }
 
+func (st *SymbolTable) InsertMethodEntry(structName string, method string, t types.Type, localST *SymbolTable, para []types.Type, returnType types.Type) {
+	//Add the method to the method table of the struct, which must have been declared
+	entry, exist := st.ContainStructure(structName)
+	if !exist {
+		panic(fmt.Sprintf("Struct named %s don't exisit", structName))
+	}
+	entry.(*structDefinitionEntry).methods[method] = NewFunctionEntry(t, localST, para, returnType)
+}
+
+func MethodLabel(structName string, method string) string {
+	//Name of a method as a function, its struct name and its name cannot be used by a top level function
+	return structName + "." + method
+}
+
 func (st *SymbolTable) Contain(input string) (Entry, bool) {
 	//Check whether the key exist in the local symboltable or its ancestor
 	cur := st
@@ -171,19 +210,58 @@ func (st *SymbolTable) ContainGlobally(input string) (Entry, bool) {
 }
 
 func (st *SymbolTable) ContainLocally(input string) (Entry, bool) {
-	//Check whether the key exist in the local symboltable or its ancestor
-	exist, pre := st.typeMap[input]
-	if pre {
-		return exist, pre
-	} else {
-		return nil, false
+	//Check whether the key exist in the scope of the symboltable, including the tables it extends
+	cur := st
+	for {
+		if entry, pre := cur.typeMap[input]; pre {
+			return entry, pre
+		} else if cur.extends {
+			cur = cur.fatherSymbolTable
+		} else {
+			return nil, false
+		}
 	}
 }
 
 func (st *SymbolTable) ContainStructure(input string) (Entry, bool) {
 	//Check whether the structure has been declared and return its definition symboltable
 	entry, exist := st.Contain(input)
-	if exist && entry.GetValue().EntryType == types.StructTySig {
+	if _, isDefinition := entry.(*structDefinitionEntry); exist && isDefinition {
+		return entry, exist
+	} else {
+		return nil, false
+	}
+}
+
+func (st *SymbolTable) ContainConstant(input string) (Entry, bool) {
+	//Check whether the ident is a constant and return its entry
+	entry, exist := st.Contain(input)
+	if _, isConstant := entry.(*constantEntry); exist && isConstant {
+		return entry, exist
+	} else {
+		return nil, false
+	}
+}
+
+func (st *SymbolTable) ContainMethod(structName string, method string) (Entry, bool) {
+	//Look the method up in the method table of the struct
+	entry, exist := st.ContainStructure(structName)
+	if !exist {
+		return nil, false
+	}
+	if methodEntry, exist := entry.(*structDefinitionEntry).methods[method]; exist {
+		return methodEntry, true
+	}
+	return nil, false
+}
+
+func (st *SymbolTable) ContainFunction(input string) (Entry, bool) {
+	//Check whether the function has been declared and return its entry, methods are found by their label
+	if dot := strings.Index(input, "."); dot >= 0 {
+		return st.ContainMethod(input[:dot], input[dot+1:])
+	}
+	entry, exist := st.Contain(input)
+	if _, isFunction := entry.(*functionEntry); exist && isFunction {
 		return entry, exist
 	} else {
 		return nil, false
@@ -1,33 +1,43 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 const (
 	//keywords
-	EOF     = "eof"
-	IDENT   = "identification"
-	VAR     = "variable"
-	RETURN  = "return"
-	PRINT   = "print"
-	FOR     = "for"
-	IF      = "if"
-	ELSE    = "else"
-	NIL     = "nil"
-	FMT     = "fmt"
-	SCAN    = "Scan"
-	PACKAGE = "package"
-	TYPE    = "type"
-	IMPORT  = "import"
-	STRUCT  = "struct"
-	PRINTLN = "Println"
-	FUNC    = "function"
+	EOF      = "eof"
+	IDENT    = "identification"
+	VAR      = "variable"
+	RETURN   = "return"
+	PRINT    = "print"
+	FOR      = "for"
+	IF       = "if"
+	ELSE     = "else"
+	NIL      = "nil"
+	FMT      = "fmt"
+	SCAN     = "Scan"
+	PACKAGE  = "package"
+	TYPE     = "type"
+	IMPORT   = "import"
+	STRUCT   = "struct"
+	PRINTLN  = "Println"
+	FUNC     = "function"
+	BREAK    = "break"
+	CONTINUE = "continue"
+	SWITCH   = "switch"
+	CASE     = "case"
+	DEFAULT  = "default"
+	CONST    = "const"
 
 	//Value type
-	INT  = "int"
-	BOOL = "bool"
+	INT    = "int"
+	BOOL   = "bool"
+	STRING = "string"
 
 	//constant
 	NUMBER = "number"
+	STRLIT = "string literal"
 	TRUE   = "true"
 	FALSE  = "false"
 
@@ -52,31 +62,71 @@ const (
 	OR        = "||"
 	AND       = "&&"
 	AMPERSAND = "&"
+	PIPE      = "|"
+	CARET     = "^"
+	ANDNOT    = "&^"
+	LSHIFT    = "<<"
+	RSHIFT    = ">>"
 
 	ASSIGN  = "="
+	DEFINE  = ":="
 	COMMENT = "//"
 
+	PLUSASSIGN   = "+="
+	MINUSASSIGN  = "-="
+	MULASSIGN    = "*="
+	DIVASSIGN    = "/="
+	MODASSIGN    = "%="
+	ANDASSIGN    = "&="
+	ORASSIGN     = "|="
+	XORASSIGN    = "^="
+	ANDNOTASSIGN = "&^="
+	LSHIFTASSIGN = "<<="
+	RSHIFTASSIGN = ">>="
+	INC          = "++"
+	DEC          = "--"
+
 	//PUNCTUATOR
-	SEMICOLON  = "semicolon"
-	PUNCTUATOR = ","
-	LEFTPAR    = "left parenthesis"
-	RIGHTPAR   = "right parenthesis"
-	LEFTBRAC   = "left bracket"
-	RIGHTBRAC  = "right bracket"
-	COLON      = "colon"
-	DOUQUAT    = "double quotation"
-	SIGQUAT    = "single quotation"
+	SEMICOLON   = "semicolon"
+	PUNCTUATOR  = ","
+	LEFTPAR     = "left parenthesis"
+	RIGHTPAR    = "right parenthesis"
+	LEFTBRAC    = "left bracket"
+	RIGHTBRAC   = "right bracket"
+	LEFTSQUARE  = "left square bracket"
+	RIGHTSQUARE = "right square bracket"
+	COLON       = "colon"
+	SIGQUAT     = "single quotation"
 
 	//ERROR
 	INVALID = "error"
 )
 
+type Position struct {
+	File   string // Path of the source file
+	Line   int    // Line number, starting at 1
+	Column int    // Column number in bytes, starting at 1
+	Offset int    // Byte offset from the start of the file, starting at 0
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
-	Rows    int
+	Pos     Position
+}
+
+func New(Type TokenType, Literal string, Pos Position) *Token {
+	return &Token{Type: Type, Literal: Literal, Pos: Pos}
 }
 
-func New(Type TokenType, Literal string, Rows int) *Token {
-	return &Token{Type: Type, Literal: Literal, Rows: Rows}
+func (t *Token) End() Position {
+	//The position right after the last character of the token
+	end := t.Pos
+	end.Column += len(t.Literal)
+	end.Offset += len(t.Literal)
+	return end
 }
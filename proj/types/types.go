@@ -1,5 +1,10 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Type interface {
 	GetName() string
 	GetType() Type
@@ -17,6 +22,12 @@ func (boolTy *BoolTy) GetName() string {
 }
 func (boolTy *BoolTy) GetType() Type { return BoolTySig }
 
+// StringTy is a string, the address of a cell holding its length followed by its bytes, nil is ""
+type StringTy struct{}
+
+func (stringTy *StringTy) GetName() string { return "string" }
+func (stringTy *StringTy) GetType() Type   { return StringTySig }
+
 type UnknownTy struct {
 	typeStr string
 }
@@ -85,20 +96,155 @@ func (importTy *ImportTy) GetType() Type {
 	return ImportTySig
 }
 
+// ArrayTy is a fixed size array, its elements live in a heap cell allocated with the variable
+type ArrayTy struct {
+	elem   Type
+	length int
+}
+
+func NewArrayTy(elem Type, length int) *ArrayTy {
+	return &ArrayTy{elem: elem, length: length}
+}
+
+func (arrayTy *ArrayTy) GetName() string {
+	return fmt.Sprintf("[%d]%s", arrayTy.length, TypeString(arrayTy.elem))
+}
+
+func (arrayTy *ArrayTy) GetType() Type { return ArrayTySig }
+
+func (arrayTy *ArrayTy) Elem() Type { return arrayTy.elem }
+
+func (arrayTy *ArrayTy) Len() int { return arrayTy.length }
+
+// SliceTy is a slice, the address of a heap header holding its length, capacity and elements
+type SliceTy struct {
+	elem Type
+}
+
+func NewSliceTy(elem Type) *SliceTy {
+	return &SliceTy{elem: elem}
+}
+
+func (sliceTy *SliceTy) GetName() string {
+	return "[]" + TypeString(sliceTy.elem)
+}
+
+func (sliceTy *SliceTy) GetType() Type { return SliceTySig }
+
+func (sliceTy *SliceTy) Elem() Type { return sliceTy.elem }
+
+// TupleTy is the list of results of a function returning more than one value
+type TupleTy struct {
+	elems []Type
+}
+
+func NewTupleTy(elems []Type) *TupleTy {
+	return &TupleTy{elems: elems}
+}
+
+func (tupleTy *TupleTy) GetName() string {
+	names := []string{}
+	for _, elem := range tupleTy.elems {
+		names = append(names, TypeString(elem))
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func (tupleTy *TupleTy) GetType() Type { return TupleTySig }
+
+func (tupleTy *TupleTy) Elems() []Type { return tupleTy.elems }
+
 var IntTySig *IntTy
 var BoolTySig *BoolTy
+var StringTySig *StringTy
 var UnknownTySig *UnknownTy
 var ImportTySig *ImportTy
 var NilTySig *NilTy
 var FuncTySig *FunctTy
 var StructTySig *StructTy
+var ArrayTySig *ArrayTy
+var SliceTySig *SliceTy
+var TupleTySig *TupleTy
 
 func init() {
 	IntTySig = &IntTy{}
 	BoolTySig = &BoolTy{}
+	StringTySig = &StringTy{}
 	UnknownTySig = &UnknownTy{}
 	ImportTySig = &ImportTy{}
 	NilTySig = &NilTy{}
 	FuncTySig = &FunctTy{}
 	StructTySig = &StructTy{}
+	ArrayTySig = &ArrayTy{}
+	SliceTySig = &SliceTy{}
+	TupleTySig = &TupleTy{}
+}
+
+func IsStruct(t Type) bool {
+	//Check whether t is a struct (pointer) type
+	return t != nil && t.GetType() == StructTySig
+}
+
+func Results(t Type) []Type {
+	//Types of the values returned by a function with the result type t
+	switch ty := t.(type) {
+	case *TupleTy:
+		return ty.elems
+	case *NilTy:
+		return []Type{}
+	}
+	return []Type{t}
+}
+
+func IsArray(t Type) bool {
+	return t != nil && t.GetType() == ArrayTySig
+}
+
+func IsSlice(t Type) bool {
+	return t != nil && t.GetType() == SliceTySig
+}
+
+func ContainsArray(t Type) bool {
+	//Check whether t is an array or a slice with arrays among its elements
+	switch ty := t.(type) {
+	case *ArrayTy:
+		return true
+	case *SliceTy:
+		return ContainsArray(ty.elem)
+	}
+	return false
+}
+
+func TypeString(t Type) string {
+	//The type as written in the source, struct types are pointers
+	if t == nil {
+		return ""
+	}
+	if IsStruct(t) {
+		return "*" + t.GetName()
+	}
+	return t.GetName()
+}
+
+func Equal(a Type, b Type) bool {
+	/*
+		Check whether two types are the same type. Struct types are compared by
+		name since every declaration creates its own StructTy.
+	*/
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.GetType() != b.GetType() {
+		return false
+	}
+	return a.GetName() == b.GetName()
+}
+
+func AssignableTo(value Type, target Type) bool {
+	//Check whether a value of the given type can be stored in a location of the target type
+	if Equal(value, target) {
+		return true
+	}
+	// nil can be assigned to any struct pointer or slice
+	return (IsStruct(target) || IsSlice(target)) && value != nil && value.GetType() == NilTySig
 }